@@ -1,14 +1,21 @@
 package router
 
 import (
+	"net/http"
+
+	"github.com/go-redis/redis/v8"
 	"github.com/gorilla/mux"
 	"github.com/temuka-api-service/internal/controller"
+	"github.com/temuka-api-service/internal/rbac"
 	"github.com/temuka-api-service/internal/repository"
+	"github.com/temuka-api-service/internal/search"
 	"github.com/temuka-api-service/middleware"
+	"github.com/temuka-api-service/pkg/mailer"
+	"github.com/temuka-api-service/pkg/oidc"
 	"gorm.io/gorm"
 )
 
-func Routes(db *gorm.DB) *mux.Router {
+func Routes(db *gorm.DB, redisClient *redis.Client, mailService mailer.Mailer, services *Services) *mux.Router {
 	router := mux.NewRouter()
 
 	// Init repositories
@@ -23,105 +30,222 @@ func Routes(db *gorm.DB) *mux.Router {
 	reviewRepo := repository.NewReviewRepository(db)
 	locationRepo := repository.NewLocationRepository(db)
 	conversationRepo := repository.NewConversationRepository(db)
+	sessionRepo := services.SessionRepository
+	passwordResetRepo := repository.NewPasswordResetRepository(db)
+	twoFactorRepo := repository.NewTwoFactorRepository(db)
+	twoFactorChallengeRepo := repository.NewTwoFactorChallengeRepository(redisClient)
+	loginAttemptRepo := repository.NewLoginAttemptRepository(redisClient)
+	loginLockoutRepo := repository.NewLoginLockoutRepository(db)
+	userIdentityRepo := repository.NewUserIdentityRepository(db)
+	oidcStateRepo := repository.NewOIDCStateRepository(redisClient)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	accountRepo := services.AccountRepository
+	dataExportRepo := services.DataExportRepository
+	postReactionRepo := repository.NewPostReactionRepository(db)
+	bookmarkRepo := repository.NewBookmarkRepository(db)
+	tagRepo := repository.NewTagRepository(db)
+	postRevisionRepo := repository.NewPostRevisionRepository(db)
+	pollRepo := repository.NewPollRepository(db)
+	mediaRepo := repository.NewMediaRepository(db)
+
+	clk := services.Clock
+	oidcProviders := oidc.NewRegistry(oidc.ConfigsFromEnv(), nil)
+
+	// Init services
+	timelineService := services.TimelineService
+	recommendationService := services.RecommendationService
+	searchIndex := search.NewPostgresIndex(db)
+	tagService := services.TagService
+	publishingService := services.PublishingService
+	pollService := services.PollService
+	analyticsService := services.AnalyticsService
+	trendingService := services.TrendingService
+
+	// Init middlewares
+	authorizer := rbac.NewAuthorizer(communityRepo, moderatorRepo)
+	authMiddleware := middleware.NewAuthMiddleware(sessionRepo, userRepo, apiKeyRepo, authorizer)
 
 	// Init controllers
-	authController := controller.NewAuthController(userRepo)
-	userController := controller.NewUserController(userRepo)
-	postController := controller.NewPostController(postRepo, notificationRepo, userRepo, reportRepo, communityRepo, commentRepo)
+	authController := controller.NewAuthController(userRepo, sessionRepo, passwordResetRepo, twoFactorRepo, twoFactorChallengeRepo, loginAttemptRepo, loginLockoutRepo, mailService, clk)
+	twoFactorController := controller.NewTwoFactorController(userRepo, twoFactorRepo, clk)
+	oidcController := controller.NewOIDCController(oidcProviders, oidcStateRepo, userIdentityRepo, userRepo, sessionRepo, twoFactorChallengeRepo, clk)
+	userController := controller.NewUserController(userRepo, timelineService)
+	postController := controller.NewPostController(postRepo, notificationRepo, userRepo, reportRepo, communityRepo, commentRepo, postReactionRepo, bookmarkRepo, tagRepo, postRevisionRepo, mediaRepo, authorizer, timelineService, recommendationService, tagService, publishingService, pollService, analyticsService, trendingService, clk)
+	postReactionController := controller.NewPostReactionController(postReactionRepo, postRepo, userRepo, notificationRepo)
+	postRevisionController := controller.NewPostRevisionController(postRevisionRepo, postRepo, authorizer, tagService)
+	pollController := controller.NewPollController(pollRepo, postRepo, pollService)
+	analyticsController := controller.NewAnalyticsController(analyticsService, postRepo, authorizer, clk)
+	bookmarkController := controller.NewBookmarkController(bookmarkRepo, postRepo, universityRepo, communityRepo)
 	communityController := controller.NewCommunityController(communityRepo)
-	commentController := controller.NewCommentController(commentRepo, postRepo, notificationRepo, reportRepo)
+	commentController := controller.NewCommentController(commentRepo, postRepo, notificationRepo, reportRepo, authorizer, tagService)
 	notificationController := controller.NewNotificationController(notificationRepo)
 	moderatorController := controller.NewModeratorController(moderatorRepo, notificationRepo)
 	reportController := controller.NewReportController(reportRepo)
 	universityController := controller.NewUniversityController(universityRepo, reviewRepo)
 	locationController := controller.NewLocationController(locationRepo)
 	conversationController := controller.NewConversationController(conversationRepo, userRepo)
-	fileUploadController := controller.NewFileUploadController("uploads")
+	apiKeyController := controller.NewAPIKeyController(apiKeyRepo)
+	accountController := controller.NewAccountController(userRepo, accountRepo, dataExportRepo, sessionRepo, twoFactorRepo, clk)
+	searchController := controller.NewSearchController(searchIndex)
+	tagController := controller.NewTagController(tagService)
+	trendingController := controller.NewTrendingController(trendingService)
+	fileUploadController := controller.NewFileUploadController("uploads", mediaRepo)
 
 	// Init routers
 	authRouter := router.PathPrefix("/api/auth").Subrouter()
 	authRouter.HandleFunc("/login", authController.Login).Methods("POST")
+	authRouter.HandleFunc("/login/2fa", authController.LoginTwoFactor).Methods("POST")
 	authRouter.HandleFunc("/register", authController.Register).Methods("POST")
-	authRouter.HandleFunc("/resetPassword/{id}", authController.ResetPassword).Methods("POST")
+	authRouter.HandleFunc("/refresh", authController.RefreshToken).Methods("POST")
+	authRouter.Handle("/logout", authMiddleware.CheckAuth(middleware.RequireSession(http.HandlerFunc(authController.Logout)))).Methods("POST")
+	authRouter.Handle("/logoutAll", authMiddleware.CheckAuth(middleware.RequireSession(http.HandlerFunc(authController.LogoutAllDevices)))).Methods("POST")
+	authRouter.HandleFunc("/verifyEmail", authController.VerifyEmail).Methods("GET")
+	authRouter.HandleFunc("/resendVerification", authController.ResendVerificationEmail).Methods("POST")
+	authRouter.HandleFunc("/forgotPassword", authController.ForgotPassword).Methods("POST")
+	authRouter.HandleFunc("/resetPassword", authController.ResetPassword).Methods("POST")
+	authRouter.HandleFunc("/oidc/{provider}/login", oidcController.Login).Methods("GET")
+	authRouter.HandleFunc("/oidc/{provider}/callback", oidcController.Callback).Methods("GET")
+	authRouter.Handle("/2fa/enroll", authMiddleware.CheckAuth(middleware.RequireSession(http.HandlerFunc(twoFactorController.Enroll)))).Methods("POST")
+	authRouter.Handle("/2fa/confirm", authMiddleware.CheckAuth(middleware.RequireSession(http.HandlerFunc(twoFactorController.Confirm)))).Methods("POST")
+	authRouter.Handle("/2fa/disable", authMiddleware.CheckAuth(middleware.RequireSession(http.HandlerFunc(twoFactorController.Disable)))).Methods("POST")
 
 	userRouter := router.PathPrefix("/api/user").Subrouter()
-	userRouter.Use(middleware.CheckAuth)
-	userRouter.HandleFunc("", userController.CreateUser).Methods("POST")
+	userRouter.Use(authMiddleware.CheckAuth)
 	userRouter.HandleFunc("/{id}", userController.UpdateUser).Methods("PUT")
 	userRouter.HandleFunc("/search", userController.SearchUsers).Methods("GET")
 	userRouter.HandleFunc("/follow", userController.FollowUser).Methods("POST")
 	userRouter.HandleFunc("/followers", userController.GetFollowers).Methods("GET")
 	userRouter.HandleFunc("/{id}", userController.GetUserDetail).Methods("GET")
+	userRouter.HandleFunc("/{id}/analytics", analyticsController.GetUserAnalytics).Methods("GET")
 
 	postRouter := router.PathPrefix("/api/post").Subrouter()
-	postRouter.Use(middleware.CheckAuth)
-	postRouter.HandleFunc("", postController.CreatePost).Methods("POST")
+	postRouter.Use(authMiddleware.CheckAuth)
+	postRouter.Handle("", authMiddleware.RequireVerified(http.HandlerFunc(postController.CreatePost))).Methods("POST")
+	postRouter.HandleFunc("/drafts", postController.GetDraftPosts).Methods("GET")
 	postRouter.HandleFunc("/{id}", postController.GetPostDetail).Methods("GET")
 	postRouter.HandleFunc("/timeline/{user_id}", postController.GetTimelinePosts).Methods("GET")
+	postRouter.HandleFunc("/feed/recommended", postController.GetRecommendedPosts).Methods("GET")
 	postRouter.HandleFunc("/user/{user_id}", postController.GetUserPosts).Methods("GET")
-	postRouter.HandleFunc("/like/{id}", postController.LikePost).Methods("PUT")
+	postRouter.HandleFunc("/like/{id}", postReactionController.LikePost).Methods("PUT")
+	postRouter.HandleFunc("/{id}/reactions", postReactionController.GetReactions).Methods("GET")
+	postRouter.HandleFunc("/{id}/reactions", postReactionController.RemoveReaction).Methods("DELETE")
+	postRouter.HandleFunc("/{id}/reactions/{type}", postReactionController.SetReaction).Methods("PUT")
+	postRouter.Handle("/{id}/repost", authMiddleware.RequireVerified(http.HandlerFunc(postController.RepostPost))).Methods("POST")
+	postRouter.HandleFunc("/{id}/repost", postController.UndoRepost).Methods("DELETE")
+	postRouter.HandleFunc("/{id}/revisions", postRevisionController.GetRevisions).Methods("GET")
+	postRouter.HandleFunc("/{id}/revisions/{revision_id}/revert", postRevisionController.RevertPost).Methods("POST")
+	postRouter.HandleFunc("/{id}/analytics", analyticsController.GetPostAnalytics).Methods("GET")
+	postRouter.HandleFunc("/{id}/poll/vote", pollController.Vote).Methods("POST")
+	postRouter.HandleFunc("/{id}/poll/votes", pollController.GetVoters).Methods("GET")
+	postRouter.HandleFunc("/{id}/poll/stream", pollController.StreamResults).Methods("GET")
+	postRouter.Handle("/{id}/quote", authMiddleware.RequireVerified(http.HandlerFunc(postController.QuotePost))).Methods("POST")
 	postRouter.HandleFunc("/{id}", postController.DeletePost).Methods("DELETE")
 	postRouter.HandleFunc("/{id}", postController.UpdatePost).Methods("PUT")
 
 	commentRouter := router.PathPrefix("/api/comment").Subrouter()
-	commentRouter.Use(middleware.CheckAuth)
-	commentRouter.HandleFunc("", commentController.AddComment).Methods("POST")
+	commentRouter.Use(authMiddleware.CheckAuth)
+	commentRouter.Handle("", authMiddleware.RequireVerified(http.HandlerFunc(commentController.AddComment))).Methods("POST")
 	commentRouter.HandleFunc("/replies", commentController.ShowReplies).Methods("GET")
 	commentRouter.HandleFunc("/{commentId}", commentController.DeleteComment).Methods("DELETE")
 	commentRouter.HandleFunc("/show", commentController.ShowCommentsByPost).Methods("GET")
 
 	communityRouter := router.PathPrefix("/api/community").Subrouter()
-	communityRouter.Use(middleware.CheckAuth)
+	communityRouter.Use(authMiddleware.CheckAuth)
 	communityRouter.HandleFunc("", communityController.CreateCommunity).Methods("POST")
 	communityRouter.HandleFunc("", communityController.GetCommunities).Methods("GET")
 	communityRouter.HandleFunc("/join/{community_id}", communityController.JoinCommunity).Methods("POST")
 	communityRouter.HandleFunc("/post/{id}", communityController.GetCommunityPosts).Methods("GET")
 	communityRouter.HandleFunc("/user", communityController.GetUserJoinedCommunities).Methods("POST")
 	communityRouter.HandleFunc("/{slug}", communityController.GetCommunityDetail).Methods("GET")
-	communityRouter.HandleFunc("/{id}", communityController.DeleteCommunity).Methods("DELETE")
-	communityRouter.HandleFunc("/{id}", communityController.UpdateCommunity).Methods("PUT")
+	communityRouter.Handle("/{id}", authMiddleware.RequireCommunityPermission(rbac.PermissionDeleteCommunity, middleware.CommunityFromVar("id"))(http.HandlerFunc(communityController.DeleteCommunity))).Methods("DELETE")
+	communityRouter.Handle("/{id}", authMiddleware.RequireCommunityPermission(rbac.PermissionUpdateCommunity, middleware.CommunityFromVar("id"))(http.HandlerFunc(communityController.UpdateCommunity))).Methods("PUT")
 
 	fileRouter := router.PathPrefix("/api/file").Subrouter()
-	fileRouter.Use(middleware.CheckAuth)
+	fileRouter.Use(authMiddleware.CheckAuth)
 	fileRouter.HandleFunc("", fileUploadController.Upload).Methods("POST")
 
 	notificationRouter := router.PathPrefix("/api/notification").Subrouter()
+	notificationRouter.Use(authMiddleware.CheckAuth)
 	notificationRouter.HandleFunc("/list/{user_id}", notificationController.GetNotificationsByUser).Methods("GET")
 
 	moderatorRouter := router.PathPrefix("/api/moderator").Subrouter()
-	moderatorRouter.Use(middleware.CheckAuth)
-	moderatorRouter.HandleFunc("/send", moderatorController.SendModeratorRequest).Methods("POST")
-	moderatorRouter.HandleFunc("/{id}", moderatorController.RemoveModerator).Methods("DELETE")
+	moderatorRouter.Use(authMiddleware.CheckAuth)
+	moderatorRouter.Handle("/send", authMiddleware.RequireCommunityPermission(rbac.PermissionManageModerators, middleware.CommunityFromBody("community_id"))(http.HandlerFunc(moderatorController.SendModeratorRequest))).Methods("POST")
+	moderatorRouter.Handle("/{id}", authMiddleware.RequireCommunityPermission(rbac.PermissionManageModerators, middleware.CommunityOfModerator(moderatorRepo, "id"))(http.HandlerFunc(moderatorController.RemoveModerator))).Methods("DELETE")
 
 	reportRouter := router.PathPrefix("/api/report").Subrouter()
-	reportRouter.Use(middleware.CheckAuth)
+	reportRouter.Use(authMiddleware.CheckAuth)
 	reportRouter.HandleFunc("", reportController.CreateReport).Methods("POST")
-	reportRouter.HandleFunc("/{id}", reportController.DeleteReport).Methods("DELETE")
+	reportRouter.Handle("/{id}", middleware.RequirePermission(rbac.PermissionManageReports)(http.HandlerFunc(reportController.DeleteReport))).Methods("DELETE")
 
 	universityRouter := router.PathPrefix("/api/university").Subrouter()
-	universityRouter.Use(middleware.CheckAuth)
-	universityRouter.HandleFunc("", universityController.AddUniversity).Methods("POST")
-	universityRouter.HandleFunc("/{id}", universityController.UpdateUniversity).Methods("PUT")
+	universityRouter.Use(authMiddleware.CheckAuth)
+	universityRouter.Handle("", middleware.RequirePermission(rbac.PermissionManageUniversities)(http.HandlerFunc(universityController.AddUniversity))).Methods("POST")
+	universityRouter.Handle("/{id}", middleware.RequirePermission(rbac.PermissionManageUniversities)(http.HandlerFunc(universityController.UpdateUniversity))).Methods("PUT")
 	universityRouter.HandleFunc("/{slug}", universityController.GetUniversityDetail).Methods("GET")
 	universityRouter.HandleFunc("", universityController.GetUniversities).Methods("GET")
 	universityRouter.HandleFunc("/review", universityController.AddReview).Methods("POST")
 	universityRouter.HandleFunc("/review/university_id", universityController.GetUniversityReviews).Methods("GET")
 
 	locationRouter := router.PathPrefix("/api/location").Subrouter()
-	locationRouter.Use(middleware.CheckAuth)
-	locationRouter.HandleFunc("", locationController.AddLocation).Methods("POST")
+	locationRouter.Use(authMiddleware.CheckAuth)
+	locationRouter.Handle("", middleware.RequirePermission(rbac.PermissionManageLocations)(http.HandlerFunc(locationController.AddLocation))).Methods("POST")
 	locationRouter.HandleFunc("", locationController.GetLocations).Methods("GET")
-	locationRouter.HandleFunc("/{id}", locationController.UpdateLocation).Methods("PUT")
+	locationRouter.Handle("/{id}", middleware.RequirePermission(rbac.PermissionManageLocations)(http.HandlerFunc(locationController.UpdateLocation))).Methods("PUT")
 
 	conversationRouter := router.PathPrefix("/api/conversation").Subrouter()
-	conversationRouter.Use(middleware.CheckAuth)
+	conversationRouter.Use(authMiddleware.CheckAuth)
 	conversationRouter.HandleFunc("", conversationController.AddConversation).Methods("POST")
 	conversationRouter.HandleFunc("/{id}", conversationController.DeleteConversation).Methods("DELETE")
 	conversationRouter.HandleFunc("/{id}", conversationController.GetConversationDetail).Methods("GET")
 	conversationRouter.HandleFunc("/participant", conversationController.AddParticipant).Methods("POST")
-	conversationRouter.HandleFunc("/message", conversationController.AddMessage).Methods("POST")
+	conversationRouter.Handle("/message", authMiddleware.RequireVerified(http.HandlerFunc(conversationController.AddMessage))).Methods("POST")
 	conversationRouter.HandleFunc("/message/{conversation_id}", conversationController.RetrieveMessages).Methods("GET")
 	conversationRouter.HandleFunc("/all/{user_id}", conversationController.GetConversationsByUserID).Methods("GET")
 
+	apiKeyRouter := router.PathPrefix("/api/apikey").Subrouter()
+	apiKeyRouter.Use(authMiddleware.CheckAuth, middleware.RequireSession)
+	apiKeyRouter.HandleFunc("", apiKeyController.CreateAPIKey).Methods("POST")
+	apiKeyRouter.HandleFunc("", apiKeyController.GetAPIKeys).Methods("GET")
+	apiKeyRouter.HandleFunc("/{id}", apiKeyController.RevokeAPIKey).Methods("DELETE")
+
+	accountRouter := router.PathPrefix("/api/account").Subrouter()
+	accountRouter.Use(authMiddleware.CheckAuth, middleware.RequireSession)
+	accountRouter.HandleFunc("/export", accountController.RequestDataExport).Methods("POST")
+	accountRouter.HandleFunc("/export/{id}", accountController.GetDataExport).Methods("GET")
+	accountRouter.HandleFunc("/export/{id}/download", accountController.DownloadDataExport).Methods("GET")
+	accountRouter.HandleFunc("/delete", accountController.RequestAccountDeletion).Methods("POST")
+	accountRouter.HandleFunc("/delete/cancel", accountController.CancelAccountDeletion).Methods("POST")
+
+	bookmarkRouter := router.PathPrefix("/api/bookmark").Subrouter()
+	bookmarkRouter.Use(authMiddleware.CheckAuth)
+	bookmarkRouter.HandleFunc("", bookmarkController.AddBookmark).Methods("POST")
+	bookmarkRouter.HandleFunc("", bookmarkController.GetBookmarks).Methods("GET")
+	bookmarkRouter.HandleFunc("/order", bookmarkController.ReorderBookmarks).Methods("PUT")
+	bookmarkRouter.HandleFunc("/collection", bookmarkController.CreateCollection).Methods("POST")
+	bookmarkRouter.HandleFunc("/collection", bookmarkController.GetCollections).Methods("GET")
+	bookmarkRouter.HandleFunc("/collection/{id}", bookmarkController.RenameCollection).Methods("PUT")
+	bookmarkRouter.HandleFunc("/collection/{id}", bookmarkController.DeleteCollection).Methods("DELETE")
+	bookmarkRouter.HandleFunc("/{id}", bookmarkController.RemoveBookmark).Methods("DELETE")
+
+	searchRouter := router.PathPrefix("/api/search").Subrouter()
+	searchRouter.Use(authMiddleware.CheckAuth)
+	searchRouter.HandleFunc("", searchController.Search).Methods("GET")
+
+	tagRouter := router.PathPrefix("/api/tag").Subrouter()
+	tagRouter.Use(authMiddleware.CheckAuth)
+	tagRouter.HandleFunc("/trending", tagController.GetTrendingTags).Methods("GET")
+	tagRouter.HandleFunc("/{tag}", postController.GetTagPosts).Methods("GET")
+
+	trendingRouter := router.PathPrefix("/api/trending").Subrouter()
+	trendingRouter.Use(authMiddleware.CheckAuth)
+	trendingRouter.HandleFunc("/posts", postController.GetTrendingPosts).Methods("GET")
+	trendingRouter.HandleFunc("/communities", trendingController.GetTrendingCommunities).Methods("GET")
+
+	adminRouter := router.PathPrefix("/api/admin").Subrouter()
+	adminRouter.Use(authMiddleware.CheckAuth)
+	adminRouter.Handle("/user/{id}/role", middleware.RequirePermission(rbac.PermissionManageRoles)(http.HandlerFunc(userController.UpdateUserRole))).Methods("PUT")
+	adminRouter.Handle("/lockouts", middleware.RequirePermission(rbac.PermissionViewSecurityEvents)(http.HandlerFunc(authController.GetLoginLockouts))).Methods("GET")
+
 	return router
 }
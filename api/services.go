@@ -0,0 +1,52 @@
+package router
+
+import (
+	"github.com/go-redis/redis/v8"
+	"github.com/temuka-api-service/internal/feed"
+	"github.com/temuka-api-service/internal/repository"
+	"github.com/temuka-api-service/pkg/clock"
+	"gorm.io/gorm"
+)
+
+// Services holds what the routes and the background workers share. They are built once, so both
+// run on the same services and the same clock.
+type Services struct {
+	Clock                clock.Clock
+	SessionRepository    repository.SessionRepository
+	AccountRepository    repository.AccountRepository
+	DataExportRepository repository.DataExportRepository
+	LockRepository       repository.LockRepository
+
+	TimelineService       feed.TimelineService
+	RecommendationService feed.RecommendationService
+	TagService            feed.TagService
+	PublishingService     feed.PublishingService
+	PollService           feed.PollService
+	AnalyticsService      feed.AnalyticsService
+	TrendingService       feed.TrendingService
+}
+
+func NewServices(db *gorm.DB, redisClient *redis.Client, clk clock.Clock) *Services {
+	postRepo := repository.NewPostRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
+
+	timelineService := feed.NewTimelineService(postRepo, userRepo, repository.NewTimelineRepository(redisClient))
+	tagService := feed.NewTagService(repository.NewTagRepository(db), userRepo, notificationRepo, clk)
+
+	return &Services{
+		Clock:                clk,
+		SessionRepository:    repository.NewSessionRepository(redisClient),
+		AccountRepository:    repository.NewAccountRepository(db),
+		DataExportRepository: repository.NewDataExportRepository(db),
+		LockRepository:       repository.NewLockRepository(redisClient),
+
+		TimelineService:       timelineService,
+		RecommendationService: feed.NewRecommendationService(userRepo, repository.NewRecommendationRepository(db), clk),
+		TagService:            tagService,
+		PublishingService:     feed.NewPublishingService(postRepo, tagService, timelineService, clk),
+		PollService:           feed.NewPollService(repository.NewPollRepository(db), repository.NewPollStreamRepository(redisClient), postRepo, notificationRepo, clk),
+		AnalyticsService:      feed.NewAnalyticsService(repository.NewAnalyticsRepository(db), repository.NewViewCounterRepository(redisClient), clk),
+		TrendingService:       feed.NewTrendingService(repository.NewTrendingRepository(db), repository.NewTrendingRankingRepository(redisClient), postRepo, repository.NewCommunityRepository(db), clk),
+	}
+}
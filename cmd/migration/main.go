@@ -5,6 +5,8 @@ import (
 
 	"github.com/temuka-api-service/config"
 	"github.com/temuka-api-service/internal/model"
+	"github.com/temuka-api-service/internal/repository"
+	"github.com/temuka-api-service/internal/search"
 )
 
 func main() {
@@ -32,9 +34,53 @@ func main() {
 		&model.Review{},
 		&model.Major{},
 		&model.MajorReview{},
+		&model.PasswordResetToken{},
+		&model.TwoFactorRecoveryCode{},
+		&model.LoginLockout{},
+		&model.UserIdentity{},
+		&model.APIKey{},
+		&model.DataExport{},
+		&model.PostReaction{},
+		&model.BookmarkCollection{},
+		&model.Bookmark{},
+		&model.Hashtag{},
+		&model.PostHashtag{},
+		&model.CommentHashtag{},
+		&model.PostMention{},
+		&model.CommentMention{},
+		&model.PostRevision{},
+		&model.Poll{},
+		&model.PollOption{},
+		&model.PollVote{},
+		&model.Media{},
+		&model.PostMedia{},
+		&model.PostViewStat{},
+		&model.AuthorViewStat{},
 	); err != nil {
 		log.Fatalf("Failed to auto-migrate database: %v", err)
 	}
 
+	// Likes used to live in the post_likes join table; carry them over as like reactions.
+	if config.Database.Migrator().HasTable("post_likes") {
+		if err := config.Database.Exec(`INSERT INTO post_reactions (post_id, user_id, type, created_at, updated_at)
+			SELECT post_id, user_id, ?, now(), now() FROM post_likes
+			ON CONFLICT (post_id, user_id) DO NOTHING`, model.ReactionLike).Error; err != nil {
+			log.Fatalf("Failed to migrate post likes: %v", err)
+		}
+	}
+
+	// Posts written before publishing could be scheduled went live when they were created.
+	if err := config.Database.Exec(`UPDATE posts SET published_at = created_at
+		WHERE published_at IS NULL AND status = ?`, model.PostStatusPublished).Error; err != nil {
+		log.Fatalf("Failed to backfill post publish times: %v", err)
+	}
+
+	searchIndexes := append(repository.UserSearchIndexes, search.PostgresIndexes()...)
+	for _, statement := range searchIndexes {
+		if err := config.Database.Exec(statement).Error; err != nil {
+			log.Fatalf("Failed to create search index: %v", err)
+		}
+	}
+
 	log.Println("Database migration completed successfully.")
 }
@@ -4,10 +4,15 @@ import (
 	"context"
 	"log"
 	"net/http"
+	"os"
 
 	router "github.com/temuka-api-service/api"
 	"github.com/temuka-api-service/config"
 	"github.com/temuka-api-service/internal/queue"
+	"github.com/temuka-api-service/internal/worker"
+	"github.com/temuka-api-service/pkg/clock"
+	httputil "github.com/temuka-api-service/pkg/http"
+	"github.com/temuka-api-service/pkg/mailer"
 	"gorm.io/gorm"
 )
 
@@ -15,7 +20,7 @@ func EnableCors(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "http://localhost:4000")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key")
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusNoContent)
 			return
@@ -35,7 +40,17 @@ func main() {
 	config.InitRedis()
 	config.InitS3()
 
-	router := router.Routes(db)
+	if err := httputil.SetTrustedProxies(os.Getenv("TRUSTED_PROXIES")); err != nil {
+		log.Fatal(err)
+	}
+
+	mailService, err := mailer.NewMailerFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	services := router.NewServices(db, config.RedisClient, clock.New())
+	router := router.Routes(db, config.RedisClient, mailService, services)
 	protectedRoutes := EnableCors(router)
 
 	http.HandleFunc("/chat", config.HandleWebSocket)
@@ -43,6 +58,13 @@ func main() {
 
 	queue.StartListening(context.Background())
 
+	worker.NewDataExportWorker(services.DataExportRepository, services.AccountRepository, "exports", services.Clock).Start(context.Background())
+	worker.NewAccountDeletionWorker(services.AccountRepository, services.SessionRepository, services.Clock).Start(context.Background())
+	worker.NewPostSchedulerWorker(services.PublishingService, services.LockRepository).Start(context.Background())
+	worker.NewPollCloseWorker(services.PollService).Start(context.Background())
+	worker.NewViewFlushWorker(services.AnalyticsService, services.LockRepository).Start(context.Background())
+	worker.NewTrendingWorker(services.TrendingService, services.LockRepository).Start(context.Background())
+
 	http.Handle("/", protectedRoutes)
 	log.Println("Server is listening on port 3200")
 	log.Fatal(http.ListenAndServe("0.0.0.0:3200", nil))
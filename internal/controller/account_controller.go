@@ -0,0 +1,234 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/temuka-api-service/internal/model"
+	"github.com/temuka-api-service/internal/repository"
+	"github.com/temuka-api-service/pkg/clock"
+	httputil "github.com/temuka-api-service/pkg/http"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const accountDeletionGracePeriod = 14 * 24 * time.Hour
+
+type AccountController interface {
+	RequestDataExport(w http.ResponseWriter, r *http.Request)
+	GetDataExport(w http.ResponseWriter, r *http.Request)
+	DownloadDataExport(w http.ResponseWriter, r *http.Request)
+	RequestAccountDeletion(w http.ResponseWriter, r *http.Request)
+	CancelAccountDeletion(w http.ResponseWriter, r *http.Request)
+}
+
+type AccountControllerImpl struct {
+	UserRepository       repository.UserRepository
+	AccountRepository    repository.AccountRepository
+	DataExportRepository repository.DataExportRepository
+	SessionRepository    repository.SessionRepository
+	TwoFactorRepository  repository.TwoFactorRepository
+	Clock                clock.Clock
+}
+
+func NewAccountController(userRepo repository.UserRepository, accountRepo repository.AccountRepository, exportRepo repository.DataExportRepository, sessionRepo repository.SessionRepository, twoFactorRepo repository.TwoFactorRepository, clk clock.Clock) AccountController {
+	return &AccountControllerImpl{
+		UserRepository:       userRepo,
+		AccountRepository:    accountRepo,
+		DataExportRepository: exportRepo,
+		SessionRepository:    sessionRepo,
+		TwoFactorRepository:  twoFactorRepo,
+		Clock:                clk,
+	}
+}
+
+func (c *AccountControllerImpl) RequestDataExport(w http.ResponseWriter, r *http.Request) {
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	latestExport, err := c.DataExportRepository.GetLatestUserDataExport(context.Background(), principal.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving data exports"})
+		return
+	}
+	if latestExport != nil && (latestExport.Status == model.DataExportStatusPending || latestExport.Status == model.DataExportStatusProcessing) {
+		httputil.WriteResponse(w, http.StatusConflict, map[string]string{"error": "A data export is already being prepared"})
+		return
+	}
+
+	newExport := model.DataExport{
+		UserID: principal.ID,
+		Status: model.DataExportStatusPending,
+	}
+
+	if err := c.DataExportRepository.CreateDataExport(context.Background(), &newExport); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error requesting data export"})
+		return
+	}
+
+	response := struct {
+		Message string           `json:"message"`
+		Data    model.DataExport `json:"data"`
+	}{
+		Message: "Data export has been requested, check its status to download it once it is ready",
+		Data:    newExport,
+	}
+
+	httputil.WriteResponse(w, http.StatusAccepted, response)
+}
+
+func (c *AccountControllerImpl) GetDataExport(w http.ResponseWriter, r *http.Request) {
+	export, ok := c.ownedDataExport(w, r)
+	if !ok {
+		return
+	}
+
+	response := struct {
+		Message string           `json:"message"`
+		Data    model.DataExport `json:"data"`
+	}{
+		Message: "Data export has been retrieved",
+		Data:    *export,
+	}
+
+	httputil.WriteResponse(w, http.StatusOK, response)
+}
+
+func (c *AccountControllerImpl) DownloadDataExport(w http.ResponseWriter, r *http.Request) {
+	export, ok := c.ownedDataExport(w, r)
+	if !ok {
+		return
+	}
+
+	if export.Status != model.DataExportStatusCompleted || export.FilePath == "" {
+		httputil.WriteResponse(w, http.StatusConflict, map[string]string{"error": "Data export is not ready yet"})
+		return
+	}
+
+	if export.ExpiresAt != nil && c.Clock.Now().After(*export.ExpiresAt) {
+		httputil.WriteResponse(w, http.StatusGone, map[string]string{"error": "Data export has expired"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"temuka-export-%d.zip\"", export.ID))
+	http.ServeFile(w, r, export.FilePath)
+}
+
+func (c *AccountControllerImpl) ownedDataExport(w http.ResponseWriter, r *http.Request) (*model.DataExport, bool) {
+	vars := mux.Vars(r)
+	exportIDstr := vars["id"]
+
+	exportID, err := strconv.Atoi(exportIDstr)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid export id"})
+		return nil, false
+	}
+
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	export, err := c.DataExportRepository.GetDataExportByID(context.Background(), exportID)
+	if err != nil || export.UserID != principal.ID {
+		httputil.WriteResponse(w, http.StatusNotFound, map[string]string{"error": "Data export not found"})
+		return nil, false
+	}
+
+	return export, true
+}
+
+func (c *AccountControllerImpl) RequestAccountDeletion(w http.ResponseWriter, r *http.Request) {
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	var requestBody struct {
+		Password     string `json:"password"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+
+	if err := httputil.ReadRequest(r, &requestBody); err != nil {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	user, err := c.UserRepository.GetUserByID(context.Background(), principal.ID)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusNotFound, map[string]string{"error": "User not found"})
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(requestBody.Password)); err != nil {
+		httputil.WriteResponse(w, http.StatusUnauthorized, map[string]string{"error": "Invalid password or code"})
+		return
+	}
+
+	if user.TwoFactorEnabled {
+		verified, err := verifySecondFactor(context.Background(), c.TwoFactorRepository, user, requestBody.Code, requestBody.RecoveryCode, c.Clock.Now())
+		if err != nil {
+			httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error verifying code"})
+			return
+		}
+		if !verified {
+			httputil.WriteResponse(w, http.StatusUnauthorized, map[string]string{"error": "Invalid password or code"})
+			return
+		}
+	}
+
+	scheduledAt := c.Clock.Now().Add(accountDeletionGracePeriod)
+	if err := c.AccountRepository.ScheduleDeletion(context.Background(), user.ID, scheduledAt); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error scheduling account deletion"})
+		return
+	}
+
+	if err := c.SessionRepository.DeleteUserSessions(context.Background(), user.ID); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error revoking sessions"})
+		return
+	}
+
+	response := struct {
+		Message             string    `json:"message"`
+		DeletionScheduledAt time.Time `json:"deletion_scheduled_at"`
+	}{
+		Message:             "Account deletion has been scheduled, log in again and cancel it before the date below to keep your account",
+		DeletionScheduledAt: scheduledAt,
+	}
+
+	httputil.WriteResponse(w, http.StatusOK, response)
+}
+
+func (c *AccountControllerImpl) CancelAccountDeletion(w http.ResponseWriter, r *http.Request) {
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	cancelled, err := c.AccountRepository.CancelDeletion(context.Background(), principal.ID)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error cancelling account deletion"})
+		return
+	}
+	if !cancelled {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Account deletion is not scheduled"})
+		return
+	}
+
+	response := struct {
+		Message string `json:"message"`
+	}{
+		Message: "Account deletion has been cancelled",
+	}
+
+	httputil.WriteResponse(w, http.StatusOK, response)
+}
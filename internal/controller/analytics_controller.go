@@ -0,0 +1,168 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/temuka-api-service/internal/feed"
+	"github.com/temuka-api-service/internal/model"
+	"github.com/temuka-api-service/internal/rbac"
+	"github.com/temuka-api-service/internal/repository"
+	"github.com/temuka-api-service/pkg/clock"
+	httputil "github.com/temuka-api-service/pkg/http"
+)
+
+type AnalyticsController interface {
+	GetPostAnalytics(w http.ResponseWriter, r *http.Request)
+	GetUserAnalytics(w http.ResponseWriter, r *http.Request)
+}
+
+type AnalyticsControllerImpl struct {
+	AnalyticsService feed.AnalyticsService
+	PostRepository   repository.PostRepository
+	Authorizer       rbac.Authorizer
+	Clock            clock.Clock
+}
+
+func NewAnalyticsController(analyticsService feed.AnalyticsService, postRepo repository.PostRepository, authorizer rbac.Authorizer, clk clock.Clock) AnalyticsController {
+	return &AnalyticsControllerImpl{
+		AnalyticsService: analyticsService,
+		PostRepository:   postRepo,
+		Authorizer:       authorizer,
+		Clock:            clk,
+	}
+}
+
+// GetPostAnalytics reports the reach of a post to its author, admins and the moderators of its
+// community.
+func (c *AnalyticsControllerImpl) GetPostAnalytics(w http.ResponseWriter, r *http.Request) {
+	postID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid post id"})
+		return
+	}
+
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	from, to, ok := c.requestDateRange(w, r)
+	if !ok {
+		return
+	}
+
+	post, err := c.PostRepository.GetPostDetailByID(context.Background(), postID)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusNotFound, map[string]string{"error": "Post not found"})
+		return
+	}
+
+	allowed := post.UserID == principal.ID || principal.HasRole(rbac.RoleAdmin)
+	if !allowed && post.CommunityID != nil {
+		allowed, err = c.Authorizer.HasCommunityPermission(context.Background(), principal.Roles, principal.ID, *post.CommunityID, rbac.PermissionModerateCommunity)
+		if err != nil {
+			httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error checking permissions"})
+			return
+		}
+	}
+	if !allowed {
+		httputil.WriteResponse(w, http.StatusForbidden, map[string]string{"error": "You are not allowed to view the analytics of this post"})
+		return
+	}
+
+	analytics, err := c.AnalyticsService.PostAnalytics(context.Background(), postID, from, to)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving analytics"})
+		return
+	}
+
+	response := struct {
+		Message string          `json:"message"`
+		Data    model.Analytics `json:"data"`
+	}{
+		Message: "Post analytics have been retrieved",
+		Data:    *analytics,
+	}
+
+	httputil.WriteResponse(w, http.StatusOK, response)
+}
+
+// GetUserAnalytics reports the reach of all posts of a user, to that user and to admins.
+func (c *AnalyticsControllerImpl) GetUserAnalytics(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid user id"})
+		return
+	}
+
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	if userID != principal.ID && !principal.HasRole(rbac.RoleAdmin) {
+		httputil.WriteResponse(w, http.StatusForbidden, map[string]string{"error": "You are not allowed to view the analytics of this user"})
+		return
+	}
+
+	from, to, ok := c.requestDateRange(w, r)
+	if !ok {
+		return
+	}
+
+	analytics, err := c.AnalyticsService.AuthorAnalytics(context.Background(), userID, from, to)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving analytics"})
+		return
+	}
+
+	response := struct {
+		Message string          `json:"message"`
+		Data    model.Analytics `json:"data"`
+	}{
+		Message: "User analytics have been retrieved",
+		Data:    *analytics,
+	}
+
+	httputil.WriteResponse(w, http.StatusOK, response)
+}
+
+// requestDateRange reads the from and to query parameters as UTC dates, writing a 400 response when
+// they are invalid. The range defaults to the last feed.DefaultAnalyticsDays days up to today.
+func (c *AnalyticsControllerImpl) requestDateRange(w http.ResponseWriter, r *http.Request) (time.Time, time.Time, bool) {
+	now := c.Clock.Now().UTC()
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if toStr := r.URL.Query().Get("to"); toStr != "" {
+		parsed, err := time.Parse(model.AnalyticsDateFormat, toStr)
+		if err != nil {
+			httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid to date, expected YYYY-MM-DD"})
+			return time.Time{}, time.Time{}, false
+		}
+		to = parsed
+	}
+
+	from := to.AddDate(0, 0, 1-feed.DefaultAnalyticsDays)
+	if fromStr := r.URL.Query().Get("from"); fromStr != "" {
+		parsed, err := time.Parse(model.AnalyticsDateFormat, fromStr)
+		if err != nil {
+			httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid from date, expected YYYY-MM-DD"})
+			return time.Time{}, time.Time{}, false
+		}
+		from = parsed
+	}
+
+	if from.After(to) {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "from must not be after to"})
+		return time.Time{}, time.Time{}, false
+	}
+	if to.Sub(from) >= feed.MaxAnalyticsDays*24*time.Hour {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("Analytics cover at most %d days", feed.MaxAnalyticsDays)})
+		return time.Time{}, time.Time{}, false
+	}
+	return from, to, true
+}
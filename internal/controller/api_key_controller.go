@@ -0,0 +1,183 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/temuka-api-service/internal/model"
+	"github.com/temuka-api-service/internal/rbac"
+	"github.com/temuka-api-service/internal/repository"
+	httputil "github.com/temuka-api-service/pkg/http"
+	"github.com/temuka-api-service/pkg/token"
+)
+
+const maxAPIKeysPerUser = 20
+
+type APIKeyController interface {
+	CreateAPIKey(w http.ResponseWriter, r *http.Request)
+	GetAPIKeys(w http.ResponseWriter, r *http.Request)
+	RevokeAPIKey(w http.ResponseWriter, r *http.Request)
+}
+
+type APIKeyControllerImpl struct {
+	APIKeyRepository repository.APIKeyRepository
+}
+
+func NewAPIKeyController(apiKeyRepo repository.APIKeyRepository) APIKeyController {
+	return &APIKeyControllerImpl{
+		APIKeyRepository: apiKeyRepo,
+	}
+}
+
+func (c *APIKeyControllerImpl) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	var requestBody struct {
+		Name          string   `json:"name"`
+		Scopes        []string `json:"scopes"`
+		ExpiresInDays int      `json:"expires_in_days"`
+	}
+
+	if err := httputil.ReadRequest(r, &requestBody); err != nil {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	requestBody.Name = strings.TrimSpace(requestBody.Name)
+	if requestBody.Name == "" {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Name is required"})
+		return
+	}
+
+	if len(requestBody.Scopes) == 0 {
+		requestBody.Scopes = []string{rbac.ScopeRead}
+	}
+	for _, scope := range requestBody.Scopes {
+		if !rbac.IsValidScope(scope) {
+			httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid scope " + scope})
+			return
+		}
+	}
+
+	if requestBody.ExpiresInDays < 0 {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid expiry"})
+		return
+	}
+
+	existingKeys, err := c.APIKeyRepository.GetUserAPIKeys(context.Background(), principal.ID)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving API keys"})
+		return
+	}
+
+	activeKeys := 0
+	for _, existingKey := range existingKeys {
+		if existingKey.RevokedAt == nil {
+			activeKeys++
+		}
+	}
+	if activeKeys >= maxAPIKeysPerUser {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "You have reached the maximum number of API keys"})
+		return
+	}
+
+	rawKey, prefix, keyHash, err := token.GenerateAPIKey()
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error creating API key"})
+		return
+	}
+
+	newAPIKey := model.APIKey{
+		UserID:  principal.ID,
+		Name:    requestBody.Name,
+		Prefix:  prefix,
+		KeyHash: keyHash,
+		Scopes:  strings.Join(requestBody.Scopes, ","),
+	}
+
+	if requestBody.ExpiresInDays > 0 {
+		expiresAt := time.Now().Add(time.Duration(requestBody.ExpiresInDays) * 24 * time.Hour)
+		newAPIKey.ExpiresAt = &expiresAt
+	}
+
+	if err := c.APIKeyRepository.CreateAPIKey(context.Background(), &newAPIKey); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error creating API key"})
+		return
+	}
+
+	response := struct {
+		Message string       `json:"message"`
+		Key     string       `json:"key"`
+		Data    model.APIKey `json:"data"`
+	}{
+		Message: "API key has been created, copy it now because it will not be shown again",
+		Key:     rawKey,
+		Data:    newAPIKey,
+	}
+
+	httputil.WriteResponse(w, http.StatusOK, response)
+}
+
+func (c *APIKeyControllerImpl) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	apiKeys, err := c.APIKeyRepository.GetUserAPIKeys(context.Background(), principal.ID)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving API keys"})
+		return
+	}
+
+	response := struct {
+		Message string         `json:"message"`
+		Data    []model.APIKey `json:"data"`
+	}{
+		Message: "API keys have been retrieved",
+		Data:    apiKeys,
+	}
+
+	httputil.WriteResponse(w, http.StatusOK, response)
+}
+
+func (c *APIKeyControllerImpl) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	apiKeyIDstr := vars["id"]
+
+	apiKeyID, err := strconv.Atoi(apiKeyIDstr)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid API key id"})
+		return
+	}
+
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	revoked, err := c.APIKeyRepository.RevokeAPIKey(context.Background(), apiKeyID, principal.ID)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error revoking API key"})
+		return
+	}
+	if !revoked {
+		httputil.WriteResponse(w, http.StatusNotFound, map[string]string{"error": "API key not found"})
+		return
+	}
+
+	response := struct {
+		Message string `json:"message"`
+	}{
+		Message: "API key has been revoked",
+	}
+
+	httputil.WriteResponse(w, http.StatusOK, response)
+}
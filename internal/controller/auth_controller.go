@@ -2,32 +2,124 @@ package controller
 
 import (
 	"context"
+	"fmt"
+	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
-	"github.com/dgrijalva/jwt-go"
-	"github.com/gorilla/mux"
 	"github.com/temuka-api-service/internal/model"
+	"github.com/temuka-api-service/internal/rbac"
 	"github.com/temuka-api-service/internal/repository"
+	"github.com/temuka-api-service/pkg/clock"
 	httputil "github.com/temuka-api-service/pkg/http"
+	"github.com/temuka-api-service/pkg/mailer"
+	"github.com/temuka-api-service/pkg/redis"
+	"github.com/temuka-api-service/pkg/token"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type AuthController interface {
 	Register(w http.ResponseWriter, r *http.Request)
 	Login(w http.ResponseWriter, r *http.Request)
+	LoginTwoFactor(w http.ResponseWriter, r *http.Request)
+	RefreshToken(w http.ResponseWriter, r *http.Request)
+	Logout(w http.ResponseWriter, r *http.Request)
+	LogoutAllDevices(w http.ResponseWriter, r *http.Request)
+	VerifyEmail(w http.ResponseWriter, r *http.Request)
+	ResendVerificationEmail(w http.ResponseWriter, r *http.Request)
+	ForgotPassword(w http.ResponseWriter, r *http.Request)
 	ResetPassword(w http.ResponseWriter, r *http.Request)
+	GetLoginLockouts(w http.ResponseWriter, r *http.Request)
 }
 
+const (
+	passwordResetTokenTTL     = 30 * time.Minute
+	verificationEmailCooldown = time.Minute
+
+	loginAttemptWindow    = 15 * time.Minute
+	maxEmailLoginFailures = 5
+	maxIPLoginFailures    = 20
+	loginLockoutBase      = time.Minute
+	loginLockoutMax       = time.Hour
+	loginLockoutLevelTTL  = 24 * time.Hour
+
+	// A challenge is dropped after maxChallengeFailures wrong codes, and a user who sends
+	// maxTwoFactorFailures wrong codes across challenges is locked out like a failed login.
+	maxChallengeFailures = 3
+	maxTwoFactorFailures = 5
+)
+
+// dummyPasswordHash is compared against when the email is unknown so that a missing account
+// takes as long to reject as a wrong password.
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("temuka-dummy-password"), bcrypt.DefaultCost)
+
 type AuthControllerImpl struct {
-	UserRepository repository.UserRepository
+	UserRepository          repository.UserRepository
+	SessionRepository       repository.SessionRepository
+	PasswordResetRepository repository.PasswordResetRepository
+	TwoFactorRepository     repository.TwoFactorRepository
+	ChallengeRepository     repository.TwoFactorChallengeRepository
+	LoginAttemptRepository  repository.LoginAttemptRepository
+	LoginLockoutRepository  repository.LoginLockoutRepository
+	Mailer                  mailer.Mailer
+	Clock                   clock.Clock
 }
 
-func NewAuthController(userRepository repository.UserRepository) AuthController {
+func NewAuthController(userRepository repository.UserRepository, sessionRepository repository.SessionRepository, passwordResetRepository repository.PasswordResetRepository, twoFactorRepository repository.TwoFactorRepository, challengeRepository repository.TwoFactorChallengeRepository, loginAttemptRepository repository.LoginAttemptRepository, loginLockoutRepository repository.LoginLockoutRepository, mailService mailer.Mailer, clk clock.Clock) AuthController {
 	return &AuthControllerImpl{
-		UserRepository: userRepository,
+		UserRepository:          userRepository,
+		SessionRepository:       sessionRepository,
+		PasswordResetRepository: passwordResetRepository,
+		TwoFactorRepository:     twoFactorRepository,
+		ChallengeRepository:     challengeRepository,
+		LoginAttemptRepository:  loginAttemptRepository,
+		LoginLockoutRepository:  loginLockoutRepository,
+		Mailer:                  mailService,
+		Clock:                   clk,
+	}
+}
+
+type sessionTokens struct {
+	AccessToken  string
+	RefreshToken string
+}
+
+// issueSession starts a new server-side session for the user and returns its token pair.
+func issueSession(ctx context.Context, sessionRepo repository.SessionRepository, r *http.Request, user *model.User, now time.Time) (*sessionTokens, error) {
+	sessionID, err := token.GenerateRandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, refreshTokenHash, err := token.GenerateRefreshToken(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	session := model.Session{
+		ID:               sessionID,
+		UserID:           user.ID,
+		RefreshTokenHash: refreshTokenHash,
+		UserAgent:        r.UserAgent(),
+		IPAddress:        httputil.ClientIP(r),
+		CreatedAt:        now,
+		LastUsedAt:       now,
+	}
+
+	if err := sessionRepo.CreateSession(ctx, &session, token.RefreshTokenTTL); err != nil {
+		return nil, err
 	}
+
+	accessToken, err := token.GenerateAccessToken(user, rbac.GlobalRoles(user.Role), sessionID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sessionTokens{AccessToken: accessToken, RefreshToken: refreshToken}, nil
 }
 
 func (c *AuthControllerImpl) Register(w http.ResponseWriter, r *http.Request) {
@@ -42,6 +134,24 @@ func (c *AuthControllerImpl) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	requestBody.Email = strings.ToLower(strings.TrimSpace(requestBody.Email))
+	requestBody.Username = strings.TrimSpace(requestBody.Username)
+
+	if requestBody.Email == "" || requestBody.Username == "" || requestBody.Password == "" {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Username, email and password are required"})
+		return
+	}
+
+	if !c.UserRepository.CheckEmailAvailability(context.Background(), requestBody.Email) {
+		httputil.WriteResponse(w, http.StatusConflict, map[string]string{"error": "Email is already registered"})
+		return
+	}
+
+	if !c.UserRepository.CheckUsernameAvailability(context.Background(), requestBody.Username) {
+		httputil.WriteResponse(w, http.StatusConflict, map[string]string{"error": "Username is already taken"})
+		return
+	}
+
 	hashedPwd, err := bcrypt.GenerateFromPassword([]byte(requestBody.Password), bcrypt.DefaultCost)
 	if err != nil {
 		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error hashing password"})
@@ -61,71 +171,74 @@ func (c *AuthControllerImpl) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := c.sendVerificationEmail(context.Background(), &newUser); err != nil {
+		log.Printf("Error sending verification email to user %d: %v", newUser.ID, err)
+	}
+
 	response := struct {
 		Message string     `json:"message"`
 		Data    model.User `json:"data"`
 	}{
-		Message: "New user has been registered",
+		Message: "New user has been registered, please check your email to verify your account",
 		Data:    newUser,
 	}
 
 	httputil.WriteResponse(w, http.StatusOK, response)
 }
 
-func (c *AuthControllerImpl) Login(w http.ResponseWriter, r *http.Request) {
-	var requestBody struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
+func (c *AuthControllerImpl) sendVerificationEmail(ctx context.Context, user *model.User) error {
+	verificationToken, err := token.GenerateEmailVerificationToken(user.ID, user.Email, c.Clock.Now())
+	if err != nil {
+		return err
 	}
 
-	if err := httputil.ReadRequest(r, &requestBody); err != nil {
-		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	verificationLink := fmt.Sprintf("%s/verify-email?token=%s", os.Getenv("APP_URL"), url.QueryEscape(verificationToken))
+	message := mailer.Message{
+		To:       user.Email,
+		Subject:  "Verify your Temuka email address",
+		TextBody: fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening the link below. It expires in %d hours.\n\n%s", user.Username, int(token.EmailVerificationTTL.Hours()), verificationLink),
+	}
+
+	return c.Mailer.Send(ctx, message)
+}
+
+func (c *AuthControllerImpl) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	claims, err := token.ParseEmailVerificationToken(r.URL.Query().Get("token"))
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid or expired verification link"})
 		return
 	}
 
-	user, err := c.UserRepository.GetUserByEmail(context.Background(), requestBody.Email)
+	user, err := c.UserRepository.GetUserByID(context.Background(), claims.UserID)
 	if err != nil {
 		httputil.WriteResponse(w, http.StatusNotFound, map[string]string{"error": "User not found"})
 		return
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(requestBody.Password)); err != nil {
-		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Wrong password"})
+	if !strings.EqualFold(user.Email, claims.Email) {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid or expired verification link"})
 		return
 	}
 
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"id":       user.ID,
-		"username": user.Username,
-		"email":    user.Email,
-	})
-
-	tokenString, err := accessToken.SignedString([]byte(os.Getenv("JWT_SECRET_KEY")))
-	if err != nil {
-		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error creating token"})
-		return
+	if !user.EmailVerified {
+		if err := c.UserRepository.MarkEmailVerified(context.Background(), user.ID); err != nil {
+			httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error verifying email"})
+			return
+		}
 	}
 
 	response := struct {
 		Message string `json:"message"`
-		Token   string `json:"token"`
 	}{
-		Message: "User has login successfully",
-		Token:   tokenString,
+		Message: "Email has been verified",
 	}
 
 	httputil.WriteResponse(w, http.StatusOK, response)
 }
 
-func (c *AuthControllerImpl) ResetPassword(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	userIDstr := vars["id"]
-
+func (c *AuthControllerImpl) ResendVerificationEmail(w http.ResponseWriter, r *http.Request) {
 	var requestBody struct {
-		ResetToken              string `json:"reset_token"`
-		Email                   string `json:"email"`
-		NewPassword             string `json:"new_password"`
-		NewPasswordConfirmation string `json:"new_password_confirmation"`
+		Email string `json:"email"`
 	}
 
 	if err := httputil.ReadRequest(r, &requestBody); err != nil {
@@ -133,56 +246,559 @@ func (c *AuthControllerImpl) ResetPassword(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	token, err := jwt.Parse(requestBody.ResetToken, func(token *jwt.Token) (interface{}, error) {
-		return []byte(os.Getenv("JWT_SECRET_KEY")), nil
-	})
+	response := struct {
+		Message string `json:"message"`
+	}{
+		Message: "If the account exists and is not verified yet, a new verification email has been sent",
+	}
 
+	user, err := c.UserRepository.GetUserByEmail(context.Background(), strings.ToLower(strings.TrimSpace(requestBody.Email)))
+	if err != nil || user.EmailVerified {
+		httputil.WriteResponse(w, http.StatusOK, response)
+		return
+	}
+
+	cooldownKey := fmt.Sprintf("verification_email_cooldown_user_%d", user.ID)
+	acquired, remaining, err := redis.AcquireCooldown(context.Background(), cooldownKey, verificationEmailCooldown)
 	if err != nil {
-		httputil.WriteResponse(w, http.StatusUnauthorized, map[string]string{"error": "Invalid or expired token"})
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error sending verification email"})
+		return
+	}
+	if !acquired {
+		w.Header().Set("Retry-After", strconv.Itoa(int(remaining.Seconds())))
+		httputil.WriteResponse(w, http.StatusTooManyRequests, map[string]string{"error": "Please wait before requesting another verification email"})
+		return
+	}
+
+	if err := c.sendVerificationEmail(context.Background(), user); err != nil {
+		log.Printf("Error sending verification email to user %d: %v", user.ID, err)
+	}
+
+	httputil.WriteResponse(w, http.StatusOK, response)
+}
+
+func (c *AuthControllerImpl) Login(w http.ResponseWriter, r *http.Request) {
+	var requestBody struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}
+
+	if err := httputil.ReadRequest(r, &requestBody); err != nil {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
 		return
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		if claims["email"] != requestBody.Email {
-			httputil.WriteResponse(w, http.StatusUnauthorized, map[string]string{"error": "Invalid token for the provided email"})
+	email := strings.ToLower(strings.TrimSpace(requestBody.Email))
+	ipAddress := httputil.ClientIP(r)
+	subjects := []loginSubject{
+		{Scope: "email", Identifier: email, MaxFailures: maxEmailLoginFailures},
+		{Scope: "ip", Identifier: ipAddress, MaxFailures: maxIPLoginFailures},
+	}
+
+	for _, subject := range subjects {
+		remaining, err := c.LoginAttemptRepository.GetLockout(context.Background(), subject.key())
+		if err != nil {
+			httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error checking login attempts"})
 			return
 		}
-
-		if requestBody.NewPassword != requestBody.NewPasswordConfirmation {
-			httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Password and password confirmation do not match"})
+		if remaining > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(remaining.Seconds())+1))
+			httputil.WriteResponse(w, http.StatusTooManyRequests, map[string]string{"error": "Too many failed login attempts, please try again later"})
 			return
 		}
-		hashedNewPwd, err := bcrypt.GenerateFromPassword([]byte(requestBody.NewPassword), bcrypt.DefaultCost)
+	}
+
+	user, err := c.UserRepository.GetUserByEmail(context.Background(), email)
+	passwordHash := dummyPasswordHash
+	if err == nil {
+		passwordHash = []byte(user.Password)
+	}
+
+	if err := bcrypt.CompareHashAndPassword(passwordHash, []byte(requestBody.Password)); err != nil || user == nil {
+		var userID *int
+		if user != nil {
+			userID = &user.ID
+		}
+		for _, subject := range subjects {
+			c.registerLoginFailure(context.Background(), subject, userID, ipAddress)
+		}
+
+		httputil.WriteResponse(w, http.StatusUnauthorized, map[string]string{"error": "Invalid email or password"})
+		return
+	}
+
+	if err := c.LoginAttemptRepository.ClearFailures(context.Background(), subjects[0].key()); err != nil {
+		log.Printf("Error clearing login failures for user %d: %v", user.ID, err)
+	}
+
+	completeLogin(w, r, c.SessionRepository, c.ChallengeRepository, user, c.Clock.Now())
+}
+
+type loginSubject struct {
+	Scope       string
+	Identifier  string
+	MaxFailures int64
+}
+
+func (s loginSubject) key() string {
+	return s.Scope + ":" + s.Identifier
+}
+
+// twoFactorSubject counts the wrong two-factor codes of a user across all of their challenges.
+func twoFactorSubject(userID int) loginSubject {
+	return loginSubject{Scope: "two_factor", Identifier: strconv.Itoa(userID), MaxFailures: maxTwoFactorFailures}
+}
+
+// registerLoginFailure counts a failed login for the subject and locks it out once it reaches its limit.
+// Every lockout within loginLockoutLevelTTL doubles the previous one, up to loginLockoutMax.
+func (c *AuthControllerImpl) registerLoginFailure(ctx context.Context, subject loginSubject, userID *int, ipAddress string) {
+	failures, err := c.LoginAttemptRepository.RegisterFailure(ctx, subject.key(), loginAttemptWindow)
+	if err != nil {
+		log.Printf("Error registering login failure for %s: %v", subject.Scope, err)
+		return
+	}
+	if failures < subject.MaxFailures {
+		return
+	}
+
+	level, err := c.LoginAttemptRepository.IncrementLockoutLevel(ctx, subject.key(), loginLockoutLevelTTL)
+	if err != nil {
+		log.Printf("Error locking out %s: %v", subject.Scope, err)
+		return
+	}
+
+	duration := loginLockoutBase
+	for i := int64(1); i < level && duration < loginLockoutMax; i++ {
+		duration *= 2
+	}
+	if duration > loginLockoutMax {
+		duration = loginLockoutMax
+	}
+
+	if err := c.LoginAttemptRepository.Lock(ctx, subject.key(), duration); err != nil {
+		log.Printf("Error locking out %s: %v", subject.Scope, err)
+		return
+	}
+
+	lockout := model.LoginLockout{
+		Scope:          subject.Scope,
+		Identifier:     subject.Identifier,
+		UserID:         userID,
+		IPAddress:      ipAddress,
+		FailedAttempts: int(failures),
+		LockedUntil:    c.Clock.Now().Add(duration),
+	}
+	if subject.Scope == "ip" {
+		lockout.UserID = nil
+	}
+
+	if err := c.LoginLockoutRepository.CreateLoginLockout(ctx, &lockout); err != nil {
+		log.Printf("Error recording login lockout for %s: %v", subject.Scope, err)
+	}
+}
+
+func (c *AuthControllerImpl) LoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var requestBody struct {
+		ChallengeToken string `json:"challenge_token"`
+		Code           string `json:"code"`
+		RecoveryCode   string `json:"recovery_code"`
+	}
+
+	if err := httputil.ReadRequest(r, &requestBody); err != nil {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	claims, err := token.ParseTwoFactorChallengeToken(requestBody.ChallengeToken, c.Clock.Now())
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusUnauthorized, map[string]string{"error": "Invalid or expired challenge token"})
+		return
+	}
+
+	subject := twoFactorSubject(claims.UserID)
+	remaining, err := c.LoginAttemptRepository.GetLockout(context.Background(), subject.key())
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error checking login attempts"})
+		return
+	}
+	if remaining > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(remaining.Seconds())+1))
+		httputil.WriteResponse(w, http.StatusTooManyRequests, map[string]string{"error": "Too many invalid two-factor codes, please try again later"})
+		return
+	}
+
+	exists, err := c.ChallengeRepository.ChallengeExists(context.Background(), claims.Id)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error checking challenge token"})
+		return
+	}
+	if !exists {
+		httputil.WriteResponse(w, http.StatusUnauthorized, map[string]string{"error": "Invalid or expired challenge token"})
+		return
+	}
+
+	user, err := c.UserRepository.GetUserByID(context.Background(), claims.UserID)
+	if err != nil || !user.TwoFactorEnabled {
+		httputil.WriteResponse(w, http.StatusUnauthorized, map[string]string{"error": "Invalid or expired challenge token"})
+		return
+	}
+
+	verified, err := verifySecondFactor(context.Background(), c.TwoFactorRepository, user, requestBody.Code, requestBody.RecoveryCode, c.Clock.Now())
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error verifying code"})
+		return
+	}
+	if !verified {
+		c.registerLoginFailure(context.Background(), subject, &user.ID, httputil.ClientIP(r))
+
+		failures, err := c.ChallengeRepository.RegisterChallengeFailure(context.Background(), claims.Id, maxChallengeFailures)
 		if err != nil {
-			httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error hashing password"})
+			log.Printf("Error registering two-factor failure for user %d: %v", user.ID, err)
+		}
+		if failures == 0 || failures >= maxChallengeFailures {
+			httputil.WriteResponse(w, http.StatusUnauthorized, map[string]string{"error": "Too many invalid two-factor codes, please log in again"})
 			return
 		}
+		httputil.WriteResponse(w, http.StatusUnauthorized, map[string]string{"error": "Invalid two-factor code"})
+		return
+	}
+
+	consumed, err := c.ChallengeRepository.ConsumeChallenge(context.Background(), claims.Id)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error checking challenge token"})
+		return
+	}
+	if !consumed {
+		httputil.WriteResponse(w, http.StatusUnauthorized, map[string]string{"error": "Invalid or expired challenge token"})
+		return
+	}
 
-		userID, err := strconv.Atoi(userIDstr)
+	if err := c.LoginAttemptRepository.ClearFailures(context.Background(), subject.key()); err != nil {
+		log.Printf("Error clearing two-factor failures for user %d: %v", user.ID, err)
+	}
+
+	writeLoginResponse(w, r, c.SessionRepository, user, c.Clock.Now())
+}
+
+// completeLogin finishes a login once the user's primary credentials have been checked. Users with
+// two-factor authentication enabled get a short-lived, single-use challenge token instead of a session.
+func completeLogin(w http.ResponseWriter, r *http.Request, sessionRepo repository.SessionRepository, challengeRepo repository.TwoFactorChallengeRepository, user *model.User, now time.Time) {
+	if user.TwoFactorEnabled {
+		challengeID, err := token.GenerateRandomToken(16)
 		if err != nil {
-			httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid user id"})
+			httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error creating token"})
 			return
 		}
 
-		user, err := c.UserRepository.GetUserByID(context.Background(), userID)
-		if err != nil {
-			httputil.WriteResponse(w, http.StatusNotFound, map[string]string{"error": "User not found"})
+		if err := challengeRepo.CreateChallenge(context.Background(), challengeID, token.TwoFactorChallengeTTL); err != nil {
+			httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error creating token"})
 			return
 		}
 
-		user.Password = string(hashedNewPwd)
-		if err := c.UserRepository.UpdateUser(context.Background(), userID, user); err != nil {
-			httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error updating new password"})
+		challengeToken, err := token.GenerateTwoFactorChallengeToken(user.ID, challengeID, now)
+		if err != nil {
+			httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error creating token"})
 			return
 		}
 
 		response := struct {
-			Message string `json:"message"`
+			Message           string `json:"message"`
+			TwoFactorRequired bool   `json:"two_factor_required"`
+			ChallengeToken    string `json:"challenge_token"`
 		}{
-			Message: "Password was reset successfully",
+			Message:           "Two-factor authentication code is required",
+			TwoFactorRequired: true,
+			ChallengeToken:    challengeToken,
 		}
+
 		httputil.WriteResponse(w, http.StatusOK, response)
-	} else {
-		httputil.WriteResponse(w, http.StatusUnauthorized, map[string]string{"error": "Invalid token"})
+		return
 	}
+
+	writeLoginResponse(w, r, sessionRepo, user, now)
+}
+
+func writeLoginResponse(w http.ResponseWriter, r *http.Request, sessionRepo repository.SessionRepository, user *model.User, now time.Time) {
+	tokens, err := issueSession(context.Background(), sessionRepo, r, user, now)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error creating token"})
+		return
+	}
+
+	response := struct {
+		Message      string `json:"message"`
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int    `json:"expires_in"`
+	}{
+		Message:      "User has login successfully",
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    int(token.AccessTokenTTL.Seconds()),
+	}
+
+	httputil.WriteResponse(w, http.StatusOK, response)
+}
+
+func (c *AuthControllerImpl) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	var requestBody struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	if err := httputil.ReadRequest(r, &requestBody); err != nil {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	sessionID, secret, err := token.SplitRefreshToken(requestBody.RefreshToken)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusUnauthorized, map[string]string{"error": "Invalid refresh token"})
+		return
+	}
+
+	session, err := c.SessionRepository.GetSession(context.Background(), sessionID)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusUnauthorized, map[string]string{"error": "Session has expired or been revoked"})
+		return
+	}
+
+	newRefreshToken, newRefreshTokenHash, err := token.GenerateRefreshToken(sessionID)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error creating token"})
+		return
+	}
+
+	rotated, err := c.SessionRepository.RotateRefreshToken(context.Background(), sessionID, session.UserID, token.HashToken(secret), newRefreshTokenHash, token.RefreshTokenTTL)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error refreshing session"})
+		return
+	}
+
+	if !rotated {
+		// A refresh token that was already rotated is being replayed, so treat the session as stolen.
+		if err := c.SessionRepository.DeleteSession(context.Background(), sessionID); err != nil {
+			log.Printf("Error revoking session %s after refresh token reuse: %v", sessionID, err)
+		}
+		httputil.WriteResponse(w, http.StatusUnauthorized, map[string]string{"error": "Invalid refresh token"})
+		return
+	}
+
+	user, err := c.UserRepository.GetUserByID(context.Background(), session.UserID)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusUnauthorized, map[string]string{"error": "User not found"})
+		return
+	}
+
+	accessToken, err := token.GenerateAccessToken(user, rbac.GlobalRoles(user.Role), sessionID, c.Clock.Now())
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error creating token"})
+		return
+	}
+
+	response := struct {
+		Message      string `json:"message"`
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int    `json:"expires_in"`
+	}{
+		Message:      "Token has been refreshed",
+		Token:        accessToken,
+		RefreshToken: newRefreshToken,
+		ExpiresIn:    int(token.AccessTokenTTL.Seconds()),
+	}
+
+	httputil.WriteResponse(w, http.StatusOK, response)
+}
+
+func (c *AuthControllerImpl) Logout(w http.ResponseWriter, r *http.Request) {
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	if err := c.SessionRepository.DeleteSession(context.Background(), principal.SessionID); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error revoking session"})
+		return
+	}
+
+	response := struct {
+		Message string `json:"message"`
+	}{
+		Message: "User has logged out",
+	}
+
+	httputil.WriteResponse(w, http.StatusOK, response)
+}
+
+func (c *AuthControllerImpl) LogoutAllDevices(w http.ResponseWriter, r *http.Request) {
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	if err := c.SessionRepository.DeleteUserSessions(context.Background(), principal.ID); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error revoking sessions"})
+		return
+	}
+
+	response := struct {
+		Message string `json:"message"`
+	}{
+		Message: "User has logged out from all devices",
+	}
+
+	httputil.WriteResponse(w, http.StatusOK, response)
+}
+
+func (c *AuthControllerImpl) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var requestBody struct {
+		Email string `json:"email"`
+	}
+
+	if err := httputil.ReadRequest(r, &requestBody); err != nil {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	// The response is the same whether or not the email is registered, so it cannot be used to probe accounts.
+	response := struct {
+		Message string `json:"message"`
+	}{
+		Message: "If the email is registered, a password reset link has been sent",
+	}
+
+	user, err := c.UserRepository.GetUserByEmail(context.Background(), requestBody.Email)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusOK, response)
+		return
+	}
+
+	if err := c.PasswordResetRepository.InvalidateUserPasswordResetTokens(context.Background(), user.ID); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error creating reset token"})
+		return
+	}
+
+	rawToken, err := token.GenerateRandomToken(32)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error creating reset token"})
+		return
+	}
+
+	resetToken := model.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: token.HashToken(rawToken),
+		ExpiresAt: c.Clock.Now().Add(passwordResetTokenTTL),
+	}
+
+	if err := c.PasswordResetRepository.CreatePasswordResetToken(context.Background(), &resetToken); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error creating reset token"})
+		return
+	}
+
+	resetLink := fmt.Sprintf("%s/reset-password?token=%s", os.Getenv("APP_URL"), url.QueryEscape(rawToken))
+	message := mailer.Message{
+		To:       user.Email,
+		Subject:  "Reset your Temuka password",
+		TextBody: fmt.Sprintf("Hi %s,\n\nUse the link below to reset your password. It expires in %d minutes and can only be used once.\n\n%s\n\nIf you did not request this, you can ignore this email.", user.Username, int(passwordResetTokenTTL.Minutes()), resetLink),
+	}
+
+	if err := c.Mailer.Send(context.Background(), message); err != nil {
+		log.Printf("Error sending password reset email to user %d: %v", user.ID, err)
+	}
+
+	httputil.WriteResponse(w, http.StatusOK, response)
+}
+
+func (c *AuthControllerImpl) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var requestBody struct {
+		ResetToken              string `json:"reset_token"`
+		NewPassword             string `json:"new_password"`
+		NewPasswordConfirmation string `json:"new_password_confirmation"`
+	}
+
+	if err := httputil.ReadRequest(r, &requestBody); err != nil {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	if requestBody.NewPassword == "" || requestBody.NewPassword != requestBody.NewPasswordConfirmation {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Password and password confirmation do not match"})
+		return
+	}
+
+	resetToken, err := c.PasswordResetRepository.GetPasswordResetTokenByHash(context.Background(), token.HashToken(requestBody.ResetToken))
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusUnauthorized, map[string]string{"error": "Invalid or expired token"})
+		return
+	}
+
+	consumed, err := c.PasswordResetRepository.ConsumePasswordResetToken(context.Background(), resetToken.ID)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error verifying reset token"})
+		return
+	}
+	if !consumed {
+		httputil.WriteResponse(w, http.StatusUnauthorized, map[string]string{"error": "Invalid or expired token"})
+		return
+	}
+
+	hashedNewPwd, err := bcrypt.GenerateFromPassword([]byte(requestBody.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error hashing password"})
+		return
+	}
+
+	user, err := c.UserRepository.GetUserByID(context.Background(), resetToken.UserID)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusNotFound, map[string]string{"error": "User not found"})
+		return
+	}
+
+	user.Password = string(hashedNewPwd)
+	if err := c.UserRepository.UpdateUser(context.Background(), user.ID, user); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error updating new password"})
+		return
+	}
+
+	if err := c.SessionRepository.DeleteUserSessions(context.Background(), user.ID); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error revoking existing sessions"})
+		return
+	}
+
+	response := struct {
+		Message string `json:"message"`
+	}{
+		Message: "Password was reset successfully",
+	}
+	httputil.WriteResponse(w, http.StatusOK, response)
+}
+
+func (c *AuthControllerImpl) GetLoginLockouts(w http.ResponseWriter, r *http.Request) {
+	limit := 100
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsedLimit, err := strconv.Atoi(limitStr)
+		if err != nil || parsedLimit <= 0 {
+			httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid limit"})
+			return
+		}
+		if parsedLimit < limit {
+			limit = parsedLimit
+		}
+	}
+
+	lockouts, err := c.LoginLockoutRepository.GetLoginLockouts(context.Background(), limit)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving login lockouts"})
+		return
+	}
+
+	response := struct {
+		Message string               `json:"message"`
+		Data    []model.LoginLockout `json:"data"`
+	}{
+		Message: "Login lockouts have been retrieved",
+		Data:    lockouts,
+	}
+
+	httputil.WriteResponse(w, http.StatusOK, response)
 }
@@ -0,0 +1,130 @@
+package controller
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/temuka-api-service/internal/model"
+	"github.com/temuka-api-service/pkg/mailer"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// resetTokenFromMail returns the reset token from the link in the last email sent.
+func resetTokenFromMail(t *testing.T, mail *mailer.MemoryMailer) string {
+	t.Helper()
+
+	message, found := mail.Last()
+	if !found {
+		t.Fatal("no email was sent")
+	}
+
+	start := strings.Index(message.TextBody, "/reset-password?")
+	if start < 0 {
+		t.Fatalf("email %q has no reset link", message.TextBody)
+	}
+	link, err := url.Parse(strings.Fields(message.TextBody[start:])[0])
+	if err != nil {
+		t.Fatalf("parsing reset link: %v", err)
+	}
+	return link.Query().Get("token")
+}
+
+func TestPasswordReset(t *testing.T) {
+	tests := []struct {
+		name string
+		// token sends the reset email and returns the token to reset the password with.
+		token      func(t *testing.T, env *authTestEnv, mail *mailer.MemoryMailer) string
+		wantStatus int
+	}{
+		{
+			name: "mailed token",
+			token: func(t *testing.T, env *authTestEnv, mail *mailer.MemoryMailer) string {
+				doJSON(t, env.auth.ForgotPassword, nil, map[string]string{"email": env.user.Email})
+				return resetTokenFromMail(t, mail)
+			},
+			wantStatus: 200,
+		},
+		{
+			name: "used token",
+			token: func(t *testing.T, env *authTestEnv, mail *mailer.MemoryMailer) string {
+				doJSON(t, env.auth.ForgotPassword, nil, map[string]string{"email": env.user.Email})
+				resetToken := resetTokenFromMail(t, mail)
+				body := map[string]string{"reset_token": resetToken, "new_password": "first", "new_password_confirmation": "first"}
+				if status, response := doJSON(t, env.auth.ResetPassword, nil, body); status != 200 {
+					t.Fatalf("first ResetPassword() = %d %v", status, response)
+				}
+				return resetToken
+			},
+			wantStatus: 401,
+		},
+		{
+			name: "expired token",
+			token: func(t *testing.T, env *authTestEnv, mail *mailer.MemoryMailer) string {
+				doJSON(t, env.auth.ForgotPassword, nil, map[string]string{"email": env.user.Email})
+				env.clock.Advance(passwordResetTokenTTL + time.Second)
+				return resetTokenFromMail(t, mail)
+			},
+			wantStatus: 401,
+		},
+		{
+			name: "token replaced by a newer request",
+			token: func(t *testing.T, env *authTestEnv, mail *mailer.MemoryMailer) string {
+				doJSON(t, env.auth.ForgotPassword, nil, map[string]string{"email": env.user.Email})
+				resetToken := resetTokenFromMail(t, mail)
+				doJSON(t, env.auth.ForgotPassword, nil, map[string]string{"email": env.user.Email})
+				return resetToken
+			},
+			wantStatus: 401,
+		},
+		{
+			name: "unknown token",
+			token: func(t *testing.T, env *authTestEnv, mail *mailer.MemoryMailer) string {
+				return "not-a-token"
+			},
+			wantStatus: 401,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newAuthTestEnv(t)
+			mail := mailer.NewMemoryMailer()
+			env.auth.Mailer = mail
+			env.auth.PasswordResetRepository = &fakePasswordResetRepository{clock: env.clock}
+			body := map[string]string{"reset_token": tt.token(t, env, mail), "new_password": "new password", "new_password_confirmation": "new password"}
+			env.sessions.CreateSession(context.Background(), &model.Session{ID: "existing", UserID: env.user.ID}, time.Hour)
+
+			status, response := doJSON(t, env.auth.ResetPassword, nil, body)
+			if status != tt.wantStatus {
+				t.Fatalf("ResetPassword() = %d %v, want %d", status, response, tt.wantStatus)
+			}
+
+			changed := bcrypt.CompareHashAndPassword([]byte(env.users.get(env.user.ID).Password), []byte("new password")) == nil
+			if changed != (status == 200) {
+				t.Fatalf("after ResetPassword() = %d the password changed is %v", status, changed)
+			}
+			if revoked := env.sessions.count(env.user.ID) == 0; revoked != (status == 200) {
+				t.Fatalf("after ResetPassword() = %d the sessions revoked is %v", status, revoked)
+			}
+		})
+	}
+}
+
+func TestForgotPasswordUnknownEmail(t *testing.T) {
+	env := newAuthTestEnv(t)
+	mail := mailer.NewMemoryMailer()
+	env.auth.Mailer = mail
+	env.auth.PasswordResetRepository = &fakePasswordResetRepository{clock: env.clock}
+
+	known, knownResponse := doJSON(t, env.auth.ForgotPassword, nil, map[string]string{"email": env.user.Email})
+	unknown, unknownResponse := doJSON(t, env.auth.ForgotPassword, nil, map[string]string{"email": "nobody@example.com"})
+	if known != unknown || knownResponse["message"] != unknownResponse["message"] {
+		t.Fatalf("ForgotPassword() answered %d %v for a known and %d %v for an unknown email", known, knownResponse, unknown, unknownResponse)
+	}
+	if messages := mail.Messages(); len(messages) != 1 || messages[0].To != env.user.Email {
+		t.Fatalf("sent %+v, want a single email to %s", messages, env.user.Email)
+	}
+}
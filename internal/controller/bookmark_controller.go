@@ -0,0 +1,414 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/temuka-api-service/internal/model"
+	"github.com/temuka-api-service/internal/repository"
+	httputil "github.com/temuka-api-service/pkg/http"
+	"gorm.io/gorm"
+)
+
+const maxBookmarkCollectionNameLength = 100
+
+type BookmarkController interface {
+	AddBookmark(w http.ResponseWriter, r *http.Request)
+	RemoveBookmark(w http.ResponseWriter, r *http.Request)
+	GetBookmarks(w http.ResponseWriter, r *http.Request)
+	ReorderBookmarks(w http.ResponseWriter, r *http.Request)
+	CreateCollection(w http.ResponseWriter, r *http.Request)
+	GetCollections(w http.ResponseWriter, r *http.Request)
+	RenameCollection(w http.ResponseWriter, r *http.Request)
+	DeleteCollection(w http.ResponseWriter, r *http.Request)
+}
+
+type BookmarkControllerImpl struct {
+	BookmarkRepository   repository.BookmarkRepository
+	PostRepository       repository.PostRepository
+	UniversityRepository repository.UniversityRepository
+	CommunityRepository  repository.CommunityRepository
+}
+
+func NewBookmarkController(bookmarkRepo repository.BookmarkRepository, postRepo repository.PostRepository, universityRepo repository.UniversityRepository, communityRepo repository.CommunityRepository) BookmarkController {
+	return &BookmarkControllerImpl{
+		BookmarkRepository:   bookmarkRepo,
+		PostRepository:       postRepo,
+		UniversityRepository: universityRepo,
+		CommunityRepository:  communityRepo,
+	}
+}
+
+func (c *BookmarkControllerImpl) AddBookmark(w http.ResponseWriter, r *http.Request) {
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	var requestBody struct {
+		TargetType   string `json:"target_type"`
+		TargetID     int    `json:"target_id"`
+		CollectionID *int   `json:"collection_id"`
+	}
+
+	if err := httputil.ReadRequest(r, &requestBody); err != nil {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	if requestBody.CollectionID != nil && !c.ownsCollection(w, principal.ID, *requestBody.CollectionID) {
+		return
+	}
+
+	bookmark := model.Bookmark{
+		UserID:       principal.ID,
+		CollectionID: requestBody.CollectionID,
+	}
+
+	var err error
+	switch requestBody.TargetType {
+	case model.BookmarkTargetPost:
+		_, err = c.PostRepository.GetPostDetailByID(context.Background(), requestBody.TargetID)
+		bookmark.PostID = &requestBody.TargetID
+	case model.BookmarkTargetUniversity:
+		_, err = c.UniversityRepository.GetUniversityDetailByID(context.Background(), requestBody.TargetID)
+		bookmark.UniversityID = &requestBody.TargetID
+	case model.BookmarkTargetCommunity:
+		_, err = c.CommunityRepository.GetCommunityDetailByID(context.Background(), requestBody.TargetID)
+		bookmark.CommunityID = &requestBody.TargetID
+	default:
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid bookmark target type"})
+		return
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			httputil.WriteResponse(w, http.StatusNotFound, map[string]string{"error": "Bookmark target not found"})
+		} else {
+			httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving bookmark target"})
+		}
+		return
+	}
+
+	if err := c.BookmarkRepository.SaveBookmark(context.Background(), &bookmark); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error saving bookmark"})
+		return
+	}
+
+	response := struct {
+		Message string         `json:"message"`
+		Data    model.Bookmark `json:"data"`
+	}{
+		Message: "Bookmark has been saved",
+		Data:    bookmark,
+	}
+
+	httputil.WriteResponse(w, http.StatusOK, response)
+}
+
+func (c *BookmarkControllerImpl) RemoveBookmark(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bookmarkIDstr := vars["id"]
+
+	bookmarkID, err := strconv.Atoi(bookmarkIDstr)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid bookmark id"})
+		return
+	}
+
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	deleted, err := c.BookmarkRepository.DeleteBookmark(context.Background(), principal.ID, bookmarkID)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error removing bookmark"})
+		return
+	}
+	if !deleted {
+		httputil.WriteResponse(w, http.StatusNotFound, map[string]string{"error": "Bookmark not found"})
+		return
+	}
+
+	response := struct {
+		Message string `json:"message"`
+	}{
+		Message: "Bookmark has been removed",
+	}
+
+	httputil.WriteResponse(w, http.StatusOK, response)
+}
+
+func (c *BookmarkControllerImpl) GetBookmarks(w http.ResponseWriter, r *http.Request) {
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	collectionID, ok := c.requestCollectionID(w, r, principal.ID)
+	if !ok {
+		return
+	}
+
+	page, ok := requestPage(w, r)
+	if !ok {
+		return
+	}
+
+	bookmarks, nextCursor, err := c.BookmarkRepository.GetBookmarks(context.Background(), principal.ID, collectionID, page)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving bookmarks"})
+		return
+	}
+
+	response := struct {
+		Message    string           `json:"message"`
+		Data       []model.Bookmark `json:"data"`
+		NextCursor string           `json:"next_cursor"`
+	}{
+		Message:    "Bookmarks have been retrieved",
+		Data:       bookmarks,
+		NextCursor: nextCursor,
+	}
+
+	httputil.WriteResponse(w, http.StatusOK, response)
+}
+
+func (c *BookmarkControllerImpl) ReorderBookmarks(w http.ResponseWriter, r *http.Request) {
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	var requestBody struct {
+		CollectionID *int  `json:"collection_id"`
+		BookmarkIDs  []int `json:"bookmark_ids"`
+	}
+
+	if err := httputil.ReadRequest(r, &requestBody); err != nil {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	if requestBody.CollectionID != nil && !c.ownsCollection(w, principal.ID, *requestBody.CollectionID) {
+		return
+	}
+
+	if err := c.BookmarkRepository.ReorderBookmarks(context.Background(), principal.ID, requestBody.CollectionID, requestBody.BookmarkIDs); err != nil {
+		if errors.Is(err, repository.ErrBookmarkOrderMismatch) {
+			httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Bookmark ids must list every bookmark of the collection once"})
+			return
+		}
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error reordering bookmarks"})
+		return
+	}
+
+	response := struct {
+		Message string `json:"message"`
+	}{
+		Message: "Bookmarks have been reordered",
+	}
+
+	httputil.WriteResponse(w, http.StatusOK, response)
+}
+
+func (c *BookmarkControllerImpl) CreateCollection(w http.ResponseWriter, r *http.Request) {
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	var requestBody struct {
+		Name string `json:"name"`
+	}
+
+	if err := httputil.ReadRequest(r, &requestBody); err != nil {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	name, ok := c.validCollectionName(w, principal.ID, requestBody.Name)
+	if !ok {
+		return
+	}
+
+	newCollection := model.BookmarkCollection{
+		UserID: principal.ID,
+		Name:   name,
+	}
+
+	if err := c.BookmarkRepository.CreateCollection(context.Background(), &newCollection); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error creating collection"})
+		return
+	}
+
+	response := struct {
+		Message string                   `json:"message"`
+		Data    model.BookmarkCollection `json:"data"`
+	}{
+		Message: "Collection has been created",
+		Data:    newCollection,
+	}
+
+	httputil.WriteResponse(w, http.StatusOK, response)
+}
+
+func (c *BookmarkControllerImpl) GetCollections(w http.ResponseWriter, r *http.Request) {
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	collections, err := c.BookmarkRepository.GetCollections(context.Background(), principal.ID)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving collections"})
+		return
+	}
+
+	response := struct {
+		Message string                     `json:"message"`
+		Data    []model.BookmarkCollection `json:"data"`
+	}{
+		Message: "Collections have been retrieved",
+		Data:    collections,
+	}
+
+	httputil.WriteResponse(w, http.StatusOK, response)
+}
+
+func (c *BookmarkControllerImpl) RenameCollection(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	collectionIDstr := vars["id"]
+
+	collectionID, err := strconv.Atoi(collectionIDstr)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid collection id"})
+		return
+	}
+
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	var requestBody struct {
+		Name string `json:"name"`
+	}
+
+	if err := httputil.ReadRequest(r, &requestBody); err != nil {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	if !c.ownsCollection(w, principal.ID, collectionID) {
+		return
+	}
+
+	name, ok := c.validCollectionName(w, principal.ID, requestBody.Name)
+	if !ok {
+		return
+	}
+
+	if err := c.BookmarkRepository.RenameCollection(context.Background(), principal.ID, collectionID, name); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error renaming collection"})
+		return
+	}
+
+	response := struct {
+		Message string `json:"message"`
+	}{
+		Message: "Collection has been renamed",
+	}
+
+	httputil.WriteResponse(w, http.StatusOK, response)
+}
+
+func (c *BookmarkControllerImpl) DeleteCollection(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	collectionIDstr := vars["id"]
+
+	collectionID, err := strconv.Atoi(collectionIDstr)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid collection id"})
+		return
+	}
+
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	if !c.ownsCollection(w, principal.ID, collectionID) {
+		return
+	}
+
+	if err := c.BookmarkRepository.DeleteCollection(context.Background(), principal.ID, collectionID); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error deleting collection"})
+		return
+	}
+
+	response := struct {
+		Message string `json:"message"`
+	}{
+		Message: "Collection has been deleted and its bookmarks moved to unsorted",
+	}
+
+	httputil.WriteResponse(w, http.StatusOK, response)
+}
+
+// ownsCollection writes a 404 response unless the collection exists and belongs to the user.
+// Collections of other users are reported as missing so that they stay private.
+func (c *BookmarkControllerImpl) ownsCollection(w http.ResponseWriter, userID, collectionID int) bool {
+	if _, err := c.BookmarkRepository.GetCollection(context.Background(), userID, collectionID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			httputil.WriteResponse(w, http.StatusNotFound, map[string]string{"error": "Collection not found"})
+		} else {
+			httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving collection"})
+		}
+		return false
+	}
+	return true
+}
+
+// requestCollectionID reads the optional collection_id query parameter; nil selects the unsorted bookmarks.
+func (c *BookmarkControllerImpl) requestCollectionID(w http.ResponseWriter, r *http.Request, userID int) (*int, bool) {
+	collectionIDstr := r.URL.Query().Get("collection_id")
+	if collectionIDstr == "" {
+		return nil, true
+	}
+
+	collectionID, err := strconv.Atoi(collectionIDstr)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid collection id"})
+		return nil, false
+	}
+	if !c.ownsCollection(w, userID, collectionID) {
+		return nil, false
+	}
+	return &collectionID, true
+}
+
+func (c *BookmarkControllerImpl) validCollectionName(w http.ResponseWriter, userID int, name string) (string, bool) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxBookmarkCollectionNameLength {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Collection name must be between 1 and 100 characters"})
+		return "", false
+	}
+
+	collections, err := c.BookmarkRepository.GetCollections(context.Background(), userID)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving collections"})
+		return "", false
+	}
+	for _, collection := range collections {
+		if strings.EqualFold(collection.Name, name) {
+			httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Collection with the same name already exist"})
+			return "", false
+		}
+	}
+	return name, true
+}
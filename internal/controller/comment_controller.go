@@ -6,11 +6,17 @@ import (
 	"strconv"
 
 	"github.com/gorilla/mux"
+	"github.com/temuka-api-service/internal/feed"
 	"github.com/temuka-api-service/internal/model"
+	"github.com/temuka-api-service/internal/rbac"
 	"github.com/temuka-api-service/internal/repository"
 	httputil "github.com/temuka-api-service/pkg/http"
+	"github.com/temuka-api-service/pkg/pagination"
 )
 
+// maxReplyDepth is how many levels of nested replies ShowReplies returns at once.
+const maxReplyDepth = 3
+
 type CommentController interface {
 	AddComment(w http.ResponseWriter, r *http.Request)
 	ShowCommentsByPost(w http.ResponseWriter, r *http.Request)
@@ -23,21 +29,29 @@ type CommentControllerImpl struct {
 	PostRepository         repository.PostRepository
 	NotificationRepository repository.NotificationRepository
 	ReportRepository       repository.ReportRepository
+	Authorizer             rbac.Authorizer
+	TagService             feed.TagService
 }
 
-func NewCommentController(commentRepo repository.CommentRepository, postRepo repository.PostRepository, notificationRepo repository.NotificationRepository, reportRepo repository.ReportRepository) CommentController {
+func NewCommentController(commentRepo repository.CommentRepository, postRepo repository.PostRepository, notificationRepo repository.NotificationRepository, reportRepo repository.ReportRepository, authorizer rbac.Authorizer, tagService feed.TagService) CommentController {
 	return &CommentControllerImpl{
 		CommentRepository:      commentRepo,
 		PostRepository:         postRepo,
 		NotificationRepository: notificationRepo,
 		ReportRepository:       reportRepo,
+		Authorizer:             authorizer,
+		TagService:             tagService,
 	}
 }
 
 func (c *CommentControllerImpl) AddComment(w http.ResponseWriter, r *http.Request) {
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
 	var requestBody struct {
 		PostID   int    `json:"post_id"`
-		UserID   int    `json:"user_id"`
 		ParentID *int   `json:"parent_id"`
 		Content  string `json:"content"`
 	}
@@ -53,7 +67,7 @@ func (c *CommentControllerImpl) AddComment(w http.ResponseWriter, r *http.Reques
 	}
 
 	newComment := model.Comment{
-		UserID:   requestBody.UserID,
+		UserID:   principal.ID,
 		PostID:   requestBody.PostID,
 		ParentID: parentID,
 		Content:  requestBody.Content,
@@ -70,10 +84,10 @@ func (c *CommentControllerImpl) AddComment(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	if post.UserID != requestBody.UserID {
+	if post.UserID != principal.ID {
 		newCommentNotification := model.Notification{
 			UserID:    post.UserID,
-			ActorID:   requestBody.UserID,
+			ActorID:   principal.ID,
 			PostID:    requestBody.PostID,
 			CommentID: newComment.ID,
 			Type:      "comment",
@@ -87,6 +101,16 @@ func (c *CommentControllerImpl) AddComment(w http.ResponseWriter, r *http.Reques
 		}
 	}
 
+	if err := c.TagService.ProcessComment(context.Background(), &newComment, post); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error saving comment tags"})
+		return
+	}
+
+	if err := c.TagService.LinkComments(context.Background(), &newComment); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving comment details"})
+		return
+	}
+
 	response := struct {
 		Message string        `json:"message"`
 		Data    model.Comment `json:"data"`
@@ -108,18 +132,30 @@ func (c *CommentControllerImpl) ShowCommentsByPost(w http.ResponseWriter, r *htt
 		return
 	}
 
-	comments, err := c.CommentRepository.GetCommentsByPostID(context.Background(), requestBody.PostID)
+	page, ok := requestPage(w, r)
+	if !ok {
+		return
+	}
+
+	comments, nextCursor, err := c.CommentRepository.GetCommentsByPostID(context.Background(), requestBody.PostID, page)
 	if err != nil {
 		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving comments"})
 		return
 	}
 
+	if err := c.TagService.LinkComments(context.Background(), commentPointers(comments)...); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving comments"})
+		return
+	}
+
 	response := struct {
-		Message string          `json:"message"`
-		Data    []model.Comment `json:"data"`
+		Message    string          `json:"message"`
+		Data       []model.Comment `json:"data"`
+		NextCursor string          `json:"next_cursor"`
 	}{
-		Message: "Comments have been retrieved",
-		Data:    comments,
+		Message:    "Comments have been retrieved",
+		Data:       comments,
+		NextCursor: nextCursor,
 	}
 
 	httputil.WriteResponse(w, http.StatusOK, response)
@@ -135,6 +171,38 @@ func (c *CommentControllerImpl) DeleteComment(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	comment, err := c.CommentRepository.GetCommentDetailByID(context.Background(), commentID)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusNotFound, map[string]string{"error": "Comment not found"})
+		return
+	}
+
+	allowed := comment.UserID == principal.ID || principal.HasRole(rbac.RoleAdmin)
+	if !allowed {
+		post, err := c.PostRepository.GetPostByIDAnyStatus(context.Background(), comment.PostID)
+		if err != nil {
+			httputil.WriteResponse(w, http.StatusNotFound, map[string]string{"error": "Post not found"})
+			return
+		}
+
+		if post.CommunityID != nil {
+			allowed, err = c.Authorizer.HasCommunityPermission(context.Background(), principal.Roles, principal.ID, *post.CommunityID, rbac.PermissionModerateCommunity)
+			if err != nil {
+				httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error checking permissions"})
+				return
+			}
+		}
+	}
+	if !allowed {
+		httputil.WriteResponse(w, http.StatusForbidden, map[string]string{"error": "You are not allowed to delete this comment"})
+		return
+	}
+
 	if err := c.CommentRepository.DeleteComment(context.Background(), commentID); err != nil {
 		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error deleting comment"})
 		return
@@ -159,37 +227,59 @@ func (c *CommentControllerImpl) ShowReplies(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	var fetchReplies func(parentID int) ([]model.Comment, error)
-	fetchReplies = func(parentID int) ([]model.Comment, error) {
-		comments, err := c.CommentRepository.GetRepliesByParentID(context.Background(), parentID)
+	page, ok := requestPage(w, r)
+	if !ok {
+		return
+	}
+
+	// Only the requested level is paged. Nested levels hold their first page down to maxReplyDepth;
+	// the rest is loaded by asking for the replies of that comment.
+	var fetchReplies func(parentID int, page pagination.Page, depth int) ([]model.Comment, string, error)
+	fetchReplies = func(parentID int, page pagination.Page, depth int) ([]model.Comment, string, error) {
+		comments, nextCursor, err := c.CommentRepository.GetRepliesByParentID(context.Background(), parentID, page)
 		if err != nil {
-			return nil, err
+			return nil, "", err
+		}
+		if err := c.TagService.LinkComments(context.Background(), commentPointers(comments)...); err != nil {
+			return nil, "", err
 		}
 
-		for i := range comments {
-			replies, err := fetchReplies(comments[i].ID)
-			if err != nil {
-				return nil, err
+		if depth < maxReplyDepth {
+			for i := range comments {
+				replies, _, err := fetchReplies(comments[i].ID, pagination.FirstPage(), depth+1)
+				if err != nil {
+					return nil, "", err
+				}
+				comments[i].Replies = replies
 			}
-			comments[i].Replies = replies
 		}
 
-		return comments, nil
+		return comments, nextCursor, nil
 	}
 
-	replies, err := fetchReplies(requestBody.ParentID)
+	replies, nextCursor, err := fetchReplies(requestBody.ParentID, page, 1)
 	if err != nil {
 		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving replies"})
 		return
 	}
 
 	response := struct {
-		Message string          `json:"message"`
-		Data    []model.Comment `json:"data"`
+		Message    string          `json:"message"`
+		Data       []model.Comment `json:"data"`
+		NextCursor string          `json:"next_cursor"`
 	}{
-		Message: "Replies have been shown",
-		Data:    replies,
+		Message:    "Replies have been shown",
+		Data:       replies,
+		NextCursor: nextCursor,
 	}
 
 	httputil.WriteResponse(w, http.StatusOK, response)
 }
+
+func commentPointers(comments []model.Comment) []*model.Comment {
+	pointers := make([]*model.Comment, 0, len(comments))
+	for i := range comments {
+		pointers = append(pointers, &comments[i])
+	}
+	return pointers
+}
@@ -34,11 +34,17 @@ func NewCommunityController(repo repository.CommunityRepository) CommunityContro
 }
 
 func (c *CommunityControllerImpl) CreateCommunity(w http.ResponseWriter, r *http.Request) {
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
 	var requestBody struct {
 		Name         string `json:"name"`
 		Description  string `json:"description"`
 		LogoPicture  string `json:"logo_picture"`
 		CoverPicture string `json:"cover_picture"`
+		UniversityID *int   `json:"university_id"`
 	}
 
 	if err := httputil.ReadRequest(r, &requestBody); err != nil {
@@ -52,10 +58,12 @@ func (c *CommunityControllerImpl) CreateCommunity(w http.ResponseWriter, r *http
 	}
 
 	newCommunity := model.Community{
-		Name:        requestBody.Name,
-		Slug:        strings.ReplaceAll(strings.ToLower(requestBody.Name), " ", "_"),
-		Description: requestBody.Description,
-		LogoPicture: requestBody.LogoPicture,
+		Name:         requestBody.Name,
+		Slug:         strings.ReplaceAll(strings.ToLower(requestBody.Name), " ", "_"),
+		UserID:       principal.ID,
+		UniversityID: requestBody.UniversityID,
+		Description:  requestBody.Description,
+		LogoPicture:  requestBody.LogoPicture,
 	}
 
 	if err := c.CommunityRepository.CreateCommunity(context.Background(), &newCommunity); err != nil {
@@ -74,19 +82,25 @@ func (c *CommunityControllerImpl) CreateCommunity(w http.ResponseWriter, r *http
 }
 
 func (c *CommunityControllerImpl) GetCommunities(w http.ResponseWriter, r *http.Request) {
+	page, ok := requestPage(w, r)
+	if !ok {
+		return
+	}
 
-	communities, err := c.CommunityRepository.GetCommunities(context.Background())
+	communities, nextCursor, err := c.CommunityRepository.GetCommunities(context.Background(), page)
 	if err != nil {
 		httputil.WriteResponse(w, http.StatusNotFound, map[string]string{"error": "Error retrieving communities"})
 		return
 	}
 
 	response := struct {
-		Message string            `json:"message"`
-		Data    []model.Community `json:"data"`
+		Message    string            `json:"message"`
+		Data       []model.Community `json:"data"`
+		NextCursor string            `json:"next_cursor"`
 	}{
-		Message: "Communities have been retireved",
-		Data:    communities,
+		Message:    "Communities have been retireved",
+		Data:       communities,
+		NextCursor: nextCursor,
 	}
 	httputil.WriteResponse(w, http.StatusOK, response)
 }
@@ -107,6 +121,7 @@ func (c *CommunityControllerImpl) UpdateCommunity(w http.ResponseWriter, r *http
 		Description  string `json:"description"`
 		LogoPicture  string `json:"logo_picture"`
 		CoverPicture string `json:"cover_picture"`
+		UniversityID *int   `json:"university_id"`
 	}
 
 	if err := httputil.ReadRequest(r, &requestBody); err != nil {
@@ -120,6 +135,7 @@ func (c *CommunityControllerImpl) UpdateCommunity(w http.ResponseWriter, r *http
 		Description:  requestBody.Description,
 		LogoPicture:  requestBody.LogoPicture,
 		CoverPicture: requestBody.CoverPicture,
+		UniversityID: requestBody.UniversityID,
 	}
 
 	if err := c.CommunityRepository.UpdateCommunity(context.Background(), communityID, &updatedCommunity); err != nil {
@@ -147,12 +163,8 @@ func (c *CommunityControllerImpl) JoinCommunity(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	var requestBody struct {
-		UserID int `json:"user_id"`
-	}
-
-	if err := httputil.ReadRequest(r, &requestBody); err != nil {
-		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
 		return
 	}
 
@@ -166,7 +178,7 @@ func (c *CommunityControllerImpl) JoinCommunity(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	existingMember, err := c.CommunityRepository.CheckMembership(context.Background(), communityID, requestBody.UserID)
+	existingMember, err := c.CommunityRepository.CheckMembership(context.Background(), communityID, principal.ID)
 	if err != nil {
 		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error checking community membership"})
 		return
@@ -177,7 +189,7 @@ func (c *CommunityControllerImpl) JoinCommunity(w http.ResponseWriter, r *http.R
 	}
 
 	newMember := model.CommunityMember{
-		UserID:      requestBody.UserID,
+		UserID:      principal.ID,
 		CommunityID: communityID,
 	}
 
@@ -238,25 +250,37 @@ func (c *CommunityControllerImpl) GetCommunityPosts(w http.ResponseWriter, r *ht
 	if topic := r.URL.Query().Get("topic"); topic != "" {
 		filters["topic"] = topic
 	}
-	if sort := r.URL.Query().Get("sort"); sort != "" {
-		filters["sort"] = sort
+
+	// Posts are paged in the order they were added, newest first unless sort=asc.
+	newestFirst := true
+	switch r.URL.Query().Get("sort") {
+	case "", "desc":
+	case "asc":
+		newestFirst = false
+	default:
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid sort"})
+		return
 	}
-	if sortBy := r.URL.Query().Get("sort_by"); sortBy != "" {
-		filters["sort_by"] = sortBy
+
+	page, ok := requestPage(w, r)
+	if !ok {
+		return
 	}
 
-	communityPosts, err := c.CommunityRepository.GetCommunityPosts(context.Background(), communityID, filters)
+	communityPosts, nextCursor, err := c.CommunityRepository.GetCommunityPosts(context.Background(), communityID, filters, page, newestFirst)
 	if err != nil {
 		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving posts"})
 		return
 	}
 
 	response := struct {
-		Message string                `json:"message"`
-		Data    []model.CommunityPost `json:"data"`
+		Message    string                `json:"message"`
+		Data       []model.CommunityPost `json:"data"`
+		NextCursor string                `json:"next_cursor"`
 	}{
-		Message: "Community posts has been retrieved",
-		Data:    communityPosts,
+		Message:    "Community posts has been retrieved",
+		Data:       communityPosts,
+		NextCursor: nextCursor,
 	}
 
 	httputil.WriteResponse(w, http.StatusOK, response)
@@ -284,16 +308,17 @@ func (c *CommunityControllerImpl) GetCommunityDetail(w http.ResponseWriter, r *h
 }
 
 func (c *CommunityControllerImpl) GetUserJoinedCommunities(w http.ResponseWriter, r *http.Request) {
-	var requestBody struct {
-		UserID int `json:"user_id"`
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
 	}
 
-	if err := httputil.ReadRequest(r, &requestBody); err != nil {
-		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	page, ok := requestPage(w, r)
+	if !ok {
 		return
 	}
 
-	userCommunities, err := c.CommunityRepository.GetUserJoinedCommunities(context.Background(), requestBody.UserID)
+	userCommunities, nextCursor, err := c.CommunityRepository.GetUserJoinedCommunities(context.Background(), principal.ID, page)
 
 	if err != nil {
 		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving communities"})
@@ -301,11 +326,13 @@ func (c *CommunityControllerImpl) GetUserJoinedCommunities(w http.ResponseWriter
 	}
 
 	response := struct {
-		Message string            `json:"message"`
-		Data    []model.Community `json:"data"`
+		Message    string            `json:"message"`
+		Data       []model.Community `json:"data"`
+		NextCursor string            `json:"next_cursor"`
 	}{
-		Message: "Communities has been retrieved",
-		Data:    userCommunities,
+		Message:    "Communities has been retrieved",
+		Data:       userCommunities,
+		NextCursor: nextCursor,
 	}
 	httputil.WriteResponse(w, http.StatusOK, response)
 }
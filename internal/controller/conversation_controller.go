@@ -2,13 +2,16 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gorilla/mux"
 	"github.com/temuka-api-service/internal/model"
+	"github.com/temuka-api-service/internal/rbac"
 	"github.com/temuka-api-service/internal/repository"
 	httputil "github.com/temuka-api-service/pkg/http"
+	"gorm.io/gorm"
 )
 
 type ConversationController interface {
@@ -34,9 +37,13 @@ func NewConversationController(conversationRepo repository.ConversationRepositor
 }
 
 func (c *ConversationControllerImpl) AddConversation(w http.ResponseWriter, r *http.Request) {
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
 	var requestBody struct {
-		Title  string `json:"title"`
-		UserID int    `json:"user_id"`
+		Title string `json:"title"`
 	}
 
 	if err := httputil.ReadRequest(r, &requestBody); err != nil {
@@ -45,7 +52,7 @@ func (c *ConversationControllerImpl) AddConversation(w http.ResponseWriter, r *h
 	}
 
 	newConversation := model.Conversation{
-		UserID: requestBody.UserID,
+		UserID: principal.ID,
 		Title:  requestBody.Title,
 	}
 
@@ -66,6 +73,11 @@ func (c *ConversationControllerImpl) AddConversation(w http.ResponseWriter, r *h
 }
 
 func (c *ConversationControllerImpl) AddMessage(w http.ResponseWriter, r *http.Request) {
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
 	var requestBody struct {
 		ParticipantID int    `json:"participant_id"`
 		Text          string `json:"text"`
@@ -76,6 +88,17 @@ func (c *ConversationControllerImpl) AddMessage(w http.ResponseWriter, r *http.R
 		return
 	}
 
+	participant, err := c.ConversationRepository.GetParticipantByID(context.Background(), requestBody.ParticipantID)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusNotFound, map[string]string{"error": "Participant not found"})
+		return
+	}
+
+	if participant.UserID != principal.ID {
+		httputil.WriteResponse(w, http.StatusForbidden, map[string]string{"error": "You are not allowed to send messages as this participant"})
+		return
+	}
+
 	newMessage := model.Message{
 		ParticipantID: requestBody.ParticipantID,
 		Text:          requestBody.Text,
@@ -107,18 +130,35 @@ func (c *ConversationControllerImpl) GetConversationsByUserID(w http.ResponseWri
 		return
 	}
 
-	conversations, err := c.ConversationRepository.GetConversationsByUserID(context.Background(), userID)
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	if userID != principal.ID {
+		httputil.WriteResponse(w, http.StatusForbidden, map[string]string{"error": "You are not allowed to view these conversations"})
+		return
+	}
+
+	page, ok := requestPage(w, r)
+	if !ok {
+		return
+	}
+
+	conversations, nextCursor, err := c.ConversationRepository.GetConversationsByUserID(context.Background(), userID, page)
 	if err != nil {
-		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving conversatiosn"})
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving conversations"})
 		return
 	}
 
 	response := struct {
-		Message string               `json:"message"`
-		Data    []model.Conversation `json:"data"`
+		Message    string               `json:"message"`
+		Data       []model.Conversation `json:"data"`
+		NextCursor string               `json:"next_cursor"`
 	}{
-		Message: "Conversations have been retrieved",
-		Data:    conversations,
+		Message:    "Conversations have been retrieved",
+		Data:       conversations,
+		NextCursor: nextCursor,
 	}
 
 	httputil.WriteResponse(w, http.StatusOK, response)
@@ -134,9 +174,13 @@ func (c *ConversationControllerImpl) GetConversationDetail(w http.ResponseWriter
 		return
 	}
 
-	conversation, err := c.ConversationRepository.GetConversationDetailByID(context.Background(), conversationID)
-	if err != nil {
-		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving conversation detail"})
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	conversation, ok := c.authorizeConversation(w, principal.ID, conversationID)
+	if !ok {
 		return
 	}
 
@@ -152,6 +196,11 @@ func (c *ConversationControllerImpl) GetConversationDetail(w http.ResponseWriter
 }
 
 func (c *ConversationControllerImpl) AddParticipant(w http.ResponseWriter, r *http.Request) {
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
 	var requestBody struct {
 		ConversationID int `json:"conversation_id"`
 		UserID         int `json:"user_id"`
@@ -162,6 +211,17 @@ func (c *ConversationControllerImpl) AddParticipant(w http.ResponseWriter, r *ht
 		return
 	}
 
+	conversation, err := c.ConversationRepository.GetConversationDetailByID(context.Background(), requestBody.ConversationID)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusNotFound, map[string]string{"error": "Conversation not found"})
+		return
+	}
+
+	if conversation.UserID != principal.ID {
+		httputil.WriteResponse(w, http.StatusForbidden, map[string]string{"error": "You are not allowed to add participants to this conversation"})
+		return
+	}
+
 	newParticipant := model.Participant{
 		UserID:         requestBody.UserID,
 		ConversationID: requestBody.ConversationID,
@@ -191,6 +251,22 @@ func (c *ConversationControllerImpl) DeleteConversation(w http.ResponseWriter, r
 		return
 	}
 
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	conversation, err := c.ConversationRepository.GetConversationDetailByID(context.Background(), conversationID)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusNotFound, map[string]string{"error": "Conversation not found"})
+		return
+	}
+
+	if conversation.UserID != principal.ID && !principal.HasRole(rbac.RoleAdmin) {
+		httputil.WriteResponse(w, http.StatusForbidden, map[string]string{"error": "You are not allowed to delete this conversation"})
+		return
+	}
+
 	if err := c.ConversationRepository.DeleteConversation(context.Background(), conversationID); err != nil {
 		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error deleting conversation"})
 		return
@@ -215,19 +291,62 @@ func (c *ConversationControllerImpl) RetrieveMessages(w http.ResponseWriter, r *
 		return
 	}
 
-	messages, err := c.ConversationRepository.GetMessagesByConversationID(context.Background(), conversationID)
+	page, ok := requestPage(w, r)
+	if !ok {
+		return
+	}
+
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	if _, ok := c.authorizeConversation(w, principal.ID, conversationID); !ok {
+		return
+	}
+
+	messages, nextCursor, err := c.ConversationRepository.GetMessagesByConversationID(context.Background(), conversationID, page)
 	if err != nil {
-		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error deleting conversation"})
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving messages"})
 		return
 	}
 
 	response := struct {
-		Message string          `json:"message"`
-		Data    []model.Message `json:"data"`
+		Message    string          `json:"message"`
+		Data       []model.Message `json:"data"`
+		NextCursor string          `json:"next_cursor"`
 	}{
-		Message: "Conversation has been deleted",
-		Data:    messages,
+		Message:    "Messages have been retrieved",
+		Data:       messages,
+		NextCursor: nextCursor,
 	}
 
 	httputil.WriteResponse(w, http.StatusOK, response)
 }
+
+// authorizeConversation loads a conversation the user owns or takes part in, writing an error response
+// when it does not exist or belongs to others.
+func (c *ConversationControllerImpl) authorizeConversation(w http.ResponseWriter, userID, conversationID int) (*model.Conversation, bool) {
+	conversation, err := c.ConversationRepository.GetConversationDetailByID(context.Background(), conversationID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			httputil.WriteResponse(w, http.StatusNotFound, map[string]string{"error": "Conversation not found"})
+			return nil, false
+		}
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving conversation detail"})
+		return nil, false
+	}
+
+	if conversation.UserID != userID {
+		isParticipant, err := c.ConversationRepository.IsParticipant(context.Background(), conversationID, userID)
+		if err != nil {
+			httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving conversation detail"})
+			return nil, false
+		}
+		if !isParticipant {
+			httputil.WriteResponse(w, http.StatusForbidden, map[string]string{"error": "You are not allowed to view this conversation"})
+			return nil, false
+		}
+	}
+	return conversation, true
+}
@@ -0,0 +1,538 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/temuka-api-service/internal/model"
+	"github.com/temuka-api-service/internal/repository"
+	"github.com/temuka-api-service/middleware"
+	"github.com/temuka-api-service/pkg/clock"
+	"gorm.io/gorm"
+)
+
+// The fakes below keep just enough state in memory for the auth flows under test. They embed the
+// repository interfaces so that calling anything else panics instead of silently passing.
+
+type fakeUserRepository struct {
+	repository.UserRepository
+
+	mu     sync.Mutex
+	users  map[int]*model.User
+	nextID int
+}
+
+func newFakeUserRepository(users ...model.User) *fakeUserRepository {
+	repo := &fakeUserRepository{users: make(map[int]*model.User)}
+	for i := range users {
+		repo.add(users[i])
+	}
+	return repo
+}
+
+func (r *fakeUserRepository) add(user model.User) *model.User {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if user.ID == 0 {
+		r.nextID++
+		user.ID = 100 + r.nextID
+	}
+	r.users[user.ID] = &user
+	return &user
+}
+
+func (r *fakeUserRepository) get(id int) model.User {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return *r.users[id]
+}
+
+func (r *fakeUserRepository) update(id int, apply func(user *model.User)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if user, found := r.users[id]; found {
+		apply(user)
+	}
+}
+
+func (r *fakeUserRepository) CreateUser(ctx context.Context, user *model.User) error {
+	*user = *r.add(*user)
+	return nil
+}
+
+func (r *fakeUserRepository) GetUserByID(ctx context.Context, id int) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, found := r.users[id]
+	if !found {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (r *fakeUserRepository) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, user := range r.users {
+		if strings.EqualFold(user.Email, email) {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeUserRepository) CheckUsernameAvailability(ctx context.Context, username string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, user := range r.users {
+		if strings.EqualFold(user.Username, username) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *fakeUserRepository) MarkEmailVerified(ctx context.Context, userID int) error {
+	r.update(userID, func(user *model.User) { user.EmailVerified = true })
+	return nil
+}
+
+func (r *fakeUserRepository) UpdateUser(ctx context.Context, userID int, updated *model.User) error {
+	r.update(userID, func(user *model.User) {
+		if updated.Password != "" {
+			user.Password = updated.Password
+		}
+		if updated.Username != "" {
+			user.Username = updated.Username
+		}
+	})
+	return nil
+}
+
+type fakeTwoFactorRepository struct {
+	users *fakeUserRepository
+
+	mu            sync.Mutex
+	recoveryCodes map[int]map[string]bool
+}
+
+func newFakeTwoFactorRepository(users *fakeUserRepository) *fakeTwoFactorRepository {
+	return &fakeTwoFactorRepository{users: users, recoveryCodes: make(map[int]map[string]bool)}
+}
+
+func (r *fakeTwoFactorRepository) SetTwoFactorSecret(ctx context.Context, userID int, secret string) error {
+	r.users.update(userID, func(user *model.User) {
+		user.TwoFactorSecret = secret
+		user.TwoFactorEnabled = false
+		user.TwoFactorStep = 0
+	})
+	return nil
+}
+
+func (r *fakeTwoFactorRepository) EnableTwoFactor(ctx context.Context, userID int) error {
+	r.users.update(userID, func(user *model.User) { user.TwoFactorEnabled = true })
+	return nil
+}
+
+func (r *fakeTwoFactorRepository) DisableTwoFactor(ctx context.Context, userID int) error {
+	r.users.update(userID, func(user *model.User) {
+		user.TwoFactorSecret = ""
+		user.TwoFactorEnabled = false
+		user.TwoFactorStep = 0
+	})
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.recoveryCodes, userID)
+	return nil
+}
+
+func (r *fakeTwoFactorRepository) MarkTwoFactorStepUsed(ctx context.Context, userID int, step int64) (bool, error) {
+	marked := false
+	r.users.update(userID, func(user *model.User) {
+		if user.TwoFactorStep < step {
+			user.TwoFactorStep = step
+			marked = true
+		}
+	})
+	return marked, nil
+}
+
+func (r *fakeTwoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	codes := make(map[string]bool, len(codeHashes))
+	for _, hash := range codeHashes {
+		codes[hash] = true
+	}
+	r.recoveryCodes[userID] = codes
+	return nil
+}
+
+func (r *fakeTwoFactorRepository) ConsumeRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.recoveryCodes[userID][codeHash] {
+		return false, nil
+	}
+	delete(r.recoveryCodes[userID], codeHash)
+	return true, nil
+}
+
+type fakeSessionRepository struct {
+	repository.SessionRepository
+
+	mu       sync.Mutex
+	sessions map[string]model.Session
+}
+
+func newFakeSessionRepository() *fakeSessionRepository {
+	return &fakeSessionRepository{sessions: make(map[string]model.Session)}
+}
+
+func (r *fakeSessionRepository) CreateSession(ctx context.Context, session *model.Session, ttl time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sessions[session.ID] = *session
+	return nil
+}
+
+func (r *fakeSessionRepository) SessionExists(ctx context.Context, id string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, found := r.sessions[id]
+	return found, nil
+}
+
+func (r *fakeSessionRepository) DeleteUserSessions(ctx context.Context, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, session := range r.sessions {
+		if session.UserID == userID {
+			delete(r.sessions, id)
+		}
+	}
+	return nil
+}
+
+func (r *fakeSessionRepository) count(userID int) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	count := 0
+	for _, session := range r.sessions {
+		if session.UserID == userID {
+			count++
+		}
+	}
+	return count
+}
+
+// fakeExpiringStore is a tiny Redis stand-in whose keys expire on the fake clock.
+type fakeExpiringStore struct {
+	clock *clock.FakeClock
+
+	mu      sync.Mutex
+	values  map[string]int64
+	expires map[string]time.Time
+}
+
+func newFakeExpiringStore(clk *clock.FakeClock) *fakeExpiringStore {
+	return &fakeExpiringStore{clock: clk, values: make(map[string]int64), expires: make(map[string]time.Time)}
+}
+
+// live must be called with mu held.
+func (s *fakeExpiringStore) live(key string) bool {
+	if _, found := s.values[key]; !found {
+		return false
+	}
+	if expires, found := s.expires[key]; found && !s.clock.Now().Before(expires) {
+		delete(s.values, key)
+		delete(s.expires, key)
+		return false
+	}
+	return true
+}
+
+type fakeLoginAttemptRepository struct {
+	store *fakeExpiringStore
+}
+
+func (r *fakeLoginAttemptRepository) GetLockout(ctx context.Context, subject string) (time.Duration, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	key := "lockout:" + subject
+	if !r.store.live(key) {
+		return 0, nil
+	}
+	return r.store.expires[key].Sub(r.store.clock.Now()), nil
+}
+
+func (r *fakeLoginAttemptRepository) RegisterFailure(ctx context.Context, subject string, window time.Duration) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	key := "failures:" + subject
+	if !r.store.live(key) {
+		r.store.expires[key] = r.store.clock.Now().Add(window)
+	}
+	r.store.values[key]++
+	return r.store.values[key], nil
+}
+
+func (r *fakeLoginAttemptRepository) IncrementLockoutLevel(ctx context.Context, subject string, ttl time.Duration) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	key := "level:" + subject
+	r.store.live(key)
+	r.store.values[key]++
+	r.store.expires[key] = r.store.clock.Now().Add(ttl)
+	return r.store.values[key], nil
+}
+
+func (r *fakeLoginAttemptRepository) Lock(ctx context.Context, subject string, duration time.Duration) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.values["lockout:"+subject] = 1
+	r.store.expires["lockout:"+subject] = r.store.clock.Now().Add(duration)
+	delete(r.store.values, "failures:"+subject)
+	return nil
+}
+
+func (r *fakeLoginAttemptRepository) ClearFailures(ctx context.Context, subject string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delete(r.store.values, "failures:"+subject)
+	delete(r.store.values, "level:"+subject)
+	return nil
+}
+
+type fakeTwoFactorChallengeRepository struct {
+	store *fakeExpiringStore
+}
+
+func (r *fakeTwoFactorChallengeRepository) CreateChallenge(ctx context.Context, id string, ttl time.Duration) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.values["challenge:"+id] = 0
+	r.store.expires["challenge:"+id] = r.store.clock.Now().Add(ttl)
+	return nil
+}
+
+func (r *fakeTwoFactorChallengeRepository) ChallengeExists(ctx context.Context, id string) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	return r.store.live("challenge:" + id), nil
+}
+
+func (r *fakeTwoFactorChallengeRepository) RegisterChallengeFailure(ctx context.Context, id string, maxFailures int64) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	key := "challenge:" + id
+	if !r.store.live(key) {
+		return 0, nil
+	}
+	r.store.values[key]++
+	failures := r.store.values[key]
+	if failures >= maxFailures {
+		delete(r.store.values, key)
+	}
+	return failures, nil
+}
+
+func (r *fakeTwoFactorChallengeRepository) ConsumeChallenge(ctx context.Context, id string) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	key := "challenge:" + id
+	if !r.store.live(key) {
+		return false, nil
+	}
+	delete(r.store.values, key)
+	return true, nil
+}
+
+type fakeLoginLockoutRepository struct {
+	repository.LoginLockoutRepository
+
+	mu       sync.Mutex
+	lockouts []model.LoginLockout
+}
+
+func (r *fakeLoginLockoutRepository) CreateLoginLockout(ctx context.Context, lockout *model.LoginLockout) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lockouts = append(r.lockouts, *lockout)
+	return nil
+}
+
+type fakePasswordResetRepository struct {
+	clock *clock.FakeClock
+
+	mu     sync.Mutex
+	tokens []model.PasswordResetToken
+}
+
+func (r *fakePasswordResetRepository) CreatePasswordResetToken(ctx context.Context, resetToken *model.PasswordResetToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	resetToken.ID = len(r.tokens) + 1
+	r.tokens = append(r.tokens, *resetToken)
+	return nil
+}
+
+func (r *fakePasswordResetRepository) GetPasswordResetTokenByHash(ctx context.Context, tokenHash string) (*model.PasswordResetToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, resetToken := range r.tokens {
+		if resetToken.TokenHash == tokenHash {
+			return &resetToken, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakePasswordResetRepository) ConsumePasswordResetToken(ctx context.Context, id int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.clock.Now()
+	for i := range r.tokens {
+		resetToken := &r.tokens[i]
+		if resetToken.ID == id && resetToken.UsedAt == nil && resetToken.ExpiresAt.After(now) {
+			resetToken.UsedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakePasswordResetRepository) InvalidateUserPasswordResetTokens(ctx context.Context, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.clock.Now()
+	for i := range r.tokens {
+		if r.tokens[i].UserID == userID && r.tokens[i].UsedAt == nil {
+			r.tokens[i].UsedAt = &now
+		}
+	}
+	return nil
+}
+
+type fakeOIDCStateRepository struct {
+	repository.OIDCStateRepository
+
+	mu     sync.Mutex
+	states map[string]model.OIDCAuthState
+}
+
+func newFakeOIDCStateRepository() *fakeOIDCStateRepository {
+	return &fakeOIDCStateRepository{states: make(map[string]model.OIDCAuthState)}
+}
+
+func (r *fakeOIDCStateRepository) SaveState(ctx context.Context, state string, authState *model.OIDCAuthState, ttl time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.states[state] = *authState
+	return nil
+}
+
+func (r *fakeOIDCStateRepository) ConsumeState(ctx context.Context, state string) (*model.OIDCAuthState, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	authState, found := r.states[state]
+	if !found {
+		return nil, errors.New("state not found")
+	}
+	delete(r.states, state)
+	return &authState, nil
+}
+
+func (r *fakeOIDCStateRepository) update(state string, apply func(authState *model.OIDCAuthState)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if authState, found := r.states[state]; found {
+		apply(&authState)
+		r.states[state] = authState
+	}
+}
+
+type fakeUserIdentityRepository struct {
+	repository.UserIdentityRepository
+
+	mu         sync.Mutex
+	identities []model.UserIdentity
+}
+
+func (r *fakeUserIdentityRepository) CreateUserIdentity(ctx context.Context, identity *model.UserIdentity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	identity.ID = len(r.identities) + 1
+	r.identities = append(r.identities, *identity)
+	return nil
+}
+
+func (r *fakeUserIdentityRepository) GetUserIdentity(ctx context.Context, provider, subject string) (*model.UserIdentity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, identity := range r.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			copied := identity
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// doJSON sends body to handler as JSON, authenticated as principal when it is not nil, and decodes
+// the JSON response into a map.
+func doJSON(t *testing.T, handler http.HandlerFunc, principal *middleware.Principal, body interface{}) (int, map[string]interface{}) {
+	t.Helper()
+
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		t.Fatalf("encoding request: %v", err)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(encoded))
+	r.Header.Set("Content-Type", "application/json")
+	if principal != nil {
+		r = r.WithContext(middleware.WithPrincipal(r.Context(), principal))
+	}
+
+	w := httptest.NewRecorder()
+	handler(w, r)
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decoding response %q: %v", w.Body.String(), err)
+	}
+	return w.Code, response
+}
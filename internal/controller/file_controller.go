@@ -1,36 +1,63 @@
 package controller
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"path/filepath"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/temuka-api-service/config"
+	"github.com/temuka-api-service/internal/model"
+	"github.com/temuka-api-service/internal/repository"
+	"github.com/temuka-api-service/pkg/helper"
 	httputil "github.com/temuka-api-service/pkg/http"
+	"github.com/temuka-api-service/pkg/thumbnail"
+)
+
+const (
+	// maxThumbnailSize is the longest side of generated thumbnails, in pixels.
+	maxThumbnailSize = 320
+	// maxImagePixels keeps small files that decode into huge images from exhausting memory.
+	maxImagePixels = 50_000_000
 )
 
 type FileUploadHandler struct {
-	AllowedExtensions map[string]bool
+	AllowedExtensions map[string]string
 	UploadDirectory   string
+	MediaRepository   repository.MediaRepository
 }
 
-func NewFileUploadController(uploadDir string) *FileUploadHandler {
+func NewFileUploadController(uploadDir string, mediaRepo repository.MediaRepository) *FileUploadHandler {
 	return &FileUploadHandler{
-		AllowedExtensions: map[string]bool{
-			".jpg":  true,
-			".png":  true,
-			".mp4":  true,
-			".mkv":  true,
-			".jpeg": true,
+		AllowedExtensions: map[string]string{
+			".jpg":  "image/jpeg",
+			".png":  "image/png",
+			".mp4":  "video/mp4",
+			".mkv":  "video/x-matroska",
+			".jpeg": "image/jpeg",
 		},
 		UploadDirectory: uploadDir,
+		MediaRepository: mediaRepo,
 	}
 }
 
+// Upload stores a file and records it as media of the uploader, which they can then attach to their
+// posts. Images are measured and get a thumbnail.
 func (h *FileUploadHandler) Upload(w http.ResponseWriter, r *http.Request) {
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
 	if err := r.ParseMultipartForm(10 << 20); err != nil {
 		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Could not parse multipart form"})
 		return
@@ -43,33 +70,102 @@ func (h *FileUploadHandler) Upload(w http.ResponseWriter, r *http.Request) {
 	}
 	defer file.Close()
 
-	ext := filepath.Ext(handler.Filename)
-	if !h.AllowedExtensions[ext] {
+	ext := strings.ToLower(filepath.Ext(handler.Filename))
+	mimeType, allowed := h.AllowedExtensions[ext]
+	if !allowed {
 		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Only images or videos are allowed"})
 		return
 	}
 
-	s3Key := fmt.Sprintf("uploads/%s", handler.Filename)
+	baseKey := fmt.Sprintf("%s/%d/%s", h.UploadDirectory, principal.ID, helper.GenerateRandomID(24))
+	media := model.Media{
+		UserID:     principal.ID,
+		StorageKey: baseKey + ext,
+		MimeType:   mimeType,
+		Size:       handler.Size,
+	}
 
-	_, err = config.S3Client.PutObject(context.TODO(), &s3.PutObjectInput{
-		Bucket:      aws.String(config.S3Bucket),
-		Key:         aws.String(s3Key),
-		Body:        file,
-		ContentType: aws.String(handler.Header.Get("Content-Type")),
-	})
+	var thumbnailData []byte
+	if strings.HasPrefix(mimeType, "image/") {
+		media.Width, media.Height, thumbnailData, err = readImage(file)
+		if err != nil {
+			httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Could not read image"})
+			return
+		}
+	}
 
-	if err != nil {
+	if err := putObject(media.StorageKey, file, mimeType); err != nil {
 		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Could not upload file to S3"})
 		return
 	}
+	media.URL = objectURL(media.StorageKey)
+
+	if thumbnailData != nil {
+		thumbnailKey := baseKey + "_thumb.jpg"
+		if err := putObject(thumbnailKey, bytes.NewReader(thumbnailData), "image/jpeg"); err != nil {
+			httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Could not upload thumbnail to S3"})
+			return
+		}
+		media.ThumbnailURL = objectURL(thumbnailKey)
+	}
+
+	if err := h.MediaRepository.CreateMedia(context.Background(), &media); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error saving media"})
+		return
+	}
 
 	response := struct {
-		Message string `json:"message"`
-		URL     string `json:"url"`
+		Message string      `json:"message"`
+		URL     string      `json:"url"`
+		Data    model.Media `json:"data"`
 	}{
 		Message: "File has been uploaded",
-		URL:     fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", config.S3Bucket, config.S3Client.Options().Region, s3Key),
+		URL:     media.URL,
+		Data:    media,
 	}
 
 	httputil.WriteResponse(w, http.StatusOK, response)
 }
+
+// readImage returns the dimensions of an uploaded image and its JPEG thumbnail, rewinding the file
+// so that it can be uploaded afterwards.
+func readImage(file multipart.File) (int, int, []byte, error) {
+	cfg, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	if cfg.Width*cfg.Height > maxImagePixels {
+		return 0, 0, nil, fmt.Errorf("image of %dx%d pixels is too large", cfg.Width, cfg.Height)
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return 0, 0, nil, err
+	}
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	thumbnailData, err := thumbnail.JPEG(img, maxThumbnailSize)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return 0, 0, nil, err
+	}
+	return cfg.Width, cfg.Height, thumbnailData, nil
+}
+
+func putObject(key string, body io.Reader, contentType string) error {
+	_, err := config.S3Client.PutObject(context.TODO(), &s3.PutObjectInput{
+		Bucket:      aws.String(config.S3Bucket),
+		Key:         aws.String(key),
+		Body:        body,
+		ContentType: aws.String(contentType),
+	})
+	return err
+}
+
+func objectURL(key string) string {
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", config.S3Bucket, config.S3Client.Options().Region, key)
+}
@@ -85,19 +85,25 @@ func (c *LocationControllerImpl) UpdateLocation(w http.ResponseWriter, r *http.R
 }
 
 func (c *LocationControllerImpl) GetLocations(w http.ResponseWriter, r *http.Request) {
+	page, ok := requestPage(w, r)
+	if !ok {
+		return
+	}
 
-	locations, err := c.LocationRepository.GetLocations(context.Background())
+	locations, nextCursor, err := c.LocationRepository.GetLocations(context.Background(), page)
 	if err != nil {
 		httputil.WriteResponse(w, http.StatusOK, map[string]string{"error": "Locations not found"})
 		return
 	}
 
 	response := struct {
-		Message string           `json:"message"`
-		Data    []model.Location `json:"data"`
+		Message    string           `json:"message"`
+		Data       []model.Location `json:"data"`
+		NextCursor string           `json:"next_cursor"`
 	}{
-		Message: "Data has been retrieved successfully",
-		Data:    locations,
+		Message:    "Data has been retrieved successfully",
+		Data:       locations,
+		NextCursor: nextCursor,
 	}
 
 	httputil.WriteResponse(w, http.StatusOK, response)
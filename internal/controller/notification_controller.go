@@ -35,18 +35,35 @@ func (c *NotificationControllerImpl) GetNotificationsByUser(w http.ResponseWrite
 		return
 	}
 
-	notifications, err := c.NotificationRepository.GetNotificationsByUserID(context.Background(), userID)
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	if userID != principal.ID {
+		httputil.WriteResponse(w, http.StatusForbidden, map[string]string{"error": "You are not allowed to view these notifications"})
+		return
+	}
+
+	page, ok := requestPage(w, r)
+	if !ok {
+		return
+	}
+
+	notifications, nextCursor, err := c.NotificationRepository.GetNotificationsByUserID(context.Background(), userID, page)
 	if err != nil {
 		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving notifications"})
 		return
 	}
 
 	response := struct {
-		Message string               `json:"message"`
-		Data    []model.Notification `json:"data"`
+		Message    string               `json:"message"`
+		Data       []model.Notification `json:"data"`
+		NextCursor string               `json:"next_cursor"`
 	}{
-		Message: "User posts have been retrieved",
-		Data:    notifications,
+		Message:    "User posts have been retrieved",
+		Data:       notifications,
+		NextCursor: nextCursor,
 	}
 
 	httputil.WriteResponse(w, http.StatusOK, response)
@@ -0,0 +1,257 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/temuka-api-service/internal/model"
+	"github.com/temuka-api-service/internal/repository"
+	"github.com/temuka-api-service/pkg/clock"
+	httputil "github.com/temuka-api-service/pkg/http"
+	"github.com/temuka-api-service/pkg/oidc"
+	"github.com/temuka-api-service/pkg/token"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const oidcStateTTL = 10 * time.Minute
+
+type OIDCController interface {
+	Login(w http.ResponseWriter, r *http.Request)
+	Callback(w http.ResponseWriter, r *http.Request)
+}
+
+type OIDCControllerImpl struct {
+	Providers              *oidc.Registry
+	OIDCStateRepository    repository.OIDCStateRepository
+	UserIdentityRepository repository.UserIdentityRepository
+	UserRepository         repository.UserRepository
+	SessionRepository      repository.SessionRepository
+	ChallengeRepository    repository.TwoFactorChallengeRepository
+	Clock                  clock.Clock
+}
+
+func NewOIDCController(providers *oidc.Registry, stateRepo repository.OIDCStateRepository, identityRepo repository.UserIdentityRepository, userRepo repository.UserRepository, sessionRepo repository.SessionRepository, challengeRepo repository.TwoFactorChallengeRepository, clk clock.Clock) OIDCController {
+	return &OIDCControllerImpl{
+		Providers:              providers,
+		OIDCStateRepository:    stateRepo,
+		UserIdentityRepository: identityRepo,
+		UserRepository:         userRepo,
+		SessionRepository:      sessionRepo,
+		ChallengeRepository:    challengeRepo,
+		Clock:                  clk,
+	}
+}
+
+func (c *OIDCControllerImpl) Login(w http.ResponseWriter, r *http.Request) {
+	providerName := mux.Vars(r)["provider"]
+
+	provider, err := c.Providers.Get(context.Background(), providerName)
+	if errors.Is(err, oidc.ErrUnknownProvider) {
+		httputil.WriteResponse(w, http.StatusNotFound, map[string]string{"error": "Unknown login provider"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error loading oidc provider %s: %v", providerName, err)
+		httputil.WriteResponse(w, http.StatusBadGateway, map[string]string{"error": "Login provider is unavailable"})
+		return
+	}
+
+	state, err := token.GenerateRandomToken(32)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error starting login"})
+		return
+	}
+	nonce, err := token.GenerateRandomToken(32)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error starting login"})
+		return
+	}
+	codeVerifier, err := token.GenerateRandomToken(48)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error starting login"})
+		return
+	}
+
+	authState := model.OIDCAuthState{
+		Provider:     provider.Name(),
+		CodeVerifier: codeVerifier,
+		Nonce:        nonce,
+	}
+
+	if err := c.OIDCStateRepository.SaveState(context.Background(), state, &authState, oidcStateTTL); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error starting login"})
+		return
+	}
+
+	http.Redirect(w, r, provider.AuthCodeURL(state, nonce, oidc.CodeChallengeS256(codeVerifier)), http.StatusFound)
+}
+
+func (c *OIDCControllerImpl) Callback(w http.ResponseWriter, r *http.Request) {
+	providerName := mux.Vars(r)["provider"]
+	query := r.URL.Query()
+
+	if query.Get("error") != "" {
+		httputil.WriteResponse(w, http.StatusUnauthorized, map[string]string{"error": "Login was cancelled or denied by the provider"})
+		return
+	}
+
+	authState, err := c.OIDCStateRepository.ConsumeState(context.Background(), query.Get("state"))
+	if err != nil || authState.Provider != providerName {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid or expired login state"})
+		return
+	}
+
+	provider, err := c.Providers.Get(context.Background(), providerName)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusBadGateway, map[string]string{"error": "Login provider is unavailable"})
+		return
+	}
+
+	tokenResponse, err := provider.Exchange(context.Background(), query.Get("code"), authState.CodeVerifier)
+	if err != nil {
+		log.Printf("Error exchanging oidc code with %s: %v", providerName, err)
+		httputil.WriteResponse(w, http.StatusUnauthorized, map[string]string{"error": "Could not complete login with the provider"})
+		return
+	}
+
+	claims, err := provider.VerifyIDToken(context.Background(), tokenResponse.IDToken, authState.Nonce)
+	if err != nil {
+		log.Printf("Error verifying oidc id token from %s: %v", providerName, err)
+		httputil.WriteResponse(w, http.StatusUnauthorized, map[string]string{"error": "Could not complete login with the provider"})
+		return
+	}
+
+	user, err := c.resolveUser(context.Background(), providerName, claims)
+	if err != nil {
+		if errors.Is(err, errUnverifiedProviderEmail) {
+			httputil.WriteResponse(w, http.StatusForbidden, map[string]string{"error": "The provider did not confirm a verified email address"})
+			return
+		}
+		log.Printf("Error resolving oidc user from %s: %v", providerName, err)
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error completing login"})
+		return
+	}
+
+	completeLogin(w, r, c.SessionRepository, c.ChallengeRepository, user, c.Clock.Now())
+}
+
+var errUnverifiedProviderEmail = errors.New("provider email is not verified")
+
+// resolveUser finds the user linked to the provider account. On first login the account is linked
+// to an existing user with the same email, or a new user is created. A local account whose email was
+// never verified may have been registered by someone else in advance, so its password is cleared and
+// its sessions are revoked before the provider account takes it over.
+func (c *OIDCControllerImpl) resolveUser(ctx context.Context, providerName string, claims *oidc.IDTokenClaims) (*model.User, error) {
+	identity, err := c.UserIdentityRepository.GetUserIdentity(ctx, providerName, claims.Subject)
+	if err == nil {
+		return c.UserRepository.GetUserByID(ctx, identity.UserID)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	email := strings.ToLower(strings.TrimSpace(claims.Email))
+	if email == "" || !claims.EmailVerified {
+		return nil, errUnverifiedProviderEmail
+	}
+
+	user, err := c.UserRepository.GetUserByEmail(ctx, email)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if user == nil {
+		user, err = c.createUser(ctx, email, claims.Name)
+		if err != nil {
+			return nil, err
+		}
+	} else if !user.EmailVerified {
+		if err := c.claimUnverifiedUser(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+
+	newIdentity := model.UserIdentity{
+		UserID:   user.ID,
+		Provider: providerName,
+		Subject:  claims.Subject,
+		Email:    email,
+	}
+	if err := c.UserIdentityRepository.CreateUserIdentity(ctx, &newIdentity); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// claimUnverifiedUser locks out whoever registered the account with the password they chose, and
+// marks the email as verified now that the provider has confirmed it.
+func (c *OIDCControllerImpl) claimUnverifiedUser(ctx context.Context, user *model.User) error {
+	hashedPwd, err := unusablePassword()
+	if err != nil {
+		return err
+	}
+	user.Password = hashedPwd
+	if err := c.UserRepository.UpdateUser(ctx, user.ID, user); err != nil {
+		return err
+	}
+	if err := c.SessionRepository.DeleteUserSessions(ctx, user.ID); err != nil {
+		return err
+	}
+	if err := c.UserRepository.MarkEmailVerified(ctx, user.ID); err != nil {
+		return err
+	}
+	user.EmailVerified = true
+	return nil
+}
+
+func (c *OIDCControllerImpl) createUser(ctx context.Context, email, name string) (*model.User, error) {
+	username := strings.SplitN(email, "@", 2)[0]
+	if !c.UserRepository.CheckUsernameAvailability(ctx, username) {
+		suffix, err := token.GenerateRandomToken(3)
+		if err != nil {
+			return nil, err
+		}
+		username = username + "_" + suffix
+	}
+
+	hashedPwd, err := unusablePassword()
+	if err != nil {
+		return nil, err
+	}
+
+	now := c.Clock.Now()
+	newUser := model.User{
+		Username:        username,
+		Displayname:     name,
+		Email:           email,
+		EmailVerified:   true,
+		EmailVerifiedAt: &now,
+		Password:        hashedPwd,
+	}
+
+	if err := c.UserRepository.CreateUser(ctx, &newUser); err != nil {
+		return nil, err
+	}
+	return &newUser, nil
+}
+
+// unusablePassword hashes a random password nobody knows. The account can then only be used through
+// the provider until the user sets a password via the reset flow.
+func unusablePassword() (string, error) {
+	randomPassword, err := token.GenerateRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+	hashedPwd, err := bcrypt.GenerateFromPassword([]byte(randomPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashedPwd), nil
+}
@@ -0,0 +1,402 @@
+package controller
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gorilla/mux"
+	"github.com/temuka-api-service/internal/model"
+	"github.com/temuka-api-service/pkg/oidc"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	mockProviderName = "mock"
+	mockClientID     = "temuka"
+	mockRedirectURL  = "http://localhost/auth/oidc/mock/callback"
+	mockKeyID        = "mock-key"
+)
+
+// mockAccount is the account the user signs in with at the mock issuer.
+type mockAccount struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	// Nonce replaces the nonce of the authorization request in the ID token when set.
+	Nonce string
+}
+
+type mockAuthorization struct {
+	account       mockAccount
+	codeChallenge string
+	nonce         string
+}
+
+// mockIssuer is an OpenID Connect provider serving discovery, JWKS and the token endpoint. The
+// authorization step is done by authorize, in place of the user's browser.
+type mockIssuer struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]mockAuthorization
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+
+	issuer := &mockIssuer{key: key, codes: make(map[string]mockAuthorization)}
+
+	router := http.NewServeMux()
+	router.HandleFunc("/.well-known/openid-configuration", issuer.discovery)
+	router.HandleFunc("/jwks", issuer.jwks)
+	router.HandleFunc("/token", issuer.token)
+	issuer.server = httptest.NewServer(router)
+	t.Cleanup(issuer.server.Close)
+
+	return issuer
+}
+
+func (i *mockIssuer) discovery(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]string{
+		"issuer":                 i.server.URL,
+		"authorization_endpoint": i.server.URL + "/authorize",
+		"token_endpoint":         i.server.URL + "/token",
+		"jwks_uri":               i.server.URL + "/jwks",
+	})
+}
+
+func (i *mockIssuer) jwks(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": mockKeyID,
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(i.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(i.key.E)).Bytes()),
+		}},
+	})
+}
+
+// token redeems an authorization code once, and only with the code verifier matching the PKCE
+// challenge it was issued for.
+func (i *mockIssuer) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+
+	i.mu.Lock()
+	authorization, found := i.codes[r.PostForm.Get("code")]
+	delete(i.codes, r.PostForm.Get("code"))
+	i.mu.Unlock()
+
+	if !found ||
+		r.PostForm.Get("grant_type") != "authorization_code" ||
+		r.PostForm.Get("client_id") != mockClientID ||
+		r.PostForm.Get("redirect_uri") != mockRedirectURL ||
+		oidc.CodeChallengeS256(r.PostForm.Get("code_verifier")) != authorization.codeChallenge {
+		http.Error(w, "invalid_grant", http.StatusBadRequest)
+		return
+	}
+
+	nonce := authorization.nonce
+	if authorization.account.Nonce != "" {
+		nonce = authorization.account.Nonce
+	}
+	now := time.Now()
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            i.server.URL,
+		"sub":            authorization.account.Subject,
+		"aud":            mockClientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"email":          authorization.account.Email,
+		"email_verified": authorization.account.EmailVerified,
+		"name":           "Mock User",
+		"nonce":          nonce,
+	})
+	idToken.Header["kid"] = mockKeyID
+	signed, err := idToken.SignedString(i.key)
+	if err != nil {
+		http.Error(w, "server_error", http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(oidc.TokenResponse{AccessToken: "access", TokenType: "Bearer", IDToken: signed, ExpiresIn: 300})
+}
+
+// authorize follows the redirect to the authorization endpoint, signs in as account and returns the
+// query the provider redirects back to the callback with.
+func (i *mockIssuer) authorize(t *testing.T, location string, account mockAccount) url.Values {
+	t.Helper()
+
+	redirect, err := url.Parse(location)
+	if err != nil {
+		t.Fatalf("parsing redirect %q: %v", location, err)
+	}
+	query := redirect.Query()
+	if redirect.Path != "/authorize" || query.Get("client_id") != mockClientID || query.Get("redirect_uri") != mockRedirectURL {
+		t.Fatalf("unexpected authorization request %q", location)
+	}
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" || query.Get("nonce") == "" {
+		t.Fatalf("authorization request %q is missing PKCE or nonce", location)
+	}
+
+	code := "code-" + query.Get("state")
+	i.mu.Lock()
+	i.codes[code] = mockAuthorization{account: account, codeChallenge: query.Get("code_challenge"), nonce: query.Get("nonce")}
+	i.mu.Unlock()
+
+	return url.Values{"code": {code}, "state": {query.Get("state")}}
+}
+
+type oidcTestEnv struct {
+	*authTestEnv
+	issuer     *mockIssuer
+	states     *fakeOIDCStateRepository
+	identities *fakeUserIdentityRepository
+	oidc       *OIDCControllerImpl
+}
+
+func newOIDCTestEnv(t *testing.T) *oidcTestEnv {
+	t.Helper()
+
+	env := &oidcTestEnv{
+		authTestEnv: newAuthTestEnv(t),
+		issuer:      newMockIssuer(t),
+		states:      newFakeOIDCStateRepository(),
+		identities:  &fakeUserIdentityRepository{},
+	}
+	providers := oidc.NewRegistry([]oidc.Config{{
+		Name:        mockProviderName,
+		IssuerURL:   env.issuer.server.URL,
+		ClientID:    mockClientID,
+		RedirectURL: mockRedirectURL,
+	}}, env.issuer.server.Client())
+
+	env.oidc = &OIDCControllerImpl{
+		Providers:              providers,
+		OIDCStateRepository:    env.states,
+		UserIdentityRepository: env.identities,
+		UserRepository:         env.users,
+		SessionRepository:      env.sessions,
+		ChallengeRepository:    env.auth.ChallengeRepository,
+		Clock:                  env.clock,
+	}
+	return env
+}
+
+// login starts the flow and returns the authorization request the user is redirected to.
+func (env *oidcTestEnv) login(t *testing.T) string {
+	t.Helper()
+
+	r := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/", nil), map[string]string{"provider": mockProviderName})
+	w := httptest.NewRecorder()
+	env.oidc.Login(w, r)
+	if w.Code != http.StatusFound {
+		t.Fatalf("login: status %d, body %s", w.Code, w.Body.String())
+	}
+	return w.Header().Get("Location")
+}
+
+func (env *oidcTestEnv) callback(t *testing.T, query url.Values) (int, map[string]interface{}) {
+	t.Helper()
+
+	r := httptest.NewRequest(http.MethodGet, "/?"+query.Encode(), nil)
+	r = mux.SetURLVars(r, map[string]string{"provider": mockProviderName})
+	w := httptest.NewRecorder()
+	env.oidc.Callback(w, r)
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decoding response %q: %v", w.Body.String(), err)
+	}
+	return w.Code, response
+}
+
+func (env *oidcTestEnv) signIn(t *testing.T, account mockAccount) (int, map[string]interface{}) {
+	t.Helper()
+	return env.callback(t, env.issuer.authorize(t, env.login(t), account))
+}
+
+func (env *oidcTestEnv) linkedUserID(subject string) int {
+	identity, err := env.identities.GetUserIdentity(context.Background(), mockProviderName, subject)
+	if err != nil {
+		return 0
+	}
+	return identity.UserID
+}
+
+func TestOIDCCallback(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, env *oidcTestEnv)
+	}{
+		{
+			name: "creates a user on first login and reuses it afterwards",
+			run: func(t *testing.T, env *oidcTestEnv) {
+				account := mockAccount{Subject: "bob-subject", Email: "Bob@Example.com", EmailVerified: true}
+				if status, response := env.signIn(t, account); status != 200 || response["token"] == nil {
+					t.Fatalf("first login: status %d, response %v", status, response)
+				}
+				userID := env.linkedUserID(account.Subject)
+				user := env.users.get(userID)
+				if userID == 0 || user.Email != "bob@example.com" || user.Username != "bob" || !user.EmailVerified {
+					t.Fatalf("created user %+v", user)
+				}
+
+				if status, _ := env.signIn(t, account); status != 200 {
+					t.Fatalf("second login: status %d", status)
+				}
+				if len(env.identities.identities) != 1 || env.linkedUserID(account.Subject) != userID {
+					t.Fatalf("second login linked again: %+v", env.identities.identities)
+				}
+			},
+		},
+		{
+			name: "links a verified local account and keeps its password and sessions",
+			run: func(t *testing.T, env *oidcTestEnv) {
+				passwordHash := env.user.Password
+				env.sessions.CreateSession(context.Background(), &model.Session{ID: "existing", UserID: env.user.ID}, time.Hour)
+
+				account := mockAccount{Subject: "alice-subject", Email: env.user.Email, EmailVerified: true}
+				if status, response := env.signIn(t, account); status != 200 || response["token"] == nil {
+					t.Fatalf("status %d, response %v", status, response)
+				}
+				if env.linkedUserID(account.Subject) != env.user.ID {
+					t.Fatalf("identities %+v", env.identities.identities)
+				}
+				if env.users.get(env.user.ID).Password != passwordHash {
+					t.Fatal("password of a verified account was changed")
+				}
+				if exists, _ := env.sessions.SessionExists(context.Background(), "existing"); !exists {
+					t.Fatal("sessions of a verified account were revoked")
+				}
+			},
+		},
+		{
+			name: "clears the password and sessions of an unverified local account before linking",
+			run: func(t *testing.T, env *oidcTestEnv) {
+				env.users.update(env.user.ID, func(user *model.User) { user.EmailVerified = false })
+				env.sessions.CreateSession(context.Background(), &model.Session{ID: "squatter", UserID: env.user.ID}, time.Hour)
+
+				account := mockAccount{Subject: "alice-subject", Email: env.user.Email, EmailVerified: true}
+				if status, response := env.signIn(t, account); status != 200 || response["token"] == nil {
+					t.Fatalf("status %d, response %v", status, response)
+				}
+				user := env.users.get(env.user.ID)
+				if env.linkedUserID(account.Subject) != user.ID || !user.EmailVerified {
+					t.Fatalf("user %+v, identities %+v", user, env.identities.identities)
+				}
+				if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(testPassword)) == nil {
+					t.Fatal("the password chosen before verification still works")
+				}
+				if exists, _ := env.sessions.SessionExists(context.Background(), "squatter"); exists {
+					t.Fatal("sessions from before verification were not revoked")
+				}
+				if env.sessions.count(user.ID) != 1 {
+					t.Fatalf("got %d sessions, want only the new login", env.sessions.count(user.ID))
+				}
+			},
+		},
+		{
+			name: "asks for the second factor of a linked account",
+			run: func(t *testing.T, env *oidcTestEnv) {
+				env.enable(t)
+
+				account := mockAccount{Subject: "alice-subject", Email: env.user.Email, EmailVerified: true}
+				status, response := env.signIn(t, account)
+				if status != 200 || response["two_factor_required"] != true || response["token"] != nil {
+					t.Fatalf("status %d, response %v", status, response)
+				}
+				if env.sessions.count(env.user.ID) != 0 {
+					t.Fatal("a session was issued before the second factor")
+				}
+			},
+		},
+		{
+			name: "rejects an email the provider has not verified",
+			run: func(t *testing.T, env *oidcTestEnv) {
+				account := mockAccount{Subject: "alice-subject", Email: env.user.Email}
+				if status, _ := env.signIn(t, account); status != 403 {
+					t.Fatalf("status %d, want 403", status)
+				}
+				if len(env.identities.identities) != 0 {
+					t.Fatalf("identities %+v", env.identities.identities)
+				}
+			},
+		},
+		{
+			name: "rejects a code redeemed with another PKCE verifier",
+			run: func(t *testing.T, env *oidcTestEnv) {
+				query := env.issuer.authorize(t, env.login(t), mockAccount{Subject: "bob-subject", Email: "bob@example.com", EmailVerified: true})
+				env.states.update(query.Get("state"), func(authState *model.OIDCAuthState) {
+					authState.CodeVerifier = "attacker-verifier"
+				})
+				if status, _ := env.callback(t, query); status != 401 {
+					t.Fatalf("status %d, want 401", status)
+				}
+				if len(env.identities.identities) != 0 {
+					t.Fatalf("identities %+v", env.identities.identities)
+				}
+			},
+		},
+		{
+			name: "rejects an ID token issued for another nonce",
+			run: func(t *testing.T, env *oidcTestEnv) {
+				account := mockAccount{Subject: "bob-subject", Email: "bob@example.com", EmailVerified: true, Nonce: "replayed-nonce"}
+				if status, _ := env.signIn(t, account); status != 401 {
+					t.Fatalf("status %d, want 401", status)
+				}
+				if len(env.identities.identities) != 0 {
+					t.Fatalf("identities %+v", env.identities.identities)
+				}
+			},
+		},
+		{
+			name: "rejects a replayed callback",
+			run: func(t *testing.T, env *oidcTestEnv) {
+				query := env.issuer.authorize(t, env.login(t), mockAccount{Subject: "bob-subject", Email: "bob@example.com", EmailVerified: true})
+				if status, _ := env.callback(t, query); status != 200 {
+					t.Fatalf("first callback: status %d", status)
+				}
+				if status, _ := env.callback(t, query); status != 400 {
+					t.Fatalf("replayed callback: status %d, want 400", status)
+				}
+			},
+		},
+		{
+			name: "rejects a login denied by the user",
+			run: func(t *testing.T, env *oidcTestEnv) {
+				location, _ := url.Parse(env.login(t))
+				query := url.Values{"error": {"access_denied"}, "state": {location.Query().Get("state")}}
+				if status, _ := env.callback(t, query); status != 401 {
+					t.Fatalf("status %d, want 401", status)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, newOIDCTestEnv(t))
+		})
+	}
+}
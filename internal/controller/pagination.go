@@ -0,0 +1,38 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	httputil "github.com/temuka-api-service/pkg/http"
+	"github.com/temuka-api-service/pkg/pagination"
+)
+
+// requestPage returns the page selected by the cursor and limit query parameters, writing a 400
+// response when either is malformed.
+func requestPage(w http.ResponseWriter, r *http.Request) (pagination.Page, bool) {
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		writePaginationError(w, err)
+		return pagination.Page{}, false
+	}
+	return page, true
+}
+
+// requestRankedPage is requestPage for lists ordered by relevance.
+func requestRankedPage(w http.ResponseWriter, r *http.Request) (pagination.RankedPage, bool) {
+	page, err := pagination.RankedFromRequest(r)
+	if err != nil {
+		writePaginationError(w, err)
+		return pagination.RankedPage{}, false
+	}
+	return page, true
+}
+
+func writePaginationError(w http.ResponseWriter, err error) {
+	message := "Invalid cursor"
+	if errors.Is(err, pagination.ErrInvalidLimit) {
+		message = "Invalid limit"
+	}
+	httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": message})
+}
@@ -0,0 +1,277 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+	"github.com/temuka-api-service/internal/feed"
+	"github.com/temuka-api-service/internal/model"
+	"github.com/temuka-api-service/internal/repository"
+	httputil "github.com/temuka-api-service/pkg/http"
+	"github.com/temuka-api-service/pkg/sse"
+	"gorm.io/gorm"
+)
+
+// pollKeepAliveInterval keeps idle result streams open behind proxies that time out silent connections.
+const pollKeepAliveInterval = 25 * time.Second
+
+// Events sent on a poll result stream.
+const (
+	pollEventResults = "results"
+	pollEventClosed  = "closed"
+)
+
+type PollController interface {
+	Vote(w http.ResponseWriter, r *http.Request)
+	GetVoters(w http.ResponseWriter, r *http.Request)
+	StreamResults(w http.ResponseWriter, r *http.Request)
+}
+
+type PollControllerImpl struct {
+	PollRepository repository.PollRepository
+	PostRepository repository.PostRepository
+	PollService    feed.PollService
+}
+
+func NewPollController(pollRepo repository.PollRepository, postRepo repository.PostRepository, pollService feed.PollService) PollController {
+	return &PollControllerImpl{
+		PollRepository: pollRepo,
+		PostRepository: postRepo,
+		PollService:    pollService,
+	}
+}
+
+// PollRequest is the poll sent along with a new post.
+type PollRequest struct {
+	Options        []string   `json:"options"`
+	MultipleChoice bool       `json:"multiple_choice"`
+	Anonymous      bool       `json:"anonymous"`
+	ClosesAt       *time.Time `json:"closes_at"`
+}
+
+// toPoll validates the poll, writing a 400 response when it is invalid. A poll must stay open after
+// its post is published, which is now unless the post is scheduled.
+func (p *PollRequest) toPoll(w http.ResponseWriter, publishAt *time.Time, now time.Time) (*model.Poll, bool) {
+	if len(p.Options) < model.MinPollOptions || len(p.Options) > model.MaxPollOptions {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("A poll must have between %d and %d options", model.MinPollOptions, model.MaxPollOptions)})
+		return nil, false
+	}
+
+	options := make([]model.PollOption, 0, len(p.Options))
+	seen := make(map[string]bool, len(p.Options))
+	for i, text := range p.Options {
+		text = strings.TrimSpace(text)
+		if text == "" || utf8.RuneCountInString(text) > model.MaxPollOptionLength {
+			httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("Poll options must be between 1 and %d characters", model.MaxPollOptionLength)})
+			return nil, false
+		}
+		if seen[strings.ToLower(text)] {
+			httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Poll options must be unique"})
+			return nil, false
+		}
+		seen[strings.ToLower(text)] = true
+		options = append(options, model.PollOption{Position: i, Text: text})
+	}
+
+	if p.ClosesAt != nil {
+		opensAt := now
+		if publishAt != nil {
+			opensAt = *publishAt
+		}
+		if !p.ClosesAt.After(opensAt) {
+			httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "closes_at must be after the post is published"})
+			return nil, false
+		}
+	}
+
+	return &model.Poll{
+		MultipleChoice: p.MultipleChoice,
+		Anonymous:      p.Anonymous,
+		ClosesAt:       p.ClosesAt,
+		Options:        options,
+	}, true
+}
+
+func (c *PollControllerImpl) Vote(w http.ResponseWriter, r *http.Request) {
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	var requestBody struct {
+		OptionIDs []int `json:"option_ids"`
+	}
+
+	if err := httputil.ReadRequest(r, &requestBody); err != nil {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	poll, ok := c.requestPoll(w, r)
+	if !ok {
+		return
+	}
+
+	results, err := c.PollService.Vote(context.Background(), poll, principal.ID, requestBody.OptionIDs)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrPollClosed):
+			httputil.WriteResponse(w, http.StatusConflict, map[string]string{"error": "Poll is closed"})
+		case errors.Is(err, repository.ErrAlreadyVoted):
+			httputil.WriteResponse(w, http.StatusConflict, map[string]string{"error": "You have already voted in this poll"})
+		case errors.Is(err, repository.ErrInvalidPollOption):
+			httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid poll options"})
+		default:
+			httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error saving vote"})
+		}
+		return
+	}
+
+	response := struct {
+		Message string            `json:"message"`
+		Data    model.PollResults `json:"data"`
+	}{
+		Message: "Vote has been saved",
+		Data:    results,
+	}
+
+	httputil.WriteResponse(w, http.StatusOK, response)
+}
+
+// GetVoters lists who voted for an option. The voters of anonymous polls are never shown.
+func (c *PollControllerImpl) GetVoters(w http.ResponseWriter, r *http.Request) {
+	optionID, err := strconv.Atoi(r.URL.Query().Get("option_id"))
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid option id"})
+		return
+	}
+
+	page, ok := requestPage(w, r)
+	if !ok {
+		return
+	}
+
+	poll, ok := c.requestPoll(w, r)
+	if !ok {
+		return
+	}
+
+	if poll.Anonymous {
+		httputil.WriteResponse(w, http.StatusForbidden, map[string]string{"error": "Votes of anonymous polls are private"})
+		return
+	}
+
+	votes, nextCursor, err := c.PollRepository.GetVoters(context.Background(), poll.ID, optionID, page)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving voters"})
+		return
+	}
+
+	voters := make([]model.PublicUser, 0, len(votes))
+	for _, vote := range votes {
+		voters = append(voters, vote.User.Public())
+	}
+
+	response := struct {
+		Message    string             `json:"message"`
+		Data       []model.PublicUser `json:"data"`
+		NextCursor string             `json:"next_cursor"`
+	}{
+		Message:    "Poll voters have been retrieved",
+		Data:       voters,
+		NextCursor: nextCursor,
+	}
+
+	httputil.WriteResponse(w, http.StatusOK, response)
+}
+
+// StreamResults sends the results of a poll as server-sent events: the current results right away,
+// then the new results after every vote, until the poll closes or the client disconnects.
+func (c *PollControllerImpl) StreamResults(w http.ResponseWriter, r *http.Request) {
+	poll, ok := c.requestPoll(w, r)
+	if !ok {
+		return
+	}
+
+	// Subscribing before reading the current results keeps votes cast in between from being missed.
+	ctx := r.Context()
+	updates, unsubscribe := c.PollService.Subscribe(ctx, poll.ID)
+	defer unsubscribe()
+
+	results, err := c.PollService.Results(ctx, poll)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving poll results"})
+		return
+	}
+
+	stream, err := sse.NewStream(w)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Streaming is not supported"})
+		return
+	}
+
+	if !sendPollResults(stream, results) {
+		return
+	}
+
+	keepAlive := time.NewTicker(pollKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-keepAlive.C:
+			if err := stream.KeepAlive(); err != nil {
+				return
+			}
+		case update, open := <-updates:
+			if !open || !sendPollResults(stream, update) {
+				return
+			}
+		}
+	}
+}
+
+// sendPollResults sends the results and reports whether the stream should go on, which it does until
+// the poll closes.
+func sendPollResults(stream *sse.Stream, results model.PollResults) bool {
+	event := pollEventResults
+	if results.Closed {
+		event = pollEventClosed
+	}
+	return stream.Send(event, results) == nil && !results.Closed
+}
+
+// requestPoll loads the poll of the published post named by the id path variable, writing an error
+// response when there is none.
+func (c *PollControllerImpl) requestPoll(w http.ResponseWriter, r *http.Request) (*model.Poll, bool) {
+	postID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid post id"})
+		return nil, false
+	}
+
+	if _, err := c.PostRepository.GetPostDetailByID(context.Background(), postID); err != nil {
+		httputil.WriteResponse(w, http.StatusNotFound, map[string]string{"error": "Post not found"})
+		return nil, false
+	}
+
+	poll, err := c.PollRepository.GetPollByPostID(context.Background(), postID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			httputil.WriteResponse(w, http.StatusNotFound, map[string]string{"error": "Poll not found"})
+		} else {
+			httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving poll"})
+		}
+		return nil, false
+	}
+	return poll, true
+}
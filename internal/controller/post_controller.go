@@ -2,18 +2,23 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"strconv"
-
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/gorilla/mux"
+	"github.com/temuka-api-service/internal/feed"
 	"github.com/temuka-api-service/internal/model"
+	"github.com/temuka-api-service/internal/rbac"
 	"github.com/temuka-api-service/internal/repository"
+	"github.com/temuka-api-service/pkg/clock"
 	httputil "github.com/temuka-api-service/pkg/http"
-	"github.com/temuka-api-service/pkg/redis"
+	"github.com/temuka-api-service/pkg/textproc"
 	"gorm.io/gorm"
 )
 
@@ -24,7 +29,13 @@ type PostController interface {
 	UpdatePost(w http.ResponseWriter, r *http.Request)
 	DeletePost(w http.ResponseWriter, r *http.Request)
 	GetTimelinePosts(w http.ResponseWriter, r *http.Request)
-	LikePost(w http.ResponseWriter, r *http.Request)
+	GetRecommendedPosts(w http.ResponseWriter, r *http.Request)
+	RepostPost(w http.ResponseWriter, r *http.Request)
+	UndoRepost(w http.ResponseWriter, r *http.Request)
+	QuotePost(w http.ResponseWriter, r *http.Request)
+	GetTagPosts(w http.ResponseWriter, r *http.Request)
+	GetDraftPosts(w http.ResponseWriter, r *http.Request)
+	GetTrendingPosts(w http.ResponseWriter, r *http.Request)
 }
 
 type PostControllerImpl struct {
@@ -34,9 +45,23 @@ type PostControllerImpl struct {
 	ReportRepository       repository.ReportRepository
 	CommunityRepository    repository.CommunityRepository
 	CommentRepository      repository.CommentRepository
+	PostReactionRepository repository.PostReactionRepository
+	BookmarkRepository     repository.BookmarkRepository
+	TagRepository          repository.TagRepository
+	PostRevisionRepository repository.PostRevisionRepository
+	MediaRepository        repository.MediaRepository
+	Authorizer             rbac.Authorizer
+	TimelineService        feed.TimelineService
+	RecommendationService  feed.RecommendationService
+	TagService             feed.TagService
+	PublishingService      feed.PublishingService
+	PollService            feed.PollService
+	AnalyticsService       feed.AnalyticsService
+	TrendingService        feed.TrendingService
+	Clock                  clock.Clock
 }
 
-func NewPostController(postRepo repository.PostRepository, notificationRepo repository.NotificationRepository, userRepo repository.UserRepository, reportRepo repository.ReportRepository, communityRepo repository.CommunityRepository, commentRepo repository.CommentRepository) PostController {
+func NewPostController(postRepo repository.PostRepository, notificationRepo repository.NotificationRepository, userRepo repository.UserRepository, reportRepo repository.ReportRepository, communityRepo repository.CommunityRepository, commentRepo repository.CommentRepository, postReactionRepo repository.PostReactionRepository, bookmarkRepo repository.BookmarkRepository, tagRepo repository.TagRepository, postRevisionRepo repository.PostRevisionRepository, mediaRepo repository.MediaRepository, authorizer rbac.Authorizer, timelineService feed.TimelineService, recommendationService feed.RecommendationService, tagService feed.TagService, publishingService feed.PublishingService, pollService feed.PollService, analyticsService feed.AnalyticsService, trendingService feed.TrendingService, clk clock.Clock) PostController {
 	return &PostControllerImpl{
 		PostRepository:         postRepo,
 		NotificationRepository: notificationRepo,
@@ -44,15 +69,37 @@ func NewPostController(postRepo repository.PostRepository, notificationRepo repo
 		ReportRepository:       reportRepo,
 		CommunityRepository:    communityRepo,
 		CommentRepository:      commentRepo,
+		PostReactionRepository: postReactionRepo,
+		BookmarkRepository:     bookmarkRepo,
+		TagRepository:          tagRepo,
+		PostRevisionRepository: postRevisionRepo,
+		MediaRepository:        mediaRepo,
+		Authorizer:             authorizer,
+		TimelineService:        timelineService,
+		RecommendationService:  recommendationService,
+		TagService:             tagService,
+		PublishingService:      publishingService,
+		PollService:            pollService,
+		AnalyticsService:       analyticsService,
+		TrendingService:        trendingService,
+		Clock:                  clk,
 	}
 }
 
 func (c *PostControllerImpl) CreatePost(w http.ResponseWriter, r *http.Request) {
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
 	var requestBody struct {
-		Title       string `json:"title"`
-		Description string `json:"description"`
-		UserID      int    `json:"user_id"`
-		CommunityID int    `json:"community_id"`
+		Title       string             `json:"title"`
+		Description string             `json:"description"`
+		CommunityID int                `json:"community_id"`
+		Status      string             `json:"status"`
+		PublishAt   *time.Time         `json:"publish_at"`
+		Poll        *PollRequest       `json:"poll"`
+		Media       []PostMediaRequest `json:"media"`
 	}
 
 	if err := httputil.ReadRequest(r, &requestBody); err != nil {
@@ -60,19 +107,67 @@ func (c *PostControllerImpl) CreatePost(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	now := c.Clock.Now()
+	status, publishAt, ok := requestPostStatus(w, requestBody.Status, requestBody.PublishAt, now)
+	if !ok {
+		return
+	}
+
+	var poll *model.Poll
+	if requestBody.Poll != nil {
+		if poll, ok = requestBody.Poll.toPoll(w, publishAt, now); !ok {
+			return
+		}
+	}
+
+	media, image, ok := c.requestPostMedia(w, principal.ID, requestBody.Media)
+	if !ok {
+		return
+	}
+
+	if requestBody.CommunityID != 0 {
+		allowed, err := c.Authorizer.HasCommunityPermission(context.Background(), principal.Roles, principal.ID, requestBody.CommunityID, rbac.PermissionPostInCommunity)
+		if err != nil {
+			httputil.WriteResponse(w, http.StatusNotFound, map[string]string{"error": "Community not found"})
+			return
+		}
+		if !allowed {
+			httputil.WriteResponse(w, http.StatusForbidden, map[string]string{"error": "Only community members can post in this community"})
+			return
+		}
+	}
+
 	newPost := model.Post{
 		Title:       requestBody.Title,
 		Description: requestBody.Description,
-		UserID:      requestBody.UserID,
+		Image:       image,
+		Media:       media,
+		UserID:      principal.ID,
+		Status:      status,
+		PublishAt:   publishAt,
+		Poll:        poll,
+	}
+	if requestBody.CommunityID != 0 {
+		newPost.CommunityID = &requestBody.CommunityID
 	}
 
-	if err := c.PostRepository.CreatePost(context.Background(), &newPost); err != nil {
+	if err := c.PublishingService.CreatePost(context.Background(), &newPost); err != nil {
 		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error creating post"})
 		return
 	}
 
-	if err := c.CommunityRepository.UpdateCommunityPostsCount(context.Background(), requestBody.CommunityID); err != nil {
-		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error updating community posts count"})
+	if err := c.TagService.LinkPosts(context.Background(), &newPost); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving post details"})
+		return
+	}
+
+	if err := c.PollService.AttachPolls(context.Background(), principal.ID, &newPost); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving post details"})
+		return
+	}
+
+	if err := c.attachMedia(context.Background(), &newPost); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving post details"})
 		return
 	}
 
@@ -97,37 +192,59 @@ func (c *PostControllerImpl) GetPostDetail(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	post, err := c.PostRepository.GetPostDetailByID(context.Background(), postID)
-	if err != nil {
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	post, err := c.PostRepository.GetPostByIDAnyStatus(context.Background(), postID)
+	if err != nil || (post.Status != model.PostStatusPublished && post.UserID != principal.ID) {
 		httputil.WriteResponse(w, http.StatusNotFound, map[string]string{"error": "Post not found"})
 		return
 	}
 
+	if err := c.decoratePosts(context.Background(), principal.ID, post); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving post details"})
+		return
+	}
+	c.AnalyticsService.RecordViews(context.Background(), model.ViewKindDetail, principal.ID, post)
+
 	user, err := c.UserRepository.GetUserByID(context.Background(), post.UserID)
 	if err != nil {
 		httputil.WriteResponse(w, http.StatusNotFound, map[string]string{"error": "User not found"})
 		return
 	}
 
-	comments, err := c.CommentRepository.GetCommentsByPostID(context.Background(), postID)
+	page, ok := requestPage(w, r)
+	if !ok {
+		return
+	}
+
+	comments, nextCursor, err := c.CommentRepository.GetCommentsByPostID(context.Background(), postID, page)
 	if err != nil {
 		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving comments"})
 		return
 	}
 
+	if err := c.TagService.LinkComments(context.Background(), commentPointers(comments)...); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving comments"})
+		return
+	}
+
 	type UserData struct {
 		Username       string `json:"Username"`
 		ProfilePicture string `json:"ProfilePicture"`
 	}
 
 	type Comment struct {
-		ID        int       `json:"ID"`
-		Content   string    `json:"Content"`
-		Username  string    `json:"Username"`
-		UserPhoto string    `json:"Userphoto"`
-		Votes     int       `json:"Votes"`
-		CreatedAt time.Time `json:"CreatedAt"`
-		UpdatedAt time.Time `json:"UpdatedAt"`
+		ID        int                `json:"ID"`
+		Content   string             `json:"Content"`
+		Username  string             `json:"Username"`
+		UserPhoto string             `json:"Userphoto"`
+		Votes     int                `json:"Votes"`
+		Entities  []model.TextEntity `json:"Entities"`
+		CreatedAt time.Time          `json:"CreatedAt"`
+		UpdatedAt time.Time          `json:"UpdatedAt"`
 	}
 
 	postComments := make([]Comment, 0, len(comments))
@@ -144,6 +261,7 @@ func (c *PostControllerImpl) GetPostDetail(w http.ResponseWriter, r *http.Reques
 			Username:  commentUser.Username,
 			UserPhoto: commentUser.ProfilePicture,
 			Votes:     len(comment.Votes),
+			Entities:  comment.Entities,
 			CreatedAt: comment.CreatedAt,
 			UpdatedAt: comment.UpdatedAt,
 		})
@@ -161,8 +279,9 @@ func (c *PostControllerImpl) GetPostDetail(w http.ResponseWriter, r *http.Reques
 	}
 
 	response := struct {
-		Message string       `json:"message"`
-		Data    ResponseData `json:"data"`
+		Message    string       `json:"message"`
+		Data       ResponseData `json:"data"`
+		NextCursor string       `json:"next_cursor"`
 	}{
 		Message: "Post detail has been retrieved",
 		Data: ResponseData{
@@ -170,6 +289,7 @@ func (c *PostControllerImpl) GetPostDetail(w http.ResponseWriter, r *http.Reques
 			Post:     *post,
 			Comments: postComments,
 		},
+		NextCursor: nextCursor,
 	}
 
 	httputil.WriteResponse(w, http.StatusOK, response)
@@ -185,18 +305,36 @@ func (c *PostControllerImpl) GetUserPosts(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	posts, err := c.PostRepository.GetPostsByUserID(context.Background(), userID)
+	page, ok := requestPage(w, r)
+	if !ok {
+		return
+	}
+
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	posts, nextCursor, err := c.PostRepository.GetPostsByUserID(context.Background(), userID, page)
 	if err != nil {
 		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving posts"})
 		return
 	}
 
+	if err := c.decoratePosts(context.Background(), principal.ID, postPointers(posts)...); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving post details"})
+		return
+	}
+	c.AnalyticsService.RecordViews(context.Background(), model.ViewKindImpression, principal.ID, postPointers(posts)...)
+
 	response := struct {
-		Message string       `json:"message"`
-		Data    []model.Post `json:"data"`
+		Message    string       `json:"message"`
+		Data       []model.Post `json:"data"`
+		NextCursor string       `json:"next_cursor"`
 	}{
-		Message: "User posts have been retrieved",
-		Data:    posts,
+		Message:    "User posts have been retrieved",
+		Data:       posts,
+		NextCursor: nextCursor,
 	}
 
 	httputil.WriteResponse(w, http.StatusOK, response)
@@ -212,12 +350,28 @@ func (c *PostControllerImpl) UpdatePost(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	var post model.Post
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	existingPost, err := c.PostRepository.GetPostByIDAnyStatus(context.Background(), postID)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusNotFound, map[string]string{"error": "Post not found"})
+		return
+	}
+
+	if existingPost.UserID != principal.ID && !principal.HasRole(rbac.RoleAdmin) {
+		httputil.WriteResponse(w, http.StatusForbidden, map[string]string{"error": "You are not allowed to update this post"})
+		return
+	}
 
 	var requestBody struct {
-		UserID      int    `json:"user_id"`
-		Title       string `json:"title"`
-		Description string `json:"description"`
+		Title       string              `json:"title"`
+		Description string              `json:"description"`
+		Status      string              `json:"status"`
+		PublishAt   *time.Time          `json:"publish_at"`
+		Media       *[]PostMediaRequest `json:"media"`
 	}
 
 	if err := httputil.ReadRequest(r, &requestBody); err != nil {
@@ -225,15 +379,106 @@ func (c *PostControllerImpl) UpdatePost(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	updatedPost := model.Post{
-		UserID:      requestBody.UserID,
-		Title:       requestBody.Title,
-		Description: requestBody.Description,
+	// Media is only replaced when sent; an empty list removes all of it. It has to be uploaded by the
+	// author, also when an admin edits the post.
+	var media []model.PostMedia
+	var image string
+	if requestBody.Media != nil {
+		if media, image, ok = c.requestPostMedia(w, existingPost.UserID, *requestBody.Media); !ok {
+			return
+		}
 	}
 
-	post.ID = postID
-	if err := c.PostRepository.UpdatePost(context.Background(), postID, &updatedPost); err != nil {
-		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error updating post"})
+	notLive := existingPost.Status == model.PostStatusDraft || existingPost.Status == model.PostStatusScheduled
+	reschedule := requestBody.Status != "" || requestBody.PublishAt != nil
+	if reschedule && !notLive && (requestBody.Status != model.PostStatusPublished || requestBody.PublishAt != nil) {
+		httputil.WriteResponse(w, http.StatusConflict, map[string]string{"error": "Post has already been published"})
+		return
+	}
+
+	status, publishAt, ok := requestPostStatus(w, requestBody.Status, requestBody.PublishAt, c.Clock.Now())
+	if !ok {
+		return
+	}
+
+	if existingPost.Status == model.PostStatusPublished {
+		// Edits of live posts are kept as revisions. Empty fields keep their current text.
+		var title, description *string
+		if requestBody.Title != "" {
+			title = &requestBody.Title
+		}
+		if requestBody.Description != "" {
+			description = &requestBody.Description
+		}
+
+		existingPost, _, err = c.PostRevisionRepository.RevisePost(context.Background(), postID, principal.ID, title, description, nil)
+		if err != nil {
+			httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error updating post"})
+			return
+		}
+	} else {
+		updatedPost := model.Post{
+			Title:       requestBody.Title,
+			Description: requestBody.Description,
+		}
+
+		if err := c.PostRepository.UpdatePost(context.Background(), postID, &updatedPost); err != nil {
+			httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error updating post"})
+			return
+		}
+
+		// Updates skips empty fields, so the text that is kept has to be parsed again too.
+		if updatedPost.Title != "" {
+			existingPost.Title = updatedPost.Title
+		}
+		if updatedPost.Description != "" {
+			existingPost.Description = updatedPost.Description
+		}
+	}
+
+	if requestBody.Media != nil {
+		if err := c.MediaRepository.ReplacePostMedia(context.Background(), postID, media, image); err != nil {
+			httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error updating post media"})
+			return
+		}
+		existingPost.Image = image
+	}
+
+	switch {
+	case notLive && reschedule && status == model.PostStatusPublished:
+		if err := c.PublishingService.Publish(context.Background(), existingPost); err != nil {
+			if errors.Is(err, repository.ErrPostAlreadyPublished) {
+				httputil.WriteResponse(w, http.StatusConflict, map[string]string{"error": "Post has already been published"})
+			} else {
+				httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error publishing post"})
+			}
+			return
+		}
+	case notLive && reschedule:
+		if err := c.PostRepository.UpdatePostSchedule(context.Background(), postID, status, publishAt); err != nil {
+			if errors.Is(err, repository.ErrPostAlreadyPublished) {
+				httputil.WriteResponse(w, http.StatusConflict, map[string]string{"error": "Post has already been published"})
+			} else {
+				httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error scheduling post"})
+			}
+			return
+		}
+		existingPost.Status = status
+		existingPost.PublishAt = publishAt
+	case existingPost.Status == model.PostStatusPublished:
+		if err := c.TagService.ProcessPost(context.Background(), existingPost); err != nil {
+			httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error saving post tags"})
+			return
+		}
+	}
+
+	if err := c.TagService.LinkPosts(context.Background(), existingPost); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving post details"})
+		return
+	}
+
+	if err := c.attachMedia(context.Background(), existingPost); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving post details"})
 		return
 	}
 
@@ -242,7 +487,7 @@ func (c *PostControllerImpl) UpdatePost(w http.ResponseWriter, r *http.Request)
 		Data    model.Post `json:"data"`
 	}{
 		Message: "Post has been updated",
-		Data:    post,
+		Data:    *existingPost,
 	}
 
 	httputil.WriteResponse(w, http.StatusOK, response)
@@ -258,11 +503,39 @@ func (c *PostControllerImpl) DeletePost(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	post, err := c.PostRepository.GetPostByIDAnyStatus(context.Background(), postID)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusNotFound, map[string]string{"error": "Post not found"})
+		return
+	}
+
+	allowed := post.UserID == principal.ID || principal.HasRole(rbac.RoleAdmin)
+	if !allowed && post.CommunityID != nil {
+		allowed, err = c.Authorizer.HasCommunityPermission(context.Background(), principal.Roles, principal.ID, *post.CommunityID, rbac.PermissionModerateCommunity)
+		if err != nil {
+			httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error checking permissions"})
+			return
+		}
+	}
+	if !allowed {
+		httputil.WriteResponse(w, http.StatusForbidden, map[string]string{"error": "You are not allowed to delete this post"})
+		return
+	}
+
 	if err := c.PostRepository.DeletePost(context.Background(), postID); err != nil {
 		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error deleting post"})
 		return
 	}
 
+	if err := c.TimelineService.RemovePost(context.Background(), post); err != nil {
+		log.Printf("Error removing post %d from timelines: %v", post.ID, err)
+	}
+
 	response := struct {
 		Message string `json:"message"`
 	}{
@@ -282,62 +555,167 @@ func (c *PostControllerImpl) GetTimelinePosts(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	cacheKey := fmt.Sprintf("timeline_posts_user_%d", userID)
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
 
-	var cachedResponse struct {
-		Message string       `json:"message"`
-		Data    []model.Post `json:"data"`
+	if userID != principal.ID {
+		httputil.WriteResponse(w, http.StatusForbidden, map[string]string{"error": "You are not allowed to view this timeline"})
+		return
 	}
 
-	if err := redis.GetCache(cacheKey, &cachedResponse); err == nil {
-		log.Printf("Cache hit for user %d", userID)
-		httputil.WriteResponse(w, http.StatusOK, cachedResponse)
+	page, ok := requestRankedPage(w, r)
+	if !ok {
 		return
-	} else if err != nil {
-		log.Printf("Cache miss for user %d", userID)
 	}
 
-	userPosts, err := c.PostRepository.GetPostsByUserID(context.Background(), userID)
+	timelinePosts, nextCursor, err := c.TimelineService.GetTimeline(context.Background(), userID, page)
 	if err != nil {
-		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Error retrieving user posts"})
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving timeline posts"})
 		return
 	}
-	userFollowers, err := c.UserRepository.GetFollowers(context.Background(), userID)
-	if err != nil {
-		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving followers"})
+
+	if err := c.decoratePosts(context.Background(), principal.ID, postPointers(timelinePosts)...); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving post details"})
 		return
 	}
+	c.AnalyticsService.RecordViews(context.Background(), model.ViewKindImpression, principal.ID, postPointers(timelinePosts)...)
 
-	var followerPosts []model.Post
+	response := struct {
+		Message    string       `json:"message"`
+		Data       []model.Post `json:"data"`
+		NextCursor string       `json:"next_cursor"`
+	}{
+		Message:    "Timeline posts have been retrieved successfully",
+		Data:       timelinePosts,
+		NextCursor: nextCursor,
+	}
 
-	for _, data := range userFollowers {
-		if posts, err := c.PostRepository.GetPostsByUserID(context.Background(), data.FollowingID); err == nil {
-			followerPosts = append(followerPosts, posts...)
-		} else {
-			httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving friend posts"})
-			return
-		}
+	httputil.WriteResponse(w, http.StatusOK, response)
+}
+
+func (c *PostControllerImpl) GetRecommendedPosts(w http.ResponseWriter, r *http.Request) {
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	page, ok := requestRankedPage(w, r)
+	if !ok {
+		return
+	}
+
+	debug := r.URL.Query().Get("debug") == "true"
+
+	recommendedPosts, nextCursor, err := c.RecommendationService.GetRecommendedPosts(context.Background(), principal.ID, page, debug)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving recommended posts"})
+		return
 	}
 
-	var timelinePosts []model.Post
-	timelinePosts = append(userPosts, followerPosts...)
+	posts := make([]*model.Post, 0, len(recommendedPosts))
+	for i := range recommendedPosts {
+		posts = append(posts, &recommendedPosts[i].Post)
+	}
+	if err := c.decoratePosts(context.Background(), principal.ID, posts...); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving post details"})
+		return
+	}
+	c.AnalyticsService.RecordViews(context.Background(), model.ViewKindImpression, principal.ID, posts...)
 
 	response := struct {
-		Message string       `json:"message"`
-		Data    []model.Post `json:"data"`
+		Message    string                 `json:"message"`
+		Data       []feed.RecommendedPost `json:"data"`
+		NextCursor string                 `json:"next_cursor"`
 	}{
-		Message: "Timeline posts have been retrieved successfully",
-		Data:    timelinePosts,
+		Message:    "Recommended posts have been retrieved",
+		Data:       recommendedPosts,
+		NextCursor: nextCursor,
 	}
 
-	if err := redis.SetCache(cacheKey, response, 10*time.Minute); err != nil {
-		log.Print("Error caching timeline posts:")
+	httputil.WriteResponse(w, http.StatusOK, response)
+}
+
+func (c *PostControllerImpl) RepostPost(w http.ResponseWriter, r *http.Request) {
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
 	}
 
-	httputil.WriteResponse(w, http.StatusOK, response)
+	original, ok := c.shareTarget(w, r)
+	if !ok {
+		return
+	}
+
+	existingRepost, err := c.PostRepository.GetRepost(context.Background(), principal.ID, original.ID)
+	if err == nil {
+		response := struct {
+			Message string     `json:"message"`
+			Data    model.Post `json:"data"`
+		}{
+			Message: "You have already reposted this post",
+			Data:    *existingRepost,
+		}
+		httputil.WriteResponse(w, http.StatusOK, response)
+		return
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving repost"})
+		return
+	}
+
+	now := c.Clock.Now()
+	repost := model.Post{
+		UserID:         principal.ID,
+		OriginalPostID: &original.ID,
+		ShareType:      model.ShareTypeRepost,
+		Status:         model.PostStatusPublished,
+		PublishedAt:    &now,
+	}
+	c.share(w, principal.ID, original, &repost, "Post has been reposted")
 }
 
-func (c *PostControllerImpl) LikePost(w http.ResponseWriter, r *http.Request) {
+func (c *PostControllerImpl) QuotePost(w http.ResponseWriter, r *http.Request) {
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	var requestBody struct {
+		Title       string `json:"title"`
+		Description string `json:"description"`
+	}
+
+	if err := httputil.ReadRequest(r, &requestBody); err != nil {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	if strings.TrimSpace(requestBody.Description) == "" {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Quote text is required"})
+		return
+	}
+
+	original, ok := c.shareTarget(w, r)
+	if !ok {
+		return
+	}
+
+	now := c.Clock.Now()
+	quote := model.Post{
+		Title:          requestBody.Title,
+		Description:    requestBody.Description,
+		UserID:         principal.ID,
+		OriginalPostID: &original.ID,
+		ShareType:      model.ShareTypeQuote,
+		Status:         model.PostStatusPublished,
+		PublishedAt:    &now,
+	}
+	c.share(w, principal.ID, original, &quote, "Post has been quoted")
+}
+
+func (c *PostControllerImpl) UndoRepost(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	postIDstr := vars["id"]
 
@@ -347,69 +725,423 @@ func (c *PostControllerImpl) LikePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var requestBody struct {
-		UserID int `json:"user_id"`
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
 	}
 
-	if err := httputil.ReadRequest(r, &requestBody); err != nil {
-		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	repost, err := c.PostRepository.GetRepost(context.Background(), principal.ID, postID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			httputil.WriteResponse(w, http.StatusNotFound, map[string]string{"error": "Repost not found"})
+		} else {
+			httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving repost"})
+		}
+		return
+	}
+
+	if err := c.PostRepository.DeletePost(context.Background(), repost.ID); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error deleting repost"})
 		return
 	}
 
+	if err := c.TimelineService.RemovePost(context.Background(), repost); err != nil {
+		log.Printf("Error removing post %d from timelines: %v", repost.ID, err)
+	}
+
+	response := struct {
+		Message string `json:"message"`
+	}{
+		Message: "Repost has been removed",
+	}
+
+	httputil.WriteResponse(w, http.StatusOK, response)
+}
+
+// shareTarget loads the post a repost or quote of the {id} post should point to. Sharing a repost
+// shares its original instead, so that share chains stay one level deep.
+func (c *PostControllerImpl) shareTarget(w http.ResponseWriter, r *http.Request) (*model.Post, bool) {
+	postID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid post id"})
+		return nil, false
+	}
+
 	post, err := c.PostRepository.GetPostDetailByID(context.Background(), postID)
+	if err == nil && post.ShareType == model.ShareTypeRepost && post.OriginalPostID != nil {
+		post, err = c.PostRepository.GetPostDetailByID(context.Background(), *post.OriginalPostID)
+	}
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
 			httputil.WriteResponse(w, http.StatusNotFound, map[string]string{"error": "Post not found"})
 		} else {
 			httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving post"})
 		}
+		return nil, false
+	}
+	return post, true
+}
+
+// share stores a repost or quote post, fans it out to the sharer's followers and notifies the author
+// of the original.
+func (c *PostControllerImpl) share(w http.ResponseWriter, sharerID int, original *model.Post, post *model.Post, message string) {
+	if err := c.PostRepository.SharePost(context.Background(), post); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error sharing post"})
 		return
 	}
 
-	alreadyLiked := false
-	for _, user := range post.Likes {
-		if user.ID == requestBody.UserID {
-			alreadyLiked = true
-			break
+	if post.ShareType == model.ShareTypeQuote {
+		if err := c.TagService.ProcessPost(context.Background(), post); err != nil {
+			httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error saving post tags"})
+			return
 		}
 	}
 
-	if !alreadyLiked {
-		liker, err := c.UserRepository.GetUserByID(context.Background(), requestBody.UserID)
+	if err := c.TimelineService.PublishPost(context.Background(), post); err != nil {
+		log.Printf("Error fanning out post %d: %v", post.ID, err)
+	}
+
+	if original.UserID != sharerID {
+		sharer, err := c.UserRepository.GetUserByID(context.Background(), sharerID)
 		if err != nil {
-			if err == gorm.ErrRecordNotFound {
-				httputil.WriteResponse(w, http.StatusNotFound, map[string]string{"error": "User not found"})
-			} else {
-				httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving user"})
-			}
+			httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving user"})
 			return
 		}
 
-		post.Likes = append(post.Likes, &model.User{ID: requestBody.UserID})
-		if err := c.PostRepository.UpdatePost(context.Background(), postID, post); err != nil {
-			httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error liking post"})
-			return
+		verb := " reposted your post: "
+		if post.ShareType == model.ShareTypeQuote {
+			verb = " quoted your post: "
 		}
 
-		likePostNotification := model.Notification{
-			UserID:  post.UserID,
-			ActorID: requestBody.UserID,
-			PostID:  post.ID,
-			Type:    "like",
-			Message: liker.Username + " liked your post: " + post.Title,
+		shareNotification := model.Notification{
+			UserID:  original.UserID,
+			ActorID: sharerID,
+			PostID:  original.ID,
+			Type:    post.ShareType,
+			Message: sharer.Username + verb + original.Title,
 			Read:    false,
 		}
-		if err := c.NotificationRepository.CreateNotification(context.Background(), &likePostNotification); err != nil {
+		if err := c.NotificationRepository.CreateNotification(context.Background(), &shareNotification); err != nil {
 			httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error creating notification"})
 			return
 		}
+	}
 
-		response := struct {
-			Message string `json:"message"`
-		}{
-			Message: "You have liked this post",
+	post.Original = &model.SharedPost{ID: original.ID, Post: original}
+	if err := c.TagService.LinkPosts(context.Background(), post, original); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving post details"})
+		return
+	}
+
+	response := struct {
+		Message string     `json:"message"`
+		Data    model.Post `json:"data"`
+	}{
+		Message: message,
+		Data:    *post,
+	}
+
+	httputil.WriteResponse(w, http.StatusOK, response)
+}
+
+// decoratePosts fills in the originals of reposts and quote posts, with tombstones for deleted
+// originals, the hashtags, mentions, media and polls of the posts and their originals, as well as the
+// reaction counts of the posts, the reaction the viewer left on each and whether the viewer
+// bookmarked it.
+func (c *PostControllerImpl) decoratePosts(ctx context.Context, viewerID int, posts ...*model.Post) error {
+	postIDs := make([]int, 0, len(posts))
+	var originalIDs []int
+	for _, post := range posts {
+		postIDs = append(postIDs, post.ID)
+		if post.OriginalPostID != nil {
+			originalIDs = append(originalIDs, *post.OriginalPostID)
 		}
+	}
 
-		httputil.WriteResponse(w, http.StatusOK, response)
+	originals, err := c.PostRepository.GetPostsByIDs(ctx, originalIDs)
+	if err != nil {
+		return err
+	}
+	originalsByID := make(map[int]*model.Post, len(originals))
+	for i := range originals {
+		originalsByID[originals[i].ID] = &originals[i]
+	}
+
+	if err := c.TagService.LinkPosts(ctx, append(postPointers(originals), posts...)...); err != nil {
+		return err
+	}
+
+	if err := c.PollService.AttachPolls(ctx, viewerID, append(postPointers(originals), posts...)...); err != nil {
+		return err
+	}
+
+	if err := c.attachMedia(ctx, append(postPointers(originals), posts...)...); err != nil {
+		return err
+	}
+
+	counts, err := c.PostReactionRepository.GetReactionCounts(ctx, postIDs)
+	if err != nil {
+		return err
+	}
+	viewerReactions, err := c.PostReactionRepository.GetUserReactions(ctx, viewerID, postIDs)
+	if err != nil {
+		return err
+	}
+	bookmarked, err := c.BookmarkRepository.GetBookmarkedPostIDs(ctx, viewerID, postIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, post := range posts {
+		post.ReactionCounts = reactionCountsOf(counts, post.ID)
+		post.ViewerReaction = viewerReactions[post.ID]
+		post.Bookmarked = bookmarked[post.ID]
+
+		if post.OriginalPostID != nil {
+			original, found := originalsByID[*post.OriginalPostID]
+			post.Original = &model.SharedPost{ID: *post.OriginalPostID, Deleted: !found, Post: original}
+		}
+	}
+	return nil
+}
+
+func (c *PostControllerImpl) GetTagPosts(w http.ResponseWriter, r *http.Request) {
+	tag, ok := textproc.NormalizeTag(mux.Vars(r)["tag"])
+	if !ok {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid hashtag"})
+		return
+	}
+
+	page, ok := requestPage(w, r)
+	if !ok {
+		return
+	}
+
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	posts, nextCursor, err := c.TagRepository.GetPostsByHashtag(context.Background(), tag, page)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving posts"})
+		return
+	}
+
+	if err := c.decoratePosts(context.Background(), principal.ID, postPointers(posts)...); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving post details"})
+		return
+	}
+	c.AnalyticsService.RecordViews(context.Background(), model.ViewKindImpression, principal.ID, postPointers(posts)...)
+
+	response := struct {
+		Message    string       `json:"message"`
+		Data       []model.Post `json:"data"`
+		NextCursor string       `json:"next_cursor"`
+	}{
+		Message:    "Tagged posts have been retrieved",
+		Data:       posts,
+		NextCursor: nextCursor,
+	}
+
+	httputil.WriteResponse(w, http.StatusOK, response)
+}
+
+func (c *PostControllerImpl) GetDraftPosts(w http.ResponseWriter, r *http.Request) {
+	page, ok := requestPage(w, r)
+	if !ok {
+		return
+	}
+
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	posts, nextCursor, err := c.PostRepository.GetUnpublishedPostsByUserID(context.Background(), principal.ID, page)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving posts"})
+		return
+	}
+
+	if err := c.TagService.LinkPosts(context.Background(), postPointers(posts)...); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving post details"})
+		return
+	}
+
+	if err := c.PollService.AttachPolls(context.Background(), principal.ID, postPointers(posts)...); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving post details"})
+		return
+	}
+
+	if err := c.attachMedia(context.Background(), postPointers(posts)...); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving post details"})
+		return
+	}
+
+	response := struct {
+		Message    string       `json:"message"`
+		Data       []model.Post `json:"data"`
+		NextCursor string       `json:"next_cursor"`
+	}{
+		Message:    "Drafts and scheduled posts have been retrieved",
+		Data:       posts,
+		NextCursor: nextCursor,
+	}
+
+	httputil.WriteResponse(w, http.StatusOK, response)
+}
+
+func (c *PostControllerImpl) GetTrendingPosts(w http.ResponseWriter, r *http.Request) {
+	filter, limit, ok := requestTrendingQuery(w, r)
+	if !ok {
+		return
+	}
+
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	trending, err := c.TrendingService.GetTrendingPosts(context.Background(), filter, limit)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving trending posts"})
+		return
+	}
+
+	posts := make([]*model.Post, 0, len(trending))
+	for i := range trending {
+		posts = append(posts, &trending[i].Post)
+	}
+	if err := c.decoratePosts(context.Background(), principal.ID, posts...); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving post details"})
+		return
+	}
+	c.AnalyticsService.RecordViews(context.Background(), model.ViewKindImpression, principal.ID, posts...)
+
+	response := struct {
+		Message string               `json:"message"`
+		Data    []model.TrendingPost `json:"data"`
+	}{
+		Message: "Trending posts have been retrieved",
+		Data:    trending,
+	}
+
+	httputil.WriteResponse(w, http.StatusOK, response)
+}
+
+// PostMediaRequest references uploaded media in a post request. Media is shown in the order sent.
+type PostMediaRequest struct {
+	MediaID int    `json:"media_id"`
+	AltText string `json:"alt_text"`
+}
+
+// requestPostMedia checks that the owner uploaded every referenced media and turns the references into
+// post media, writing an error response when they are invalid. The image is the URL of the first
+// media, kept for clients that only show a single picture.
+func (c *PostControllerImpl) requestPostMedia(w http.ResponseWriter, ownerID int, items []PostMediaRequest) ([]model.PostMedia, string, bool) {
+	if len(items) > model.MaxPostMedia {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("A post can have at most %d media", model.MaxPostMedia)})
+		return nil, "", false
+	}
+
+	mediaIDs := make([]int, 0, len(items))
+	seen := make(map[int]bool, len(items))
+	for _, item := range items {
+		if seen[item.MediaID] {
+			httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Media can only be attached once per post"})
+			return nil, "", false
+		}
+		if utf8.RuneCountInString(item.AltText) > model.MaxAltTextLength {
+			httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("Alt text must be at most %d characters", model.MaxAltTextLength)})
+			return nil, "", false
+		}
+		seen[item.MediaID] = true
+		mediaIDs = append(mediaIDs, item.MediaID)
+	}
+
+	owned, err := c.MediaRepository.GetMediaByIDs(context.Background(), ownerID, mediaIDs)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving media"})
+		return nil, "", false
+	}
+	if len(owned) != len(mediaIDs) {
+		httputil.WriteResponse(w, http.StatusForbidden, map[string]string{"error": "Posts can only use media uploaded by their author"})
+		return nil, "", false
+	}
+	urls := make(map[int]string, len(owned))
+	for _, media := range owned {
+		urls[media.ID] = media.URL
+	}
+
+	postMedia := make([]model.PostMedia, 0, len(items))
+	for i, item := range items {
+		postMedia = append(postMedia, model.PostMedia{
+			MediaID:  item.MediaID,
+			Position: i,
+			AltText:  strings.TrimSpace(item.AltText),
+		})
+	}
+
+	var image string
+	if len(items) > 0 {
+		image = urls[items[0].MediaID]
+	}
+	return postMedia, image, true
+}
+
+// attachMedia sets the media of the posts, in order.
+func (c *PostControllerImpl) attachMedia(ctx context.Context, posts ...*model.Post) error {
+	postIDs := make([]int, 0, len(posts))
+	for _, post := range posts {
+		postIDs = append(postIDs, post.ID)
+	}
+
+	mediaByPostID, err := c.MediaRepository.GetPostMedia(ctx, postIDs)
+	if err != nil {
+		return err
+	}
+	for _, post := range posts {
+		post.Media = mediaByPostID[post.ID]
+		if post.Media == nil {
+			post.Media = []model.PostMedia{}
+		}
+	}
+	return nil
+}
+
+// requestPostStatus validates the status and publish time sent for a post, writing a 400 response
+// when they are invalid. A publish time without a status schedules the post and no status publishes it.
+func requestPostStatus(w http.ResponseWriter, status string, publishAt *time.Time, now time.Time) (string, *time.Time, bool) {
+	if status == "" && publishAt != nil {
+		status = model.PostStatusScheduled
+	}
+
+	switch status {
+	case "", model.PostStatusPublished:
+		return model.PostStatusPublished, nil, true
+	case model.PostStatusDraft:
+		return model.PostStatusDraft, nil, true
+	case model.PostStatusScheduled:
+		if publishAt == nil {
+			httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "publish_at is required to schedule a post"})
+			return "", nil, false
+		}
+		if !publishAt.After(now) {
+			httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "publish_at must be in the future"})
+			return "", nil, false
+		}
+		return model.PostStatusScheduled, publishAt, true
+	}
+
+	httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid status, expected one of draft, scheduled or published"})
+	return "", nil, false
+}
+
+func postPointers(posts []model.Post) []*model.Post {
+	pointers := make([]*model.Post, 0, len(posts))
+	for i := range posts {
+		pointers = append(pointers, &posts[i])
 	}
+	return pointers
 }
@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/temuka-api-service/internal/model"
+)
+
+func TestRequestPostStatus(t *testing.T) {
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Minute)
+
+	tests := []struct {
+		name       string
+		status     string
+		publishAt  *time.Time
+		wantStatus string
+		wantCode   int
+	}{
+		{name: "no status publishes", wantStatus: model.PostStatusPublished},
+		{name: "draft", status: model.PostStatusDraft, wantStatus: model.PostStatusDraft},
+		{name: "publish time schedules", publishAt: &future, wantStatus: model.PostStatusScheduled},
+		{name: "scheduled without publish time", status: model.PostStatusScheduled, wantCode: http.StatusBadRequest},
+		{name: "publish time before the clock", publishAt: &past, wantCode: http.StatusBadRequest},
+		{name: "publish time at the clock", publishAt: &now, wantCode: http.StatusBadRequest},
+		{name: "unknown status", status: "archived", wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			status, _, ok := requestPostStatus(w, tt.status, tt.publishAt, now)
+			if tt.wantCode != 0 {
+				if ok || w.Code != tt.wantCode {
+					t.Fatalf("ok = %v, status %d, want %d", ok, w.Code, tt.wantCode)
+				}
+				return
+			}
+			if !ok || status != tt.wantStatus {
+				t.Fatalf("ok = %v, status %q, want %q (body %s)", ok, status, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+}
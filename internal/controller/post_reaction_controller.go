@@ -0,0 +1,247 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/temuka-api-service/internal/model"
+	"github.com/temuka-api-service/internal/repository"
+	httputil "github.com/temuka-api-service/pkg/http"
+	"gorm.io/gorm"
+)
+
+// reactionNotificationTypes are the notification types a post owner may already have received for a
+// reaction; "like" predates reaction types.
+var reactionNotificationTypes = []string{"like", "reaction"}
+
+type PostReactionController interface {
+	SetReaction(w http.ResponseWriter, r *http.Request)
+	RemoveReaction(w http.ResponseWriter, r *http.Request)
+	GetReactions(w http.ResponseWriter, r *http.Request)
+	LikePost(w http.ResponseWriter, r *http.Request)
+}
+
+type PostReactionControllerImpl struct {
+	PostReactionRepository repository.PostReactionRepository
+	PostRepository         repository.PostRepository
+	UserRepository         repository.UserRepository
+	NotificationRepository repository.NotificationRepository
+}
+
+func NewPostReactionController(postReactionRepo repository.PostReactionRepository, postRepo repository.PostRepository, userRepo repository.UserRepository, notificationRepo repository.NotificationRepository) PostReactionController {
+	return &PostReactionControllerImpl{
+		PostReactionRepository: postReactionRepo,
+		PostRepository:         postRepo,
+		UserRepository:         userRepo,
+		NotificationRepository: notificationRepo,
+	}
+}
+
+type reactionSummary struct {
+	ReactionCounts map[string]int64 `json:"reaction_counts"`
+	ViewerReaction string           `json:"viewer_reaction,omitempty"`
+}
+
+func (c *PostReactionControllerImpl) SetReaction(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	c.react(w, r, vars["id"], vars["type"])
+}
+
+// LikePost is kept for clients that predate reaction types and reacts with a like.
+func (c *PostReactionControllerImpl) LikePost(w http.ResponseWriter, r *http.Request) {
+	c.react(w, r, mux.Vars(r)["id"], model.ReactionLike)
+}
+
+func (c *PostReactionControllerImpl) react(w http.ResponseWriter, r *http.Request, postIDstr string, reactionType string) {
+	postID, err := strconv.Atoi(postIDstr)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid post id"})
+		return
+	}
+
+	if !model.IsValidReactionType(reactionType) {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid reaction type"})
+		return
+	}
+
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	post, ok := c.getPost(w, postID)
+	if !ok {
+		return
+	}
+
+	reaction := model.PostReaction{
+		PostID: postID,
+		UserID: principal.ID,
+		Type:   reactionType,
+	}
+	if err := c.PostReactionRepository.UpsertReaction(context.Background(), &reaction); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error reacting to post"})
+		return
+	}
+
+	if post.UserID != principal.ID {
+		if err := c.notifyOwner(context.Background(), post, principal.ID); err != nil {
+			httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error creating notification"})
+			return
+		}
+	}
+
+	c.writeSummary(w, postID, reactionType, "You have reacted to this post")
+}
+
+// notifyOwner tells the post owner about the actor's reaction, unless an earlier reaction of the
+// actor to the same post already did.
+func (c *PostReactionControllerImpl) notifyOwner(ctx context.Context, post *model.Post, actorID int) error {
+	notified, err := c.NotificationRepository.HasNotification(ctx, post.UserID, actorID, post.ID, reactionNotificationTypes)
+	if err != nil || notified {
+		return err
+	}
+
+	actor, err := c.UserRepository.GetUserByID(ctx, actorID)
+	if err != nil {
+		return err
+	}
+
+	notification := model.Notification{
+		UserID:  post.UserID,
+		ActorID: actorID,
+		PostID:  post.ID,
+		Type:    "reaction",
+		Message: actor.Username + " reacted to your post: " + post.Title,
+		Read:    false,
+	}
+	return c.NotificationRepository.CreateNotification(ctx, &notification)
+}
+
+func (c *PostReactionControllerImpl) RemoveReaction(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	postIDstr := vars["id"]
+
+	postID, err := strconv.Atoi(postIDstr)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid post id"})
+		return
+	}
+
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	if _, ok := c.getPost(w, postID); !ok {
+		return
+	}
+
+	if _, err := c.PostReactionRepository.DeleteReaction(context.Background(), postID, principal.ID); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error removing reaction"})
+		return
+	}
+
+	c.writeSummary(w, postID, "", "Your reaction has been removed")
+}
+
+func (c *PostReactionControllerImpl) GetReactions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	postIDstr := vars["id"]
+
+	postID, err := strconv.Atoi(postIDstr)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid post id"})
+		return
+	}
+
+	reactionType := r.URL.Query().Get("type")
+	if reactionType != "" && !model.IsValidReactionType(reactionType) {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid reaction type"})
+		return
+	}
+
+	page, ok := requestPage(w, r)
+	if !ok {
+		return
+	}
+
+	reactions, nextCursor, err := c.PostReactionRepository.GetReactions(context.Background(), postID, reactionType, page)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving reactions"})
+		return
+	}
+
+	type Reactor struct {
+		User      model.PublicUser `json:"user"`
+		Type      string           `json:"type"`
+		ReactedAt time.Time        `json:"reacted_at"`
+	}
+
+	reactors := make([]Reactor, 0, len(reactions))
+	for i := range reactions {
+		reactors = append(reactors, Reactor{
+			User:      reactions[i].User.Public(),
+			Type:      reactions[i].Type,
+			ReactedAt: reactions[i].UpdatedAt,
+		})
+	}
+
+	response := struct {
+		Message    string    `json:"message"`
+		Data       []Reactor `json:"data"`
+		NextCursor string    `json:"next_cursor"`
+	}{
+		Message:    "Reactions have been retrieved",
+		Data:       reactors,
+		NextCursor: nextCursor,
+	}
+
+	httputil.WriteResponse(w, http.StatusOK, response)
+}
+
+func (c *PostReactionControllerImpl) getPost(w http.ResponseWriter, postID int) (*model.Post, bool) {
+	post, err := c.PostRepository.GetPostDetailByID(context.Background(), postID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.WriteResponse(w, http.StatusNotFound, map[string]string{"error": "Post not found"})
+		} else {
+			httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving post"})
+		}
+		return nil, false
+	}
+	return post, true
+}
+
+func (c *PostReactionControllerImpl) writeSummary(w http.ResponseWriter, postID int, viewerReaction string, message string) {
+	counts, err := c.PostReactionRepository.GetReactionCounts(context.Background(), []int{postID})
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving reactions"})
+		return
+	}
+
+	response := struct {
+		Message string          `json:"message"`
+		Data    reactionSummary `json:"data"`
+	}{
+		Message: message,
+		Data: reactionSummary{
+			ReactionCounts: reactionCountsOf(counts, postID),
+			ViewerReaction: viewerReaction,
+		},
+	}
+
+	httputil.WriteResponse(w, http.StatusOK, response)
+}
+
+// reactionCountsOf returns the counts of a post with every reaction type present, zero included.
+func reactionCountsOf(counts map[int]map[string]int64, postID int) map[string]int64 {
+	postCounts := make(map[string]int64, len(model.ReactionTypes))
+	for _, t := range model.ReactionTypes {
+		postCounts[t] = counts[postID][t]
+	}
+	return postCounts
+}
@@ -0,0 +1,227 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/temuka-api-service/internal/feed"
+	"github.com/temuka-api-service/internal/model"
+	"github.com/temuka-api-service/internal/rbac"
+	"github.com/temuka-api-service/internal/repository"
+	"github.com/temuka-api-service/middleware"
+	"github.com/temuka-api-service/pkg/diff"
+	httputil "github.com/temuka-api-service/pkg/http"
+	"gorm.io/gorm"
+)
+
+// maxRevisionPageSize is lower than the usual page size because every revision is diffed.
+const maxRevisionPageSize = 10
+
+type PostRevisionController interface {
+	GetRevisions(w http.ResponseWriter, r *http.Request)
+	RevertPost(w http.ResponseWriter, r *http.Request)
+}
+
+type PostRevisionControllerImpl struct {
+	PostRevisionRepository repository.PostRevisionRepository
+	PostRepository         repository.PostRepository
+	Authorizer             rbac.Authorizer
+	TagService             feed.TagService
+}
+
+func NewPostRevisionController(postRevisionRepo repository.PostRevisionRepository, postRepo repository.PostRepository, authorizer rbac.Authorizer, tagService feed.TagService) PostRevisionController {
+	return &PostRevisionControllerImpl{
+		PostRevisionRepository: postRevisionRepo,
+		PostRepository:         postRepo,
+		Authorizer:             authorizer,
+		TagService:             tagService,
+	}
+}
+
+// GetRevisions lists the edits of a post with their diffs. Only the author, admins and the moderators
+// of the post's community may see the edit history.
+func (c *PostRevisionControllerImpl) GetRevisions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	postIDstr := vars["id"]
+
+	postID, err := strconv.Atoi(postIDstr)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid post id"})
+		return
+	}
+
+	page, ok := requestPage(w, r)
+	if !ok {
+		return
+	}
+	if page.Limit > maxRevisionPageSize {
+		page.Limit = maxRevisionPageSize
+	}
+
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	post, err := c.PostRepository.GetPostDetailByID(context.Background(), postID)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusNotFound, map[string]string{"error": "Post not found"})
+		return
+	}
+
+	allowed := post.UserID == principal.ID
+	if !allowed {
+		allowed, err = c.canModerate(principal, post)
+		if err != nil {
+			httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error checking permissions"})
+			return
+		}
+	}
+	if !allowed {
+		httputil.WriteResponse(w, http.StatusForbidden, map[string]string{"error": "You are not allowed to view the revisions of this post"})
+		return
+	}
+
+	revisions, nextCursor, err := c.PostRevisionRepository.GetRevisions(context.Background(), postID, page)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving revisions"})
+		return
+	}
+
+	type RevisionDiff struct {
+		Title       []diff.Op `json:"title"`
+		Description []diff.Op `json:"description"`
+	}
+
+	type Revision struct {
+		ID             int              `json:"id"`
+		Editor         model.PublicUser `json:"editor"`
+		OldTitle       string           `json:"old_title"`
+		NewTitle       string           `json:"new_title"`
+		OldDescription string           `json:"old_description"`
+		NewDescription string           `json:"new_description"`
+		RevertedFromID *int             `json:"reverted_from_id,omitempty"`
+		Diff           RevisionDiff     `json:"diff"`
+		CreatedAt      time.Time        `json:"created_at"`
+	}
+
+	postRevisions := make([]Revision, 0, len(revisions))
+	for _, revision := range revisions {
+		postRevisions = append(postRevisions, Revision{
+			ID:             revision.ID,
+			Editor:         revision.Editor.Public(),
+			OldTitle:       revision.OldTitle,
+			NewTitle:       revision.NewTitle,
+			OldDescription: revision.OldDescription,
+			NewDescription: revision.NewDescription,
+			RevertedFromID: revision.RevertedFromID,
+			Diff: RevisionDiff{
+				Title:       diff.Words(revision.OldTitle, revision.NewTitle),
+				Description: diff.Words(revision.OldDescription, revision.NewDescription),
+			},
+			CreatedAt: revision.CreatedAt,
+		})
+	}
+
+	response := struct {
+		Message    string     `json:"message"`
+		Data       []Revision `json:"data"`
+		NextCursor string     `json:"next_cursor"`
+	}{
+		Message:    "Post revisions have been retrieved",
+		Data:       postRevisions,
+		NextCursor: nextCursor,
+	}
+
+	httputil.WriteResponse(w, http.StatusOK, response)
+}
+
+// RevertPost restores the title and description a post had before the given revision. Only admins
+// and the moderators of the post's community may revert posts.
+func (c *PostRevisionControllerImpl) RevertPost(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	postID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid post id"})
+		return
+	}
+
+	revisionID, err := strconv.Atoi(vars["revision_id"])
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid revision id"})
+		return
+	}
+
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	post, err := c.PostRepository.GetPostDetailByID(context.Background(), postID)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusNotFound, map[string]string{"error": "Post not found"})
+		return
+	}
+
+	allowed, err := c.canModerate(principal, post)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error checking permissions"})
+		return
+	}
+	if !allowed {
+		httputil.WriteResponse(w, http.StatusForbidden, map[string]string{"error": "You are not allowed to revert this post"})
+		return
+	}
+
+	revision, err := c.PostRevisionRepository.GetRevision(context.Background(), postID, revisionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			httputil.WriteResponse(w, http.StatusNotFound, map[string]string{"error": "Revision not found"})
+		} else {
+			httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving revision"})
+		}
+		return
+	}
+
+	revertedPost, _, err := c.PostRevisionRepository.RevisePost(context.Background(), postID, principal.ID, &revision.OldTitle, &revision.OldDescription, &revision.ID)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error reverting post"})
+		return
+	}
+
+	if err := c.TagService.ProcessPost(context.Background(), revertedPost); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error saving post tags"})
+		return
+	}
+
+	if err := c.TagService.LinkPosts(context.Background(), revertedPost); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving post details"})
+		return
+	}
+
+	response := struct {
+		Message string     `json:"message"`
+		Data    model.Post `json:"data"`
+	}{
+		Message: "Post has been reverted",
+		Data:    *revertedPost,
+	}
+
+	httputil.WriteResponse(w, http.StatusOK, response)
+}
+
+// canModerate reports whether the principal is an admin or a moderator of the post's community.
+func (c *PostRevisionControllerImpl) canModerate(principal *middleware.Principal, post *model.Post) (bool, error) {
+	if principal.HasRole(rbac.RoleAdmin) {
+		return true, nil
+	}
+	if post.CommunityID == nil {
+		return false, nil
+	}
+	return c.Authorizer.HasCommunityPermission(context.Background(), principal.Roles, principal.ID, *post.CommunityID, rbac.PermissionModerateCommunity)
+}
@@ -0,0 +1,18 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/temuka-api-service/middleware"
+	httputil "github.com/temuka-api-service/pkg/http"
+)
+
+// currentPrincipal returns the authenticated user of the request, writing a 401 response when there is none.
+func currentPrincipal(w http.ResponseWriter, r *http.Request) (*middleware.Principal, bool) {
+	principal, ok := middleware.GetPrincipal(r.Context())
+	if !ok {
+		httputil.WriteResponse(w, http.StatusUnauthorized, map[string]string{"error": "You are not authorized"})
+		return nil, false
+	}
+	return principal, true
+}
@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/temuka-api-service/internal/search"
+	httputil "github.com/temuka-api-service/pkg/http"
+)
+
+type SearchController interface {
+	Search(w http.ResponseWriter, r *http.Request)
+}
+
+type SearchControllerImpl struct {
+	SearchIndex search.Index
+}
+
+func NewSearchController(searchIndex search.Index) SearchController {
+	return &SearchControllerImpl{
+		SearchIndex: searchIndex,
+	}
+}
+
+func (c *SearchControllerImpl) Search(w http.ResponseWriter, r *http.Request) {
+	query := search.Query{
+		Text: r.URL.Query().Get("q"),
+	}
+
+	if types := r.URL.Query().Get("type"); types != "" {
+		query.Types = strings.Split(types, ",")
+	}
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil {
+			httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid limit"})
+			return
+		}
+		query.Limit = limit
+	}
+
+	if locationIDStr := r.URL.Query().Get("location_id"); locationIDStr != "" {
+		locationID, err := strconv.Atoi(locationIDStr)
+		if err != nil {
+			httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid location id"})
+			return
+		}
+		query.LocationID = locationID
+	}
+
+	results, err := c.SearchIndex.Search(context.Background(), query)
+	if err != nil {
+		switch {
+		case errors.Is(err, search.ErrEmptyQuery):
+			httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Search query is required"})
+		case errors.Is(err, search.ErrUnknownType):
+			httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid search type"})
+		default:
+			httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error searching"})
+		}
+		return
+	}
+
+	response := struct {
+		Message string          `json:"message"`
+		Data    *search.Results `json:"data"`
+	}{
+		Message: "Search results have been retrieved successfully",
+		Data:    results,
+	}
+
+	httputil.WriteResponse(w, http.StatusOK, response)
+}
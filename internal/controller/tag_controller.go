@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/temuka-api-service/internal/feed"
+	"github.com/temuka-api-service/internal/model"
+	httputil "github.com/temuka-api-service/pkg/http"
+)
+
+type TagController interface {
+	GetTrendingTags(w http.ResponseWriter, r *http.Request)
+}
+
+type TagControllerImpl struct {
+	TagService feed.TagService
+}
+
+func NewTagController(tagService feed.TagService) TagController {
+	return &TagControllerImpl{
+		TagService: tagService,
+	}
+}
+
+func (c *TagControllerImpl) GetTrendingTags(w http.ResponseWriter, r *http.Request) {
+	window := feed.DefaultTrendingTagWindow
+	if windowStr := r.URL.Query().Get("window"); windowStr != "" {
+		var found bool
+		window, found = feed.TrendingTagWindows[windowStr]
+		if !found {
+			httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid window, expected one of 1h, 24h or 7d"})
+			return
+		}
+	}
+
+	limit := 0
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 0 {
+			httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid limit"})
+			return
+		}
+	}
+
+	trending, err := c.TagService.GetTrendingTags(context.Background(), window, limit)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving trending tags"})
+		return
+	}
+
+	response := struct {
+		Message string                  `json:"message"`
+		Data    []model.TrendingHashtag `json:"data"`
+	}{
+		Message: "Trending tags have been retrieved",
+		Data:    trending,
+	}
+
+	httputil.WriteResponse(w, http.StatusOK, response)
+}
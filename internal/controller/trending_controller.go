@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/temuka-api-service/internal/feed"
+	"github.com/temuka-api-service/internal/model"
+	httputil "github.com/temuka-api-service/pkg/http"
+)
+
+type TrendingController interface {
+	GetTrendingCommunities(w http.ResponseWriter, r *http.Request)
+}
+
+type TrendingControllerImpl struct {
+	TrendingService feed.TrendingService
+}
+
+func NewTrendingController(trendingService feed.TrendingService) TrendingController {
+	return &TrendingControllerImpl{
+		TrendingService: trendingService,
+	}
+}
+
+func (c *TrendingControllerImpl) GetTrendingCommunities(w http.ResponseWriter, r *http.Request) {
+	filter, limit, ok := requestTrendingQuery(w, r)
+	if !ok {
+		return
+	}
+
+	trending, err := c.TrendingService.GetTrendingCommunities(context.Background(), filter, limit)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving trending communities"})
+		return
+	}
+
+	response := struct {
+		Message string                    `json:"message"`
+		Data    []model.TrendingCommunity `json:"data"`
+	}{
+		Message: "Trending communities have been retrieved",
+		Data:    trending,
+	}
+
+	httputil.WriteResponse(w, http.StatusOK, response)
+}
+
+// requestTrendingQuery returns the filter and limit selected by the university_id, location_id and
+// limit query parameters, writing a 400 response when any is malformed.
+func requestTrendingQuery(w http.ResponseWriter, r *http.Request) (feed.TrendingFilter, int, bool) {
+	query := r.URL.Query()
+
+	var filter feed.TrendingFilter
+	for _, param := range []struct {
+		name    string
+		message string
+		value   *int
+	}{
+		{"university_id", "Invalid university ID", &filter.UniversityID},
+		{"location_id", "Invalid location ID", &filter.LocationID},
+	} {
+		valueStr := query.Get(param.name)
+		if valueStr == "" {
+			continue
+		}
+		value, err := strconv.Atoi(valueStr)
+		if err != nil || value <= 0 {
+			httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": param.message})
+			return feed.TrendingFilter{}, 0, false
+		}
+		*param.value = value
+	}
+	if filter.UniversityID != 0 && filter.LocationID != 0 {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Filter by either university or location"})
+		return feed.TrendingFilter{}, 0, false
+	}
+
+	limit := 0
+	if limitStr := query.Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 0 {
+			httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid limit"})
+			return feed.TrendingFilter{}, 0, false
+		}
+	}
+
+	return filter, limit, true
+}
@@ -0,0 +1,259 @@
+package controller
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/temuka-api-service/internal/model"
+	"github.com/temuka-api-service/internal/repository"
+	"github.com/temuka-api-service/pkg/clock"
+	httputil "github.com/temuka-api-service/pkg/http"
+	"github.com/temuka-api-service/pkg/token"
+	"github.com/temuka-api-service/pkg/totp"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const recoveryCodeCount = 10
+
+type TwoFactorController interface {
+	Enroll(w http.ResponseWriter, r *http.Request)
+	Confirm(w http.ResponseWriter, r *http.Request)
+	Disable(w http.ResponseWriter, r *http.Request)
+}
+
+type TwoFactorControllerImpl struct {
+	UserRepository      repository.UserRepository
+	TwoFactorRepository repository.TwoFactorRepository
+	Clock               clock.Clock
+}
+
+func NewTwoFactorController(userRepo repository.UserRepository, twoFactorRepo repository.TwoFactorRepository, clk clock.Clock) TwoFactorController {
+	return &TwoFactorControllerImpl{
+		UserRepository:      userRepo,
+		TwoFactorRepository: twoFactorRepo,
+		Clock:               clk,
+	}
+}
+
+func (c *TwoFactorControllerImpl) Enroll(w http.ResponseWriter, r *http.Request) {
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := c.UserRepository.GetUserByID(context.Background(), principal.ID)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusNotFound, map[string]string{"error": "User not found"})
+		return
+	}
+
+	if user.TwoFactorEnabled {
+		httputil.WriteResponse(w, http.StatusConflict, map[string]string{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error generating secret"})
+		return
+	}
+
+	if err := c.TwoFactorRepository.SetTwoFactorSecret(context.Background(), user.ID, secret); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error saving secret"})
+		return
+	}
+
+	issuer := os.Getenv("TOTP_ISSUER")
+	if issuer == "" {
+		issuer = "Temuka"
+	}
+
+	type EnrollmentData struct {
+		Secret          string `json:"secret"`
+		ProvisioningURI string `json:"provisioning_uri"`
+	}
+
+	response := struct {
+		Message string         `json:"message"`
+		Data    EnrollmentData `json:"data"`
+	}{
+		Message: "Scan the provisioning URI with an authenticator app and confirm with a code",
+		Data: EnrollmentData{
+			Secret:          secret,
+			ProvisioningURI: totp.ProvisioningURI(secret, issuer, user.Email),
+		},
+	}
+
+	httputil.WriteResponse(w, http.StatusOK, response)
+}
+
+func (c *TwoFactorControllerImpl) Confirm(w http.ResponseWriter, r *http.Request) {
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	var requestBody struct {
+		Code string `json:"code"`
+	}
+
+	if err := httputil.ReadRequest(r, &requestBody); err != nil {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	user, err := c.UserRepository.GetUserByID(context.Background(), principal.ID)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusNotFound, map[string]string{"error": "User not found"})
+		return
+	}
+
+	if user.TwoFactorEnabled {
+		httputil.WriteResponse(w, http.StatusConflict, map[string]string{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+
+	if user.TwoFactorSecret == "" {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Two-factor enrollment has not been started"})
+		return
+	}
+
+	verified, err := verifySecondFactor(context.Background(), c.TwoFactorRepository, user, requestBody.Code, "", c.Clock.Now())
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error verifying code"})
+		return
+	}
+	if !verified {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid code"})
+		return
+	}
+
+	codes, hashes, err := generateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error generating recovery codes"})
+		return
+	}
+
+	if err := c.TwoFactorRepository.ReplaceRecoveryCodes(context.Background(), user.ID, hashes); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error saving recovery codes"})
+		return
+	}
+
+	if err := c.TwoFactorRepository.EnableTwoFactor(context.Background(), user.ID); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error enabling two-factor authentication"})
+		return
+	}
+
+	response := struct {
+		Message       string   `json:"message"`
+		RecoveryCodes []string `json:"recovery_codes"`
+	}{
+		Message:       "Two-factor authentication has been enabled, store the recovery codes somewhere safe",
+		RecoveryCodes: codes,
+	}
+
+	httputil.WriteResponse(w, http.StatusOK, response)
+}
+
+func (c *TwoFactorControllerImpl) Disable(w http.ResponseWriter, r *http.Request) {
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	var requestBody struct {
+		Password     string `json:"password"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+
+	if err := httputil.ReadRequest(r, &requestBody); err != nil {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	user, err := c.UserRepository.GetUserByID(context.Background(), principal.ID)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusNotFound, map[string]string{"error": "User not found"})
+		return
+	}
+
+	if !user.TwoFactorEnabled {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Two-factor authentication is not enabled"})
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(requestBody.Password)); err != nil {
+		httputil.WriteResponse(w, http.StatusUnauthorized, map[string]string{"error": "Invalid password or code"})
+		return
+	}
+
+	verified, err := verifySecondFactor(context.Background(), c.TwoFactorRepository, user, requestBody.Code, requestBody.RecoveryCode, c.Clock.Now())
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error verifying code"})
+		return
+	}
+	if !verified {
+		httputil.WriteResponse(w, http.StatusUnauthorized, map[string]string{"error": "Invalid password or code"})
+		return
+	}
+
+	if err := c.TwoFactorRepository.DisableTwoFactor(context.Background(), user.ID); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error disabling two-factor authentication"})
+		return
+	}
+
+	response := struct {
+		Message string `json:"message"`
+	}{
+		Message: "Two-factor authentication has been disabled",
+	}
+
+	httputil.WriteResponse(w, http.StatusOK, response)
+}
+
+// verifySecondFactor accepts either a TOTP code or an unused recovery code. Each TOTP step and
+// each recovery code can only be used once.
+func verifySecondFactor(ctx context.Context, twoFactorRepo repository.TwoFactorRepository, user *model.User, code, recoveryCode string, now time.Time) (bool, error) {
+	if code != "" {
+		step, ok := totp.ValidateCode(user.TwoFactorSecret, code, now)
+		if !ok {
+			return false, nil
+		}
+		return twoFactorRepo.MarkTwoFactorStepUsed(ctx, user.ID, step)
+	}
+
+	if recoveryCode != "" {
+		return twoFactorRepo.ConsumeRecoveryCode(ctx, user.ID, token.HashToken(normalizeRecoveryCode(recoveryCode)))
+	}
+
+	return false, nil
+}
+
+func generateRecoveryCodes(n int) ([]string, []string, error) {
+	codes := make([]string, 0, n)
+	hashes := make([]string, 0, n)
+
+	for i := 0; i < n; i++ {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+
+		encoded := hex.EncodeToString(b)
+		code := encoded[:5] + "-" + encoded[5:]
+		codes = append(codes, code)
+		hashes = append(hashes, token.HashToken(normalizeRecoveryCode(code)))
+	}
+
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(code)), "-", "")
+}
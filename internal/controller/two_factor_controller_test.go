@@ -0,0 +1,498 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/temuka-api-service/internal/model"
+	"github.com/temuka-api-service/middleware"
+	"github.com/temuka-api-service/pkg/clock"
+	"github.com/temuka-api-service/pkg/token"
+	"github.com/temuka-api-service/pkg/totp"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const testPassword = "correct horse battery staple"
+
+type authTestEnv struct {
+	clock      *clock.FakeClock
+	users      *fakeUserRepository
+	twoFactor  *fakeTwoFactorRepository
+	sessions   *fakeSessionRepository
+	lockouts   *fakeLoginLockoutRepository
+	auth       *AuthControllerImpl
+	controller *TwoFactorControllerImpl
+	user       *model.User
+}
+
+func newAuthTestEnv(t *testing.T) *authTestEnv {
+	t.Helper()
+	t.Setenv("JWT_SECRET_KEY", "test-secret")
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("hashing password: %v", err)
+	}
+
+	clk := clock.NewFake(time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC))
+	store := newFakeExpiringStore(clk)
+	users := newFakeUserRepository()
+	user := users.add(model.User{Username: "alice", Email: "alice@example.com", Password: string(passwordHash), EmailVerified: true})
+
+	env := &authTestEnv{
+		clock:     clk,
+		users:     users,
+		twoFactor: newFakeTwoFactorRepository(users),
+		sessions:  newFakeSessionRepository(),
+		lockouts:  &fakeLoginLockoutRepository{},
+		user:      user,
+	}
+	env.auth = &AuthControllerImpl{
+		UserRepository:         users,
+		SessionRepository:      env.sessions,
+		TwoFactorRepository:    env.twoFactor,
+		ChallengeRepository:    &fakeTwoFactorChallengeRepository{store: store},
+		LoginAttemptRepository: &fakeLoginAttemptRepository{store: store},
+		LoginLockoutRepository: env.lockouts,
+		Clock:                  clk,
+	}
+	env.controller = &TwoFactorControllerImpl{
+		UserRepository:      users,
+		TwoFactorRepository: env.twoFactor,
+		Clock:               clk,
+	}
+	return env
+}
+
+func (env *authTestEnv) principal() *middleware.Principal {
+	return &middleware.Principal{ID: env.user.ID, Username: env.user.Username, Email: env.user.Email}
+}
+
+// enable turns on two-factor authentication for the user and returns the secret and recovery codes.
+func (env *authTestEnv) enable(t *testing.T) (string, []string) {
+	t.Helper()
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatalf("generating secret: %v", err)
+	}
+	codes, hashes, err := generateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		t.Fatalf("generating recovery codes: %v", err)
+	}
+
+	env.users.update(env.user.ID, func(user *model.User) {
+		user.TwoFactorSecret = secret
+		user.TwoFactorEnabled = true
+	})
+	if err := env.twoFactor.ReplaceRecoveryCodes(context.Background(), env.user.ID, hashes); err != nil {
+		t.Fatalf("saving recovery codes: %v", err)
+	}
+	return secret, codes
+}
+
+func (env *authTestEnv) code(t *testing.T, secret string) string {
+	t.Helper()
+
+	code, err := totp.GenerateCode(secret, env.clock.Now())
+	if err != nil {
+		t.Fatalf("generating code: %v", err)
+	}
+	return code
+}
+
+// challenge logs in with the password and returns the two-factor challenge token.
+func (env *authTestEnv) challenge(t *testing.T) string {
+	t.Helper()
+
+	status, response := doJSON(t, env.auth.Login, nil, map[string]string{"email": env.user.Email, "password": testPassword})
+	if status != 200 || response["two_factor_required"] != true {
+		t.Fatalf("Login() = %d %v, want a two-factor challenge", status, response)
+	}
+	return response["challenge_token"].(string)
+}
+
+func TestTwoFactorEnroll(t *testing.T) {
+	tests := []struct {
+		name       string
+		enabled    bool
+		anonymous  bool
+		wantStatus int
+	}{
+		{name: "starts enrollment", wantStatus: 200},
+		{name: "already enabled", enabled: true, wantStatus: 409},
+		{name: "anonymous", anonymous: true, wantStatus: 401},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newAuthTestEnv(t)
+			if tt.enabled {
+				env.enable(t)
+			}
+			principal := env.principal()
+			if tt.anonymous {
+				principal = nil
+			}
+
+			status, response := doJSON(t, env.controller.Enroll, principal, nil)
+			if status != tt.wantStatus {
+				t.Fatalf("Enroll() = %d %v, want %d", status, response, tt.wantStatus)
+			}
+			if status != 200 {
+				return
+			}
+
+			data := response["data"].(map[string]interface{})
+			user := env.users.get(env.user.ID)
+			if data["secret"] != user.TwoFactorSecret || user.TwoFactorEnabled {
+				t.Fatalf("Enroll() stored secret %q enabled %v, responded with %v", user.TwoFactorSecret, user.TwoFactorEnabled, data["secret"])
+			}
+		})
+	}
+}
+
+func TestTwoFactorConfirm(t *testing.T) {
+	tests := []struct {
+		name       string
+		enrolled   bool
+		enabled    bool
+		code       func(t *testing.T, env *authTestEnv, secret string) string
+		wantStatus int
+	}{
+		{
+			name:       "current code",
+			enrolled:   true,
+			code:       func(t *testing.T, env *authTestEnv, secret string) string { return env.code(t, secret) },
+			wantStatus: 200,
+		},
+		{
+			name:     "previous step within skew",
+			enrolled: true,
+			code: func(t *testing.T, env *authTestEnv, secret string) string {
+				code := env.code(t, secret)
+				env.clock.Advance(totp.Period * time.Second)
+				return code
+			},
+			wantStatus: 200,
+		},
+		{
+			name:     "code too old",
+			enrolled: true,
+			code: func(t *testing.T, env *authTestEnv, secret string) string {
+				code := env.code(t, secret)
+				env.clock.Advance(3 * totp.Period * time.Second)
+				return code
+			},
+			wantStatus: 400,
+		},
+		{
+			name:       "wrong code",
+			enrolled:   true,
+			code:       func(t *testing.T, env *authTestEnv, secret string) string { return "000000" },
+			wantStatus: 400,
+		},
+		{
+			name:       "not enrolled",
+			code:       func(t *testing.T, env *authTestEnv, secret string) string { return "123456" },
+			wantStatus: 400,
+		},
+		{
+			name:       "already enabled",
+			enabled:    true,
+			code:       func(t *testing.T, env *authTestEnv, secret string) string { return env.code(t, secret) },
+			wantStatus: 409,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newAuthTestEnv(t)
+
+			var secret string
+			switch {
+			case tt.enabled:
+				secret, _ = env.enable(t)
+			case tt.enrolled:
+				status, response := doJSON(t, env.controller.Enroll, env.principal(), nil)
+				if status != 200 {
+					t.Fatalf("Enroll() = %d %v", status, response)
+				}
+				secret = response["data"].(map[string]interface{})["secret"].(string)
+			}
+
+			status, response := doJSON(t, env.controller.Confirm, env.principal(), map[string]string{"code": tt.code(t, env, secret)})
+			if status != tt.wantStatus {
+				t.Fatalf("Confirm() = %d %v, want %d", status, response, tt.wantStatus)
+			}
+			if status != 200 {
+				return
+			}
+
+			if !env.users.get(env.user.ID).TwoFactorEnabled {
+				t.Fatal("Confirm() did not enable two-factor authentication")
+			}
+			if codes := response["recovery_codes"].([]interface{}); len(codes) != recoveryCodeCount {
+				t.Fatalf("Confirm() returned %d recovery codes, want %d", len(codes), recoveryCodeCount)
+			}
+		})
+	}
+}
+
+func TestLoginTwoFactor(t *testing.T) {
+	tests := []struct {
+		name string
+		// request returns the challenge token and code fields to send.
+		request    func(t *testing.T, env *authTestEnv, secret string, recoveryCodes []string) map[string]string
+		wantStatus int
+	}{
+		{
+			name: "totp code",
+			request: func(t *testing.T, env *authTestEnv, secret string, recoveryCodes []string) map[string]string {
+				return map[string]string{"challenge_token": env.challenge(t), "code": env.code(t, secret)}
+			},
+			wantStatus: 200,
+		},
+		{
+			name: "wrong totp code",
+			request: func(t *testing.T, env *authTestEnv, secret string, recoveryCodes []string) map[string]string {
+				return map[string]string{"challenge_token": env.challenge(t), "code": "000000"}
+			},
+			wantStatus: 401,
+		},
+		{
+			name: "replayed totp code",
+			request: func(t *testing.T, env *authTestEnv, secret string, recoveryCodes []string) map[string]string {
+				code := env.code(t, secret)
+				status, response := doJSON(t, env.auth.LoginTwoFactor, nil, map[string]string{"challenge_token": env.challenge(t), "code": code})
+				if status != 200 {
+					t.Fatalf("first LoginTwoFactor() = %d %v", status, response)
+				}
+				return map[string]string{"challenge_token": env.challenge(t), "code": code}
+			},
+			wantStatus: 401,
+		},
+		{
+			name: "recovery code",
+			request: func(t *testing.T, env *authTestEnv, secret string, recoveryCodes []string) map[string]string {
+				return map[string]string{"challenge_token": env.challenge(t), "recovery_code": recoveryCodes[0]}
+			},
+			wantStatus: 200,
+		},
+		{
+			name: "recovery code in other case without dash",
+			request: func(t *testing.T, env *authTestEnv, secret string, recoveryCodes []string) map[string]string {
+				code := strings.ToUpper(recoveryCodes[1][:5] + recoveryCodes[1][6:])
+				return map[string]string{"challenge_token": env.challenge(t), "recovery_code": " " + code + " "}
+			},
+			wantStatus: 200,
+		},
+		{
+			name: "used recovery code",
+			request: func(t *testing.T, env *authTestEnv, secret string, recoveryCodes []string) map[string]string {
+				status, response := doJSON(t, env.auth.LoginTwoFactor, nil, map[string]string{"challenge_token": env.challenge(t), "recovery_code": recoveryCodes[0]})
+				if status != 200 {
+					t.Fatalf("first LoginTwoFactor() = %d %v", status, response)
+				}
+				return map[string]string{"challenge_token": env.challenge(t), "recovery_code": recoveryCodes[0]}
+			},
+			wantStatus: 401,
+		},
+		{
+			name: "expired challenge",
+			request: func(t *testing.T, env *authTestEnv, secret string, recoveryCodes []string) map[string]string {
+				challenge := env.challenge(t)
+				env.clock.Advance(token.TwoFactorChallengeTTL + time.Second)
+				return map[string]string{"challenge_token": challenge, "code": env.code(t, secret)}
+			},
+			wantStatus: 401,
+		},
+		{
+			name: "challenge used for a login",
+			request: func(t *testing.T, env *authTestEnv, secret string, recoveryCodes []string) map[string]string {
+				challenge := env.challenge(t)
+				status, response := doJSON(t, env.auth.LoginTwoFactor, nil, map[string]string{"challenge_token": challenge, "code": env.code(t, secret)})
+				if status != 200 {
+					t.Fatalf("first LoginTwoFactor() = %d %v", status, response)
+				}
+				env.clock.Advance(totp.Period * time.Second)
+				return map[string]string{"challenge_token": challenge, "code": env.code(t, secret)}
+			},
+			wantStatus: 401,
+		},
+		{
+			name: "challenge never handed out",
+			request: func(t *testing.T, env *authTestEnv, secret string, recoveryCodes []string) map[string]string {
+				challenge, err := token.GenerateTwoFactorChallengeToken(env.user.ID, "forged", env.clock.Now())
+				if err != nil {
+					t.Fatalf("signing challenge: %v", err)
+				}
+				return map[string]string{"challenge_token": challenge, "code": env.code(t, secret)}
+			},
+			wantStatus: 401,
+		},
+		{
+			name: "challenge dropped after too many wrong codes",
+			request: func(t *testing.T, env *authTestEnv, secret string, recoveryCodes []string) map[string]string {
+				challenge := env.challenge(t)
+				for i := 0; i < maxChallengeFailures; i++ {
+					status, response := doJSON(t, env.auth.LoginTwoFactor, nil, map[string]string{"challenge_token": challenge, "code": "000000"})
+					if status != 401 {
+						t.Fatalf("wrong code %d: LoginTwoFactor() = %d %v", i, status, response)
+					}
+				}
+				return map[string]string{"challenge_token": challenge, "code": env.code(t, secret)}
+			},
+			wantStatus: 401,
+		},
+		{
+			name: "user locked out after too many wrong codes",
+			request: func(t *testing.T, env *authTestEnv, secret string, recoveryCodes []string) map[string]string {
+				for i := 0; i < maxTwoFactorFailures; i++ {
+					status, response := doJSON(t, env.auth.LoginTwoFactor, nil, map[string]string{"challenge_token": env.challenge(t), "code": "000000"})
+					if status != 401 {
+						t.Fatalf("wrong code %d: LoginTwoFactor() = %d %v", i, status, response)
+					}
+				}
+				return map[string]string{"challenge_token": env.challenge(t), "code": env.code(t, secret)}
+			},
+			wantStatus: 429,
+		},
+		{
+			name: "lockout expires",
+			request: func(t *testing.T, env *authTestEnv, secret string, recoveryCodes []string) map[string]string {
+				for i := 0; i < maxTwoFactorFailures; i++ {
+					doJSON(t, env.auth.LoginTwoFactor, nil, map[string]string{"challenge_token": env.challenge(t), "code": "000000"})
+				}
+				env.clock.Advance(loginLockoutBase + time.Second)
+				return map[string]string{"challenge_token": env.challenge(t), "code": env.code(t, secret)}
+			},
+			wantStatus: 200,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newAuthTestEnv(t)
+			secret, recoveryCodes := env.enable(t)
+
+			sessionsBefore := env.sessions.count(env.user.ID)
+			status, response := doJSON(t, env.auth.LoginTwoFactor, nil, tt.request(t, env, secret, recoveryCodes))
+			if status != tt.wantStatus {
+				t.Fatalf("LoginTwoFactor() = %d %v, want %d", status, response, tt.wantStatus)
+			}
+
+			if status == 200 {
+				if response["token"] == "" || response["refresh_token"] == "" {
+					t.Fatalf("LoginTwoFactor() = %v, want a token pair", response)
+				}
+				if env.sessions.count(env.user.ID) != sessionsBefore+1 {
+					t.Fatal("LoginTwoFactor() did not start a session")
+				}
+			}
+		})
+	}
+}
+
+func TestLoginTwoFactorRecordsLockout(t *testing.T) {
+	env := newAuthTestEnv(t)
+	env.enable(t)
+
+	for i := 0; i < maxTwoFactorFailures; i++ {
+		doJSON(t, env.auth.LoginTwoFactor, nil, map[string]string{"challenge_token": env.challenge(t), "code": "000000"})
+	}
+
+	if len(env.lockouts.lockouts) != 1 {
+		t.Fatalf("recorded %d lockouts, want 1", len(env.lockouts.lockouts))
+	}
+	lockout := env.lockouts.lockouts[0]
+	if lockout.Scope != "two_factor" || lockout.UserID == nil || *lockout.UserID != env.user.ID {
+		t.Fatalf("recorded lockout %+v, want a two_factor lockout of user %d", lockout, env.user.ID)
+	}
+}
+
+func TestTwoFactorDisable(t *testing.T) {
+	tests := []struct {
+		name       string
+		notEnabled bool
+		password   string
+		code       func(t *testing.T, env *authTestEnv, secret string, recoveryCodes []string) map[string]string
+		wantStatus int
+	}{
+		{
+			name:     "password and totp code",
+			password: testPassword,
+			code: func(t *testing.T, env *authTestEnv, secret string, recoveryCodes []string) map[string]string {
+				return map[string]string{"code": env.code(t, secret)}
+			},
+			wantStatus: 200,
+		},
+		{
+			name:     "password and recovery code",
+			password: testPassword,
+			code: func(t *testing.T, env *authTestEnv, secret string, recoveryCodes []string) map[string]string {
+				return map[string]string{"recovery_code": recoveryCodes[3]}
+			},
+			wantStatus: 200,
+		},
+		{
+			name:     "wrong password",
+			password: "wrong",
+			code: func(t *testing.T, env *authTestEnv, secret string, recoveryCodes []string) map[string]string {
+				return map[string]string{"code": env.code(t, secret)}
+			},
+			wantStatus: 401,
+		},
+		{
+			name:     "wrong code",
+			password: testPassword,
+			code: func(t *testing.T, env *authTestEnv, secret string, recoveryCodes []string) map[string]string {
+				return map[string]string{"code": "000000"}
+			},
+			wantStatus: 401,
+		},
+		{
+			name:     "no code",
+			password: testPassword,
+			code: func(t *testing.T, env *authTestEnv, secret string, recoveryCodes []string) map[string]string {
+				return map[string]string{}
+			},
+			wantStatus: 401,
+		},
+		{
+			name:       "not enabled",
+			notEnabled: true,
+			password:   testPassword,
+			code: func(t *testing.T, env *authTestEnv, secret string, recoveryCodes []string) map[string]string {
+				return map[string]string{"code": "123456"}
+			},
+			wantStatus: 400,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newAuthTestEnv(t)
+
+			var secret string
+			var recoveryCodes []string
+			if !tt.notEnabled {
+				secret, recoveryCodes = env.enable(t)
+			}
+
+			body := tt.code(t, env, secret, recoveryCodes)
+			body["password"] = tt.password
+			status, response := doJSON(t, env.controller.Disable, env.principal(), body)
+			if status != tt.wantStatus {
+				t.Fatalf("Disable() = %d %v, want %d", status, response, tt.wantStatus)
+			}
+
+			user := env.users.get(env.user.ID)
+			if disabled := !user.TwoFactorEnabled && user.TwoFactorSecret == ""; disabled != (status == 200 || tt.notEnabled) {
+				t.Fatalf("after Disable() = %d two-factor enabled is %v", status, user.TwoFactorEnabled)
+			}
+		})
+	}
+}
@@ -172,31 +172,40 @@ func (c *UniversityControllerImpl) GetUniversityDetail(w http.ResponseWriter, r
 }
 
 func (c *UniversityControllerImpl) GetUniversities(w http.ResponseWriter, r *http.Request) {
+	page, ok := requestPage(w, r)
+	if !ok {
+		return
+	}
 
-	universities, err := c.UniversityRepository.GetUniversities(context.Background())
+	universities, nextCursor, err := c.UniversityRepository.GetUniversities(context.Background(), page)
 	if err != nil {
 		httputil.WriteResponse(w, http.StatusOK, map[string]string{"error": "Universities not found"})
 		return
 	}
 
 	response := struct {
-		Message string             `json:"message"`
-		Data    []model.University `json:"data"`
+		Message    string             `json:"message"`
+		Data       []model.University `json:"data"`
+		NextCursor string             `json:"next_cursor"`
 	}{
-		Message: "Data has been retrieved successfully",
-		Data:    universities,
+		Message:    "Data has been retrieved successfully",
+		Data:       universities,
+		NextCursor: nextCursor,
 	}
 
 	httputil.WriteResponse(w, http.StatusOK, response)
 }
 
 func (c *UniversityControllerImpl) AddReview(w http.ResponseWriter, r *http.Request) {
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
 
 	var requestBody struct {
-		UserID       int    `json:"user_id"`
 		UniversityID int    `json:"university_id"`
 		Text         string `json:"text"`
-		Rating       int    `json:"rating`
+		Rating       int    `json:"rating"`
 	}
 
 	if err := httputil.ReadRequest(r, &requestBody); err != nil {
@@ -205,9 +214,10 @@ func (c *UniversityControllerImpl) AddReview(w http.ResponseWriter, r *http.Requ
 	}
 
 	newUniversityReview := model.Review{
-		UserID:       requestBody.UserID,
+		UserID:       principal.ID,
 		UniversityID: requestBody.UniversityID,
 		Text:         requestBody.Text,
+		Stars:        requestBody.Rating,
 	}
 
 	if err := c.ReviewRepository.CreateReview(context.Background(), &newUniversityReview); err != nil {
@@ -263,18 +273,25 @@ func (c *UniversityControllerImpl) GetUniversityReviews(w http.ResponseWriter, r
 		return
 	}
 
-	universityReviews, err := c.ReviewRepository.GetReviewsByUniversityID(context.Background(), universityID)
+	page, ok := requestPage(w, r)
+	if !ok {
+		return
+	}
+
+	universityReviews, nextCursor, err := c.ReviewRepository.GetReviewsByUniversityID(context.Background(), universityID, page)
 	if err != nil {
 		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving university reviews"})
 		return
 	}
 
 	response := struct {
-		Message string         `json:"message"`
-		Data    []model.Review `json:"data"`
+		Message    string         `json:"message"`
+		Data       []model.Review `json:"data"`
+		NextCursor string         `json:"next_cursor"`
 	}{
-		Message: "Data has been retrieved successfully",
-		Data:    universityReviews,
+		Message:    "Data has been retrieved successfully",
+		Data:       universityReviews,
+		NextCursor: nextCursor,
 	}
 
 	httputil.WriteResponse(w, http.StatusOK, response)
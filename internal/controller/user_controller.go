@@ -2,12 +2,15 @@ package controller
 
 import (
 	"context"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
 
 	"github.com/gorilla/mux"
+	"github.com/temuka-api-service/internal/feed"
 	"github.com/temuka-api-service/internal/model"
+	"github.com/temuka-api-service/internal/rbac"
 	"github.com/temuka-api-service/internal/repository"
 	httputil "github.com/temuka-api-service/pkg/http"
 )
@@ -15,49 +18,64 @@ import (
 type UserController interface {
 	SearchUsers(w http.ResponseWriter, r *http.Request)
 	GetUserDetail(w http.ResponseWriter, r *http.Request)
-	CreateUser(w http.ResponseWriter, r *http.Request)
 	UpdateUser(w http.ResponseWriter, r *http.Request)
 	FollowUser(w http.ResponseWriter, r *http.Request)
 	GetFollowers(w http.ResponseWriter, r *http.Request)
+	UpdateUserRole(w http.ResponseWriter, r *http.Request)
 }
 
 type UserControllerImpl struct {
-	UserRepository repository.UserRepository
+	UserRepository  repository.UserRepository
+	TimelineService feed.TimelineService
 }
 
-func NewUserController(userRepository repository.UserRepository) UserController {
+func NewUserController(userRepository repository.UserRepository, timelineService feed.TimelineService) UserController {
 	return &UserControllerImpl{
-		UserRepository: userRepository,
+		UserRepository:  userRepository,
+		TimelineService: timelineService,
 	}
 }
 
 func (c *UserControllerImpl) SearchUsers(w http.ResponseWriter, r *http.Request) {
-	name := r.URL.Query().Get("name")
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		query = r.URL.Query().Get("name")
+	}
+	query = strings.TrimSpace(query)
+	if query == "" {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Search query is required"})
+		return
+	}
 
-	users, err := c.UserRepository.GetAllUsers(context.Background())
-	if err != nil {
-		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	page, ok := requestRankedPage(w, r)
+	if !ok {
 		return
 	}
 
-	var filteredUsers []model.User
-	for _, user := range users {
-		if name == "" || contains(user.Username, name) {
-			filteredUsers = append(filteredUsers, user)
-		}
+	results, nextCursor, err := c.UserRepository.SearchUsers(context.Background(), query, page)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error searching users"})
+		return
 	}
 
-	if len(filteredUsers) == 0 {
+	if len(results) == 0 && page.IsFirst() {
 		httputil.WriteResponse(w, http.StatusNotFound, map[string]string{"error": "No user found"})
 		return
 	}
 
+	users := make([]model.PublicUser, 0, len(results))
+	for i := range results {
+		users = append(users, results[i].Public())
+	}
+
 	response := struct {
-		Message string       `json:"message"`
-		Data    []model.User `json:"data"`
+		Message    string             `json:"message"`
+		Data       []model.PublicUser `json:"data"`
+		NextCursor string             `json:"next_cursor"`
 	}{
-		Message: "Search results have been retrieved successfully",
-		Data:    filteredUsers,
+		Message:    "Search results have been retrieved successfully",
+		Data:       users,
+		NextCursor: nextCursor,
 	}
 	httputil.WriteResponse(w, http.StatusOK, response)
 }
@@ -72,52 +90,29 @@ func (c *UserControllerImpl) GetUserDetail(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	user, err := c.UserRepository.GetUserByID(context.Background(), userID)
-	if err != nil {
-		httputil.WriteResponse(w, http.StatusNotFound, map[string]string{"error": "User not found"})
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
 		return
 	}
 
-	response := struct {
-		Message string     `json:"message"`
-		Data    model.User `json:"data"`
-	}{
-		Message: "User detail has been retrieved",
-		Data:    *user,
-	}
-
-	httputil.WriteResponse(w, http.StatusOK, response)
-}
-
-func (c *UserControllerImpl) CreateUser(w http.ResponseWriter, r *http.Request) {
-	var requestBody struct {
-		Username string `json:"username"`
-		Email    string `json:"email"`
-		Password string `json:"password"`
-	}
-
-	if err := httputil.ReadRequest(r, &requestBody); err != nil {
-		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	user, err := c.UserRepository.GetUserByID(context.Background(), userID)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusNotFound, map[string]string{"error": "User not found"})
 		return
 	}
 
-	newUser := model.User{
-		Username: requestBody.Username,
-		Email:    requestBody.Email,
-		Password: requestBody.Password,
-	}
-
-	if err := c.UserRepository.CreateUser(context.Background(), &newUser); err != nil {
-		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error creating user"})
-		return
+	// Only the user may see their own email address and account settings.
+	var data interface{} = user.Public()
+	if userID == principal.ID {
+		data = *user
 	}
 
 	response := struct {
-		Message string     `json:"message"`
-		Data    model.User `json:"data"`
+		Message string      `json:"message"`
+		Data    interface{} `json:"data"`
 	}{
-		Message: "User has been created",
-		Data:    newUser,
+		Message: "User detail has been retrieved",
+		Data:    data,
 	}
 
 	httputil.WriteResponse(w, http.StatusOK, response)
@@ -133,7 +128,15 @@ func (c *UserControllerImpl) UpdateUser(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	var user model.User
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	if userID != principal.ID {
+		httputil.WriteResponse(w, http.StatusForbidden, map[string]string{"error": "You are not allowed to update this user"})
+		return
+	}
 
 	var requestBody struct {
 		Username       string `json:"username"`
@@ -147,6 +150,21 @@ func (c *UserControllerImpl) UpdateUser(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	requestBody.Username = strings.TrimSpace(requestBody.Username)
+	if requestBody.Username != "" {
+		user, err := c.UserRepository.GetUserByID(context.Background(), userID)
+		if err != nil {
+			httputil.WriteResponse(w, http.StatusNotFound, map[string]string{"error": "User not found"})
+			return
+		}
+
+		// Usernames are unique regardless of case, but users may change the case of their own.
+		if !strings.EqualFold(user.Username, requestBody.Username) && !c.UserRepository.CheckUsernameAvailability(context.Background(), requestBody.Username) {
+			httputil.WriteResponse(w, http.StatusConflict, map[string]string{"error": "Username is already taken"})
+			return
+		}
+	}
+
 	updatedUser := model.User{
 		Username:       requestBody.Username,
 		Desc:           requestBody.Desc,
@@ -154,7 +172,6 @@ func (c *UserControllerImpl) UpdateUser(w http.ResponseWriter, r *http.Request)
 		ProfilePicture: requestBody.ProfilePicture,
 	}
 
-	user.ID = userID
 	if err := c.UserRepository.UpdateUser(context.Background(), userID, &updatedUser); err != nil {
 		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error updating user"})
 		return
@@ -170,9 +187,13 @@ func (c *UserControllerImpl) UpdateUser(w http.ResponseWriter, r *http.Request)
 }
 
 func (c *UserControllerImpl) FollowUser(w http.ResponseWriter, r *http.Request) {
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
 	var requestBody struct {
-		TargetID      int `json:"target_id"`
-		CurrentUserID int `json:"currentuser_id"`
+		TargetID int `json:"target_id"`
 	}
 
 	if err := httputil.ReadRequest(r, &requestBody); err != nil {
@@ -180,13 +201,18 @@ func (c *UserControllerImpl) FollowUser(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if requestBody.TargetID == principal.ID {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "You cannot follow yourself"})
+		return
+	}
+
 	if _, err := c.UserRepository.GetUserByID(context.Background(), requestBody.TargetID); err != nil {
 		httputil.WriteResponse(w, http.StatusNotFound, map[string]string{"error": "Target user not found"})
 		return
 	}
 
 	newUserFollow := model.UserFollow{
-		FollowerID:  requestBody.CurrentUserID,
+		FollowerID:  principal.ID,
 		FollowingID: requestBody.TargetID,
 	}
 
@@ -195,6 +221,10 @@ func (c *UserControllerImpl) FollowUser(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if err := c.TimelineService.InvalidateTimeline(context.Background(), principal.ID); err != nil {
+		log.Printf("Error invalidating timeline of user %d: %v", principal.ID, err)
+	}
+
 	response := struct {
 		Message string `json:"message"`
 	}{
@@ -205,8 +235,47 @@ func (c *UserControllerImpl) FollowUser(w http.ResponseWriter, r *http.Request)
 }
 
 func (c *UserControllerImpl) GetFollowers(w http.ResponseWriter, r *http.Request) {
+	principal, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	page, ok := requestPage(w, r)
+	if !ok {
+		return
+	}
+
+	followers, nextCursor, err := c.UserRepository.GetFollowers(context.Background(), principal.ID, page)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving followers"})
+		return
+	}
+
+	response := struct {
+		Message    string             `json:"message"`
+		Data       []model.UserFollow `json:"data"`
+		NextCursor string             `json:"next_cursor"`
+	}{
+		Message:    "Followers list has been retrieved",
+		Data:       followers,
+		NextCursor: nextCursor,
+	}
+
+	httputil.WriteResponse(w, http.StatusOK, response)
+}
+
+func (c *UserControllerImpl) UpdateUserRole(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userIDstr := vars["id"]
+
+	userID, err := strconv.Atoi(userIDstr)
+	if err != nil {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid user id"})
+		return
+	}
+
 	var requestBody struct {
-		UserID int `json:"user_id"`
+		Role string `json:"role"`
 	}
 
 	if err := httputil.ReadRequest(r, &requestBody); err != nil {
@@ -214,28 +283,26 @@ func (c *UserControllerImpl) GetFollowers(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	if _, err := c.UserRepository.GetUserByID(context.Background(), requestBody.UserID); err != nil {
+	if !rbac.IsValidRole(requestBody.Role) {
+		httputil.WriteResponse(w, http.StatusBadRequest, map[string]string{"error": "Invalid role"})
+		return
+	}
+
+	if _, err := c.UserRepository.GetUserByID(context.Background(), userID); err != nil {
 		httputil.WriteResponse(w, http.StatusNotFound, map[string]string{"error": "User not found"})
 		return
 	}
 
-	followers, err := c.UserRepository.GetFollowers(context.Background(), requestBody.UserID)
-	if err != nil {
-		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error retrieving followers"})
+	if err := c.UserRepository.UpdateUserRole(context.Background(), userID, requestBody.Role); err != nil {
+		httputil.WriteResponse(w, http.StatusInternalServerError, map[string]string{"error": "Error updating user role"})
 		return
 	}
 
 	response := struct {
-		Message string             `json:"message"`
-		Data    []model.UserFollow `json:"data"`
+		Message string `json:"message"`
 	}{
-		Message: "Followers list has been retrieved",
-		Data:    followers,
+		Message: "User role has been updated",
 	}
 
 	httputil.WriteResponse(w, http.StatusOK, response)
 }
-
-func contains(s, substr string) bool {
-	return len(s) > 0 && len(substr) > 0 && strings.Contains(s, substr)
-}
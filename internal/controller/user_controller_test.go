@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/temuka-api-service/internal/model"
+	"github.com/temuka-api-service/middleware"
+)
+
+func TestGetUserDetail(t *testing.T) {
+	users := newFakeUserRepository()
+	alice := users.add(model.User{Username: "alice", Email: "alice@example.com", EmailVerified: true, Role: "user"})
+	bob := users.add(model.User{Username: "bob", Email: "bob@example.com"})
+	controller := &UserControllerImpl{UserRepository: users}
+
+	tests := []struct {
+		name      string
+		userID    int
+		wantEmail bool
+	}{
+		{name: "own profile", userID: alice.ID, wantEmail: true},
+		{name: "other profile", userID: bob.ID, wantEmail: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r = mux.SetURLVars(r, map[string]string{"id": strconv.Itoa(tt.userID)})
+			r = r.WithContext(middleware.WithPrincipal(r.Context(), &middleware.Principal{ID: alice.ID, Username: alice.Username}))
+			w := httptest.NewRecorder()
+			controller.GetUserDetail(w, r)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status %d, body %s", w.Code, w.Body.String())
+			}
+
+			var response struct {
+				Data map[string]interface{} `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("decoding response: %v", err)
+			}
+			if response.Data["Username"] == nil && response.Data["username"] == nil {
+				t.Fatalf("data %v has no username", response.Data)
+			}
+			_, hasEmail := response.Data["Email"]
+			_, hasRole := response.Data["Role"]
+			if hasEmail != tt.wantEmail || hasRole != tt.wantEmail {
+				t.Fatalf("data %v: email and role shown = %v, want %v", response.Data, hasEmail, tt.wantEmail)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package feed
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/temuka-api-service/internal/model"
+	"github.com/temuka-api-service/internal/repository"
+	"github.com/temuka-api-service/pkg/clock"
+)
+
+const (
+	DefaultAnalyticsDays = 30
+	MaxAnalyticsDays     = 90
+)
+
+// AnalyticsService records how often posts are seen and reports the reach and engagement of posts and
+// authors. Views are counted in Redis and flushed to Postgres in the background.
+type AnalyticsService interface {
+	RecordViews(ctx context.Context, kind string, viewerID int, posts ...*model.Post)
+	FlushViews(ctx context.Context, limit int) (int, error)
+	PostAnalytics(ctx context.Context, postID int, from, to time.Time) (*model.Analytics, error)
+	AuthorAnalytics(ctx context.Context, authorID int, from, to time.Time) (*model.Analytics, error)
+}
+
+type AnalyticsServiceImpl struct {
+	AnalyticsRepository   repository.AnalyticsRepository
+	ViewCounterRepository repository.ViewCounterRepository
+	Clock                 clock.Clock
+}
+
+func NewAnalyticsService(analyticsRepo repository.AnalyticsRepository, viewCounterRepo repository.ViewCounterRepository, clk clock.Clock) AnalyticsService {
+	return &AnalyticsServiceImpl{
+		AnalyticsRepository:   analyticsRepo,
+		ViewCounterRepository: viewCounterRepo,
+		Clock:                 clk,
+	}
+}
+
+// RecordViews counts a view of the posts by the viewer. Authors viewing their own posts are not
+// counted. Failing to count is only logged, so that it never fails the request that showed the posts.
+func (s *AnalyticsServiceImpl) RecordViews(ctx context.Context, kind string, viewerID int, posts ...*model.Post) {
+	postAuthors := make(map[int]int, len(posts))
+	for _, post := range posts {
+		if post.UserID != viewerID {
+			postAuthors[post.ID] = post.UserID
+		}
+	}
+
+	if err := s.ViewCounterRepository.Record(ctx, kind, viewerID, s.Clock.Now(), postAuthors); err != nil {
+		log.Printf("Error recording %s views: %v", kind, err)
+	}
+}
+
+// FlushViews writes up to limit changed post counters and up to limit changed author counters to
+// Postgres, and returns the larger number it took of either. The counters are put back when they
+// cannot be written.
+func (s *AnalyticsServiceImpl) FlushViews(ctx context.Context, limit int) (int, error) {
+	posts, err := s.flushPostViews(ctx, limit)
+	if err != nil {
+		return 0, err
+	}
+	authors, err := s.flushAuthorViews(ctx, limit)
+	if err != nil {
+		return 0, err
+	}
+	if authors > posts {
+		return authors, nil
+	}
+	return posts, nil
+}
+
+func (s *AnalyticsServiceImpl) flushPostViews(ctx context.Context, limit int) (int, error) {
+	stats, err := s.ViewCounterRepository.PopChanged(ctx, int64(limit))
+	if err != nil || len(stats) == 0 {
+		return 0, err
+	}
+
+	if err := s.AnalyticsRepository.SaveViewStats(ctx, stats); err != nil {
+		if requeueErr := s.ViewCounterRepository.MarkChanged(ctx, stats); requeueErr != nil {
+			log.Printf("Error requeueing view counters: %v", requeueErr)
+		}
+		return 0, err
+	}
+	return len(stats), nil
+}
+
+func (s *AnalyticsServiceImpl) flushAuthorViews(ctx context.Context, limit int) (int, error) {
+	stats, err := s.ViewCounterRepository.PopChangedAuthors(ctx, int64(limit))
+	if err != nil || len(stats) == 0 {
+		return 0, err
+	}
+
+	if err := s.AnalyticsRepository.SaveAuthorViewStats(ctx, stats); err != nil {
+		if requeueErr := s.ViewCounterRepository.MarkChangedAuthors(ctx, stats); requeueErr != nil {
+			log.Printf("Error requeueing author view counters: %v", requeueErr)
+		}
+		return 0, err
+	}
+	return len(stats), nil
+}
+
+func (s *AnalyticsServiceImpl) PostAnalytics(ctx context.Context, postID int, from, to time.Time) (*model.Analytics, error) {
+	return s.analytics(ctx, repository.AnalyticsTarget{PostID: postID}, from, to)
+}
+
+func (s *AnalyticsServiceImpl) AuthorAnalytics(ctx context.Context, authorID int, from, to time.Time) (*model.Analytics, error) {
+	return s.analytics(ctx, repository.AnalyticsTarget{AuthorID: authorID}, from, to)
+}
+
+// analytics reports the days from from to to, both included, as UTC dates.
+func (s *AnalyticsServiceImpl) analytics(ctx context.Context, target repository.AnalyticsTarget, from, to time.Time) (*model.Analytics, error) {
+	stats, err := s.AnalyticsRepository.GetViewStats(ctx, target, from, to)
+	if err != nil {
+		return nil, err
+	}
+	reactions, err := s.AnalyticsRepository.GetDailyReactions(ctx, target, from, to)
+	if err != nil {
+		return nil, err
+	}
+	comments, err := s.AnalyticsRepository.GetDailyComments(ctx, target, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	analytics := &model.Analytics{
+		From: from.Format(model.AnalyticsDateFormat),
+		To:   to.Format(model.AnalyticsDateFormat),
+	}
+	dayIndex := make(map[string]int)
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		date := day.Format(model.AnalyticsDateFormat)
+		dayIndex[date] = len(analytics.Daily)
+		analytics.Daily = append(analytics.Daily, model.DailyAnalytics{
+			Date: date,
+			AnalyticsCounts: model.AnalyticsCounts{
+				Reactions: reactions[date],
+				Comments:  comments[date],
+			},
+		})
+	}
+
+	for _, stat := range stats {
+		i, found := dayIndex[stat.Day.Format(model.AnalyticsDateFormat)]
+		if !found {
+			continue
+		}
+		if stat.Kind == model.ViewKindDetail {
+			analytics.Daily[i].DetailViews += stat.Views
+		} else {
+			analytics.Daily[i].Impressions += stat.Views
+		}
+	}
+
+	viewers, err := s.viewerSketches(ctx, target, stats, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unique viewers are counted per day over the sketches of that day, and over all sketches for the
+	// whole range. The last group holds every sketch.
+	sketches := make([][]byte, 0, len(viewers))
+	groups := make([][]int, len(analytics.Daily)+1)
+	for _, viewer := range viewers {
+		i, found := dayIndex[viewer.day.Format(model.AnalyticsDateFormat)]
+		if !found {
+			continue
+		}
+		groups[i] = append(groups[i], len(sketches))
+		groups[len(groups)-1] = append(groups[len(groups)-1], len(sketches))
+		sketches = append(sketches, viewer.viewers)
+	}
+
+	uniqueViewers, err := s.ViewCounterRepository.CountUnions(ctx, sketches, groups)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range analytics.Daily {
+		daily := &analytics.Daily[i]
+		daily.UniqueViewers = uniqueViewers[i]
+		analytics.Totals.Impressions += daily.Impressions
+		analytics.Totals.DetailViews += daily.DetailViews
+		analytics.Totals.Reactions += daily.Reactions
+		analytics.Totals.Comments += daily.Comments
+	}
+	analytics.Totals.UniqueViewers = uniqueViewers[len(uniqueViewers)-1]
+	return analytics, nil
+}
+
+// daySketch is a HyperLogLog of the viewers of one day.
+type daySketch struct {
+	day     time.Time
+	viewers []byte
+}
+
+// viewerSketches returns the sketches unique viewers are counted from: those of both view kinds of a
+// post, or the one kept per day for an author, which already holds the viewers of all their posts.
+func (s *AnalyticsServiceImpl) viewerSketches(ctx context.Context, target repository.AnalyticsTarget, stats []model.PostViewStat, from, to time.Time) ([]daySketch, error) {
+	if target.PostID != 0 {
+		sketches := make([]daySketch, 0, len(stats))
+		for _, stat := range stats {
+			sketches = append(sketches, daySketch{day: stat.Day, viewers: stat.Viewers})
+		}
+		return sketches, nil
+	}
+
+	authorStats, err := s.AnalyticsRepository.GetAuthorViewStats(ctx, target.AuthorID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	sketches := make([]daySketch, 0, len(authorStats))
+	for _, stat := range authorStats {
+		sketches = append(sketches, daySketch{day: stat.Day, viewers: stat.Viewers})
+	}
+	return sketches, nil
+}
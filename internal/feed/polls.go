@@ -0,0 +1,170 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/temuka-api-service/internal/model"
+	"github.com/temuka-api-service/internal/repository"
+	"github.com/temuka-api-service/pkg/clock"
+)
+
+// NotificationPollClosed is the notification type sent to the author of a post whose poll closed.
+const NotificationPollClosed = "poll_closed"
+
+// PollService counts the votes of polls and pushes their results to clients watching them live.
+type PollService interface {
+	AttachPolls(ctx context.Context, viewerID int, posts ...*model.Post) error
+	Vote(ctx context.Context, poll *model.Poll, userID int, optionIDs []int) (model.PollResults, error)
+	Results(ctx context.Context, poll *model.Poll) (model.PollResults, error)
+	Subscribe(ctx context.Context, pollID int) (<-chan model.PollResults, func() error)
+	CloseDue(ctx context.Context, limit int) (int, error)
+}
+
+type PollServiceImpl struct {
+	PollRepository         repository.PollRepository
+	PollStreamRepository   repository.PollStreamRepository
+	PostRepository         repository.PostRepository
+	NotificationRepository repository.NotificationRepository
+	Clock                  clock.Clock
+}
+
+func NewPollService(pollRepo repository.PollRepository, pollStreamRepo repository.PollStreamRepository, postRepo repository.PostRepository, notificationRepo repository.NotificationRepository, clk clock.Clock) PollService {
+	return &PollServiceImpl{
+		PollRepository:         pollRepo,
+		PollStreamRepository:   pollStreamRepo,
+		PostRepository:         postRepo,
+		NotificationRepository: notificationRepo,
+		Clock:                  clk,
+	}
+}
+
+// AttachPolls sets the polls of the posts that have one, with the vote count of every option, the
+// number of voters and the options the viewer voted for.
+func (s *PollServiceImpl) AttachPolls(ctx context.Context, viewerID int, posts ...*model.Post) error {
+	postIDs := make([]int, 0, len(posts))
+	for _, post := range posts {
+		postIDs = append(postIDs, post.ID)
+	}
+
+	polls, err := s.PollRepository.GetPollsByPostIDs(ctx, postIDs)
+	if err != nil || len(polls) == 0 {
+		return err
+	}
+
+	pollIDs := make([]int, 0, len(polls))
+	for _, poll := range polls {
+		pollIDs = append(pollIDs, poll.ID)
+	}
+	results, err := s.PollRepository.GetResults(ctx, pollIDs)
+	if err != nil {
+		return err
+	}
+	viewerVotes, err := s.PollRepository.GetUserVotes(ctx, viewerID, pollIDs)
+	if err != nil {
+		return err
+	}
+
+	pollsByPostID := make(map[int]*model.Poll, len(polls))
+	for i := range polls {
+		poll := &polls[i]
+		applyResults(poll, results[poll.ID])
+		poll.ViewerVotes = viewerVotes[poll.ID]
+		pollsByPostID[poll.PostID] = poll
+	}
+	for _, post := range posts {
+		if poll, found := pollsByPostID[post.ID]; found {
+			post.Poll = poll
+		}
+	}
+	return nil
+}
+
+// Vote records the user's vote and pushes the new results to clients watching the poll. Failing to
+// push is only logged, since the vote itself went through.
+func (s *PollServiceImpl) Vote(ctx context.Context, poll *model.Poll, userID int, optionIDs []int) (model.PollResults, error) {
+	if err := s.PollRepository.Vote(ctx, poll.ID, userID, optionIDs, s.Clock.Now()); err != nil {
+		return model.PollResults{}, err
+	}
+
+	results, err := s.Results(ctx, poll)
+	if err != nil {
+		return model.PollResults{}, err
+	}
+	if err := s.PollStreamRepository.PublishResults(ctx, results); err != nil {
+		log.Printf("Error publishing results of poll %d: %v", poll.ID, err)
+	}
+	return results, nil
+}
+
+func (s *PollServiceImpl) Results(ctx context.Context, poll *model.Poll) (model.PollResults, error) {
+	results, err := s.PollRepository.GetResults(ctx, []int{poll.ID})
+	if err != nil {
+		return model.PollResults{}, err
+	}
+
+	result := results[poll.ID]
+	result.Closed = poll.IsClosed(s.Clock.Now())
+	return result, nil
+}
+
+func (s *PollServiceImpl) Subscribe(ctx context.Context, pollID int) (<-chan model.PollResults, func() error) {
+	return s.PollStreamRepository.SubscribeResults(ctx, pollID)
+}
+
+// CloseDue closes up to limit polls whose close time has passed, notifies the authors of their posts
+// and pushes the final results. It returns how many polls it closed.
+func (s *PollServiceImpl) CloseDue(ctx context.Context, limit int) (int, error) {
+	polls, err := s.PollRepository.ClaimClosedPolls(ctx, s.Clock.Now(), limit)
+	if err != nil || len(polls) == 0 {
+		return 0, err
+	}
+
+	postIDs := make([]int, 0, len(polls))
+	for _, poll := range polls {
+		postIDs = append(postIDs, poll.PostID)
+	}
+	// Polls of deleted or unpublished posts are closed without notifying anyone.
+	posts, err := s.PostRepository.GetPostsByIDs(ctx, postIDs)
+	if err != nil {
+		return 0, err
+	}
+	postsByID := make(map[int]*model.Post, len(posts))
+	for i := range posts {
+		postsByID[posts[i].ID] = &posts[i]
+	}
+
+	for i := range polls {
+		poll := &polls[i]
+		if post, found := postsByID[poll.PostID]; found {
+			notification := model.Notification{
+				UserID:  post.UserID,
+				ActorID: post.UserID,
+				PostID:  post.ID,
+				Type:    NotificationPollClosed,
+				Message: fmt.Sprintf("Your poll \"%s\" has closed", post.Title),
+			}
+			if err := s.NotificationRepository.CreateNotification(ctx, &notification); err != nil {
+				log.Printf("Error notifying the author of poll %d: %v", poll.ID, err)
+			}
+		}
+
+		results, err := s.Results(ctx, poll)
+		if err != nil {
+			log.Printf("Error counting results of poll %d: %v", poll.ID, err)
+			continue
+		}
+		if err := s.PollStreamRepository.PublishResults(ctx, results); err != nil {
+			log.Printf("Error publishing results of poll %d: %v", poll.ID, err)
+		}
+	}
+	return len(polls), nil
+}
+
+func applyResults(poll *model.Poll, results model.PollResults) {
+	poll.TotalVoters = results.TotalVoters
+	for i := range poll.Options {
+		poll.Options[i].Votes = results.Options[poll.Options[i].ID]
+	}
+}
@@ -0,0 +1,15 @@
+package feed
+
+import "math"
+
+// position identifies a post in a time-ordered feed. Posts with the same timestamp are ordered by id.
+type position struct {
+	Score  float64
+	PostID int
+}
+
+var startPosition = position{Score: math.MaxFloat64, PostID: math.MaxInt32}
+
+func (p position) after(other position) bool {
+	return p.Score > other.Score || (p.Score == other.Score && p.PostID > other.PostID)
+}
@@ -0,0 +1,106 @@
+package feed
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/temuka-api-service/internal/model"
+	"github.com/temuka-api-service/internal/repository"
+	"github.com/temuka-api-service/pkg/clock"
+)
+
+// PublishLease is how long a post may stay in the publishing state before the scheduler assumes the
+// instance that claimed it died and publishes it again. Every publishing step is idempotent.
+const PublishLease = 5 * time.Minute
+
+// PublishingService takes posts live. Going live stores the hashtags and mentions of the post,
+// notifies mentioned users, fans the post out to follower timelines and counts it in its community,
+// whether the post is published right away, from a draft or by the scheduler.
+type PublishingService interface {
+	CreatePost(ctx context.Context, post *model.Post) error
+	Publish(ctx context.Context, post *model.Post) error
+	PublishDue(ctx context.Context, limit int) (int, error)
+}
+
+type PublishingServiceImpl struct {
+	PostRepository  repository.PostRepository
+	TagService      TagService
+	TimelineService TimelineService
+	Clock           clock.Clock
+}
+
+func NewPublishingService(postRepo repository.PostRepository, tagService TagService, timelineService TimelineService, clk clock.Clock) PublishingService {
+	return &PublishingServiceImpl{
+		PostRepository:  postRepo,
+		TagService:      tagService,
+		TimelineService: timelineService,
+		Clock:           clk,
+	}
+}
+
+// CreatePost stores a new post and publishes it unless it is a draft or scheduled. A post without a
+// status is published.
+func (s *PublishingServiceImpl) CreatePost(ctx context.Context, post *model.Post) error {
+	if post.Status == "" {
+		post.Status = model.PostStatusPublished
+	}
+	if post.Status != model.PostStatusPublished {
+		return s.PostRepository.CreatePost(ctx, post)
+	}
+
+	now := s.Clock.Now()
+	post.Status = model.PostStatusPublishing
+	post.PublishedAt = &now
+	if err := s.PostRepository.CreatePost(ctx, post); err != nil {
+		return err
+	}
+	return s.goLive(ctx, post)
+}
+
+// Publish takes a draft or scheduled post live now. It returns repository.ErrPostAlreadyPublished when
+// the post went live in the meantime.
+func (s *PublishingServiceImpl) Publish(ctx context.Context, post *model.Post) error {
+	now := s.Clock.Now()
+	if err := s.PostRepository.ClaimPost(ctx, post.ID, now); err != nil {
+		return err
+	}
+
+	post.Status = model.PostStatusPublishing
+	post.PublishedAt = &now
+	return s.goLive(ctx, post)
+}
+
+// PublishDue publishes up to limit scheduled posts that are due and returns how many it claimed.
+// Posts that fail are left in the publishing state and retried once PublishLease has passed.
+func (s *PublishingServiceImpl) PublishDue(ctx context.Context, limit int) (int, error) {
+	now := s.Clock.Now()
+	posts, err := s.PostRepository.ClaimDuePosts(ctx, now, now.Add(-PublishLease), limit)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range posts {
+		if err := s.goLive(ctx, &posts[i]); err != nil {
+			log.Printf("Error publishing scheduled post %d: %v", posts[i].ID, err)
+		}
+	}
+	return len(posts), nil
+}
+
+// goLive runs the side effects of publishing a claimed post and then marks it published.
+func (s *PublishingServiceImpl) goLive(ctx context.Context, post *model.Post) error {
+	if err := s.TagService.ProcessPost(ctx, post); err != nil {
+		return err
+	}
+
+	if err := s.TimelineService.PublishPost(ctx, post); err != nil {
+		log.Printf("Error fanning out post %d: %v", post.ID, err)
+	}
+
+	if err := s.PostRepository.MarkPublished(ctx, post); err != nil {
+		return err
+	}
+	post.Status = model.PostStatusPublished
+	return nil
+}
@@ -0,0 +1,229 @@
+package feed
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/temuka-api-service/internal/model"
+	"github.com/temuka-api-service/internal/repository"
+	"github.com/temuka-api-service/pkg/clock"
+	"github.com/temuka-api-service/pkg/pagination"
+)
+
+const (
+	candidateWindow     = 7 * 24 * time.Hour
+	candidateLimit      = 500
+	recencyHalfLife     = 24 * time.Hour
+	commentVelocitySpan = 6 * time.Hour
+)
+
+// Score weights. Every signal is squashed with a log or decay first so that no single one dominates.
+const (
+	recencyWeight         = 3.0
+	reactionsWeight       = 1.0
+	commentVelocityWeight = 1.5
+	affinityWeight        = 2.0
+)
+
+// Candidate sources and the boost a post gets for coming from them.
+const (
+	SourceFollowing  = "following"
+	SourceCommunity  = "community"
+	SourceUniversity = "university"
+)
+
+var sourceBoosts = map[string]float64{
+	SourceFollowing:  1.0,
+	SourceCommunity:  0.5,
+	SourceUniversity: 0.3,
+}
+
+// ScoreBreakdown explains how a recommended post was scored; Total is the sum of the other scores.
+type ScoreBreakdown struct {
+	Sources         []string `json:"sources"`
+	AgeHours        float64  `json:"age_hours"`
+	Reactions       int64    `json:"reactions"`
+	RecentComments  int64    `json:"recent_comments"`
+	Interactions    int64    `json:"author_interactions"`
+	Recency         float64  `json:"recency"`
+	Popularity      float64  `json:"popularity"`
+	CommentVelocity float64  `json:"comment_velocity"`
+	AuthorAffinity  float64  `json:"author_affinity"`
+	SourceBoost     float64  `json:"source_boost"`
+	Total           float64  `json:"total"`
+}
+
+type RecommendedPost struct {
+	Post      model.Post      `json:"post"`
+	Score     float64         `json:"score"`
+	Breakdown *ScoreBreakdown `json:"breakdown,omitempty"`
+}
+
+type RecommendationService interface {
+	GetRecommendedPosts(ctx context.Context, userID int, page pagination.RankedPage, debug bool) ([]RecommendedPost, string, error)
+}
+
+type RecommendationServiceImpl struct {
+	UserRepository           repository.UserRepository
+	RecommendationRepository repository.RecommendationRepository
+	Clock                    clock.Clock
+}
+
+func NewRecommendationService(userRepo repository.UserRepository, recommendationRepo repository.RecommendationRepository, clk clock.Clock) RecommendationService {
+	return &RecommendationServiceImpl{
+		UserRepository:           userRepo,
+		RecommendationRepository: recommendationRepo,
+		Clock:                    clk,
+	}
+}
+
+// GetRecommendedPosts scores recent posts from followed users, joined communities and communities of the
+// universities the user is interested in, and returns a page of them, best first. Scores are recomputed on
+// every request, so later pages follow the ranking as it stands when they are read. Breakdowns are only
+// filled in debug mode.
+func (s *RecommendationServiceImpl) GetRecommendedPosts(ctx context.Context, userID int, page pagination.RankedPage, debug bool) ([]RecommendedPost, string, error) {
+	now := s.Clock.Now()
+
+	followingIDs, err := s.UserRepository.GetFollowingIDs(ctx, userID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	joinedCommunities, err := s.RecommendationRepository.GetJoinedCommunities(ctx, userID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	universityIDs, err := s.RecommendationRepository.GetReviewedUniversityIDs(ctx, userID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	joinedCommunityIDs := make([]int, 0, len(joinedCommunities))
+	for _, community := range joinedCommunities {
+		joinedCommunityIDs = append(joinedCommunityIDs, community.ID)
+		if community.UniversityID != nil {
+			universityIDs = append(universityIDs, *community.UniversityID)
+		}
+	}
+
+	universityCommunityIDs, err := s.RecommendationRepository.GetUniversityCommunityIDs(ctx, universityIDs)
+	if err != nil {
+		return nil, "", err
+	}
+
+	followed := toSet(followingIDs)
+	joined := toSet(joinedCommunityIDs)
+	universityCommunities := toSet(universityCommunityIDs)
+
+	candidates, err := s.RecommendationRepository.GetCandidatePosts(ctx, userID, followingIDs, append(joinedCommunityIDs, universityCommunityIDs...), now.Add(-candidateWindow), candidateLimit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	postIDs := make([]int, 0, len(candidates))
+	authorIDs := make([]int, 0, len(candidates))
+	for _, post := range candidates {
+		postIDs = append(postIDs, post.ID)
+		authorIDs = append(authorIDs, post.UserID)
+	}
+
+	engagement, err := s.RecommendationRepository.GetPostEngagement(ctx, postIDs, now.Add(-commentVelocitySpan))
+	if err != nil {
+		return nil, "", err
+	}
+
+	affinity, err := s.RecommendationRepository.GetAuthorAffinity(ctx, userID, authorIDs)
+	if err != nil {
+		return nil, "", err
+	}
+
+	recommended := make([]RecommendedPost, 0, len(candidates))
+	for _, post := range candidates {
+		var sources []string
+		if followed[post.UserID] {
+			sources = append(sources, SourceFollowing)
+		}
+		if post.CommunityID != nil && joined[*post.CommunityID] {
+			sources = append(sources, SourceCommunity)
+		}
+		if post.CommunityID != nil && universityCommunities[*post.CommunityID] {
+			sources = append(sources, SourceUniversity)
+		}
+
+		breakdown := scorePost(post, engagement[post.ID], affinity[post.UserID], sources, now)
+		recommendedPost := RecommendedPost{Post: post, Score: breakdown.Total}
+		if debug {
+			recommendedPost.Breakdown = &breakdown
+		}
+		recommended = append(recommended, recommendedPost)
+	}
+
+	sort.Slice(recommended, func(i, j int) bool {
+		if recommended[i].Score != recommended[j].Score {
+			return recommended[i].Score > recommended[j].Score
+		}
+		return recommended[i].Post.ID > recommended[j].Post.ID
+	})
+
+	if !page.IsFirst() {
+		remaining := recommended[:0]
+		for _, recommendedPost := range recommended {
+			if recommendedPost.Score < page.AfterScore || (recommendedPost.Score == page.AfterScore && recommendedPost.Post.ID < page.AfterID) {
+				remaining = append(remaining, recommendedPost)
+			}
+		}
+		recommended = remaining
+	}
+	if len(recommended) > page.Limit+1 {
+		recommended = recommended[:page.Limit+1]
+	}
+
+	recommended, nextCursor := pagination.RankedResult(page, recommended, func(recommendedPost RecommendedPost) (float64, int) {
+		return recommendedPost.Score, recommendedPost.Post.ID
+	})
+	return recommended, nextCursor, nil
+}
+
+func scorePost(post model.Post, engagement model.PostEngagement, interactions int64, sources []string, now time.Time) ScoreBreakdown {
+	age := now.Sub(post.LiveAt())
+	if age < 0 {
+		age = 0
+	}
+
+	breakdown := ScoreBreakdown{
+		Sources:        sources,
+		AgeHours:       round(age.Hours()),
+		Reactions:      engagement.Reactions,
+		RecentComments: engagement.RecentComments,
+		Interactions:   interactions,
+	}
+
+	breakdown.Recency = round(recencyWeight * math.Pow(0.5, float64(age)/float64(recencyHalfLife)))
+	breakdown.Popularity = round(reactionsWeight * math.Log1p(float64(engagement.Reactions)))
+	breakdown.CommentVelocity = round(commentVelocityWeight * math.Log1p(float64(engagement.RecentComments)/commentVelocitySpan.Hours()))
+	breakdown.AuthorAffinity = round(affinityWeight * math.Log1p(float64(interactions)))
+
+	for _, source := range sources {
+		if sourceBoosts[source] > breakdown.SourceBoost {
+			breakdown.SourceBoost = sourceBoosts[source]
+		}
+	}
+
+	breakdown.Total = round(breakdown.Recency + breakdown.Popularity + breakdown.CommentVelocity + breakdown.AuthorAffinity + breakdown.SourceBoost)
+	return breakdown
+}
+
+func toSet(ids []int) map[int]bool {
+	set := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
+
+func round(value float64) float64 {
+	return math.Round(value*1000) / 1000
+}
@@ -0,0 +1,236 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/temuka-api-service/internal/model"
+	"github.com/temuka-api-service/internal/repository"
+	"github.com/temuka-api-service/pkg/clock"
+	"github.com/temuka-api-service/pkg/textproc"
+)
+
+const (
+	DefaultTrendingTagWindow = 24 * time.Hour
+	DefaultTrendingTagLimit  = 10
+	MaxTrendingTagLimit      = 50
+
+	// NotificationMention is the notification type sent to mentioned users.
+	NotificationMention = "mention"
+)
+
+// TrendingTagWindows are the sliding windows trending tags can be counted over.
+var TrendingTagWindows = map[string]time.Duration{
+	"1h":  time.Hour,
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+}
+
+var ErrInvalidTrendingWindow = errors.New("invalid trending window")
+
+// Text fields entities are reported for.
+const (
+	FieldTitle       = "title"
+	FieldDescription = "description"
+	FieldContent     = "content"
+)
+
+// TagService runs user-written text through textproc: it stores the hashtags and mentions of posts
+// and comments, notifies newly mentioned users and links the entities in responses.
+type TagService interface {
+	ProcessPost(ctx context.Context, post *model.Post) error
+	ProcessComment(ctx context.Context, comment *model.Comment, post *model.Post) error
+	LinkPosts(ctx context.Context, posts ...*model.Post) error
+	LinkComments(ctx context.Context, comments ...*model.Comment) error
+	GetTrendingTags(ctx context.Context, window time.Duration, limit int) ([]model.TrendingHashtag, error)
+}
+
+type TagServiceImpl struct {
+	TagRepository          repository.TagRepository
+	UserRepository         repository.UserRepository
+	NotificationRepository repository.NotificationRepository
+	Clock                  clock.Clock
+}
+
+func NewTagService(tagRepo repository.TagRepository, userRepo repository.UserRepository, notificationRepo repository.NotificationRepository, clk clock.Clock) TagService {
+	return &TagServiceImpl{
+		TagRepository:          tagRepo,
+		UserRepository:         userRepo,
+		NotificationRepository: notificationRepo,
+		Clock:                  clk,
+	}
+}
+
+// ProcessPost stores the hashtags and mentions of a new or edited post. Users are only notified the
+// first time a post mentions them, and never about their own posts.
+func (s *TagServiceImpl) ProcessPost(ctx context.Context, post *model.Post) error {
+	entities := append(textproc.Parse(post.Title), textproc.Parse(post.Description)...)
+
+	mentioned, err := s.mentionedUsers(ctx, textproc.Mentions(entities))
+	if err != nil {
+		return err
+	}
+
+	newMentions, err := s.TagRepository.ReplacePostTags(ctx, post.ID, textproc.Hashtags(entities), userIDs(mentioned))
+	if err != nil {
+		return err
+	}
+
+	return s.notifyMentions(ctx, newMentions, post.UserID, map[int]bool{}, func(actor *model.User) model.Notification {
+		return model.Notification{
+			PostID:  post.ID,
+			Message: actor.Username + " mentioned you in a post: " + post.Title,
+		}
+	})
+}
+
+// ProcessComment is ProcessPost for comments. The author of the post is not notified of mentions
+// since the comment itself already notifies them.
+func (s *TagServiceImpl) ProcessComment(ctx context.Context, comment *model.Comment, post *model.Post) error {
+	entities := textproc.Parse(comment.Content)
+
+	mentioned, err := s.mentionedUsers(ctx, textproc.Mentions(entities))
+	if err != nil {
+		return err
+	}
+
+	newMentions, err := s.TagRepository.ReplaceCommentTags(ctx, comment.ID, textproc.Hashtags(entities), userIDs(mentioned))
+	if err != nil {
+		return err
+	}
+
+	return s.notifyMentions(ctx, newMentions, comment.UserID, map[int]bool{post.UserID: true}, func(actor *model.User) model.Notification {
+		return model.Notification{
+			PostID:    post.ID,
+			CommentID: comment.ID,
+			Message:   actor.Username + " mentioned you in a comment: " + post.Title,
+		}
+	})
+}
+
+// LinkPosts fills in the entities of the title and description of the posts.
+func (s *TagServiceImpl) LinkPosts(ctx context.Context, posts ...*model.Post) error {
+	var fields []*textField
+	for _, post := range posts {
+		post.Entities = []model.TextEntity{}
+		fields = append(fields,
+			&textField{name: FieldTitle, text: post.Title, entities: &post.Entities},
+			&textField{name: FieldDescription, text: post.Description, entities: &post.Entities},
+		)
+	}
+	return s.link(ctx, fields)
+}
+
+// LinkComments fills in the entities of the content of the comments.
+func (s *TagServiceImpl) LinkComments(ctx context.Context, comments ...*model.Comment) error {
+	var fields []*textField
+	for _, comment := range comments {
+		comment.Entities = []model.TextEntity{}
+		fields = append(fields, &textField{name: FieldContent, text: comment.Content, entities: &comment.Entities})
+	}
+	return s.link(ctx, fields)
+}
+
+// GetTrendingTags ranks the tags by their uses within the window ending now.
+func (s *TagServiceImpl) GetTrendingTags(ctx context.Context, window time.Duration, limit int) ([]model.TrendingHashtag, error) {
+	if window <= 0 {
+		return nil, ErrInvalidTrendingWindow
+	}
+	if limit <= 0 {
+		limit = DefaultTrendingTagLimit
+	}
+	if limit > MaxTrendingTagLimit {
+		limit = MaxTrendingTagLimit
+	}
+
+	since := s.Clock.Now().Add(-window)
+	trending, err := s.TagRepository.GetTrendingHashtags(ctx, since, since.Add(-window), limit)
+	if err != nil {
+		return nil, err
+	}
+	if trending == nil {
+		trending = []model.TrendingHashtag{}
+	}
+	return trending, nil
+}
+
+type textField struct {
+	name     string
+	text     string
+	entities *[]model.TextEntity
+}
+
+// link parses every field and resolves all mentioned usernames with a single query.
+func (s *TagServiceImpl) link(ctx context.Context, fields []*textField) error {
+	parsed := make([][]textproc.Entity, len(fields))
+	var usernames []string
+	for i, field := range fields {
+		parsed[i] = textproc.Parse(field.text)
+		usernames = append(usernames, textproc.Mentions(parsed[i])...)
+	}
+
+	mentioned, err := s.mentionedUsers(ctx, usernames)
+	if err != nil {
+		return err
+	}
+	userIDsByName := make(map[string]int, len(mentioned))
+	for _, user := range mentioned {
+		userIDsByName[strings.ToLower(user.Username)] = user.ID
+	}
+
+	for i, field := range fields {
+		for _, entity := range parsed[i] {
+			textEntity := model.TextEntity{Field: field.name, Entity: entity}
+			if entity.Type == textproc.EntityMention {
+				textEntity.UserID = userIDsByName[entity.Value]
+			}
+			*field.entities = append(*field.entities, textEntity)
+		}
+	}
+	return nil
+}
+
+func (s *TagServiceImpl) mentionedUsers(ctx context.Context, usernames []string) ([]model.User, error) {
+	if len(usernames) == 0 {
+		return nil, nil
+	}
+	return s.UserRepository.GetUsersByUsernames(ctx, usernames)
+}
+
+// notifyMentions notifies each mentioned user except the actor and the skipped users. newNotification
+// fills in the notification for the given actor; the recipient, actor and type are set here.
+func (s *TagServiceImpl) notifyMentions(ctx context.Context, mentionedUserIDs []int, actorID int, skip map[int]bool, newNotification func(actor *model.User) model.Notification) error {
+	var actor *model.User
+	for _, userID := range mentionedUserIDs {
+		if userID == actorID || skip[userID] {
+			continue
+		}
+
+		if actor == nil {
+			var err error
+			actor, err = s.UserRepository.GetUserByID(ctx, actorID)
+			if err != nil {
+				return err
+			}
+		}
+
+		notification := newNotification(actor)
+		notification.UserID = userID
+		notification.ActorID = actorID
+		notification.Type = NotificationMention
+		if err := s.NotificationRepository.CreateNotification(ctx, &notification); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func userIDs(users []model.User) []int {
+	ids := make([]int, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, user.ID)
+	}
+	return ids
+}
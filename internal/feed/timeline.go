@@ -0,0 +1,232 @@
+package feed
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/temuka-api-service/internal/model"
+	"github.com/temuka-api-service/internal/repository"
+	"github.com/temuka-api-service/pkg/pagination"
+)
+
+const (
+	// CelebrityFollowerThreshold is the follower count above which an author's posts are no longer pushed
+	// into follower timelines but merged in when a timeline is read.
+	CelebrityFollowerThreshold = 5000
+
+	timelineMaxLength = 800
+)
+
+type TimelineService interface {
+	PublishPost(ctx context.Context, post *model.Post) error
+	RemovePost(ctx context.Context, post *model.Post) error
+	InvalidateTimeline(ctx context.Context, userID int) error
+	GetTimeline(ctx context.Context, userID int, page pagination.RankedPage) ([]model.Post, string, error)
+}
+
+type TimelineServiceImpl struct {
+	PostRepository     repository.PostRepository
+	UserRepository     repository.UserRepository
+	TimelineRepository repository.TimelineRepository
+}
+
+func NewTimelineService(postRepo repository.PostRepository, userRepo repository.UserRepository, timelineRepo repository.TimelineRepository) TimelineService {
+	return &TimelineServiceImpl{
+		PostRepository:     postRepo,
+		UserRepository:     userRepo,
+		TimelineRepository: timelineRepo,
+	}
+}
+
+func postScore(post *model.Post) float64 {
+	return float64(post.LiveAt().UnixMilli())
+}
+
+// PublishPost pushes the post into the author's own timeline and, unless the author is a celebrity,
+// into the timelines of all followers.
+func (s *TimelineServiceImpl) PublishPost(ctx context.Context, post *model.Post) error {
+	recipients, err := s.fanOutRecipients(ctx, post.UserID)
+	if err != nil {
+		return err
+	}
+
+	entry := model.TimelineEntry{PostID: post.ID, Score: postScore(post)}
+	return s.TimelineRepository.AddPost(ctx, recipients, entry, timelineMaxLength)
+}
+
+func (s *TimelineServiceImpl) RemovePost(ctx context.Context, post *model.Post) error {
+	recipients, err := s.fanOutRecipients(ctx, post.UserID)
+	if err != nil {
+		return err
+	}
+
+	return s.TimelineRepository.RemovePost(ctx, recipients, post.ID)
+}
+
+func (s *TimelineServiceImpl) fanOutRecipients(ctx context.Context, authorID int) ([]int, error) {
+	recipients := []int{authorID}
+
+	followerIDs, err := s.UserRepository.GetFollowerIDs(ctx, authorID)
+	if err != nil {
+		return nil, err
+	}
+	if len(followerIDs) >= CelebrityFollowerThreshold {
+		return recipients, nil
+	}
+
+	return append(recipients, followerIDs...), nil
+}
+
+// InvalidateTimeline drops the cached timeline, e.g. after the user follows someone, so that it is
+// rebuilt from Postgres on the next read.
+func (s *TimelineServiceImpl) InvalidateTimeline(ctx context.Context, userID int) error {
+	return s.TimelineRepository.DeleteTimeline(ctx, userID)
+}
+
+// GetTimeline returns a page of posts, newest first, together with the cursor of the next page. Posts
+// are ranked by publish time in milliseconds. The next cursor is empty on the last page.
+func (s *TimelineServiceImpl) GetTimeline(ctx context.Context, userID int, page pagination.RankedPage) ([]model.Post, string, error) {
+	from := startPosition
+	if !page.IsFirst() {
+		from = position{Score: page.AfterScore, PostID: page.AfterID}
+	}
+	limit := page.Limit
+
+	pushedAuthors, celebrityAuthors, err := s.splitFollowings(ctx, userID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	exists, err := s.TimelineRepository.TimelineExists(ctx, userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if !exists {
+		if err := s.rebuildTimeline(ctx, userID, pushedAuthors); err != nil {
+			return nil, "", err
+		}
+	}
+
+	// Entries that share the cursor's score but come after it are filtered out below, so fetch a
+	// few extra to still fill the page.
+	pushed, err := s.TimelineRepository.GetTimeline(ctx, userID, from.Score, int64(limit*2+1))
+	if err != nil {
+		return nil, "", err
+	}
+
+	candidates := make([]position, 0, len(pushed)+limit)
+	for _, entry := range pushed {
+		candidates = append(candidates, position{Score: entry.Score, PostID: entry.PostID})
+	}
+
+	if len(celebrityAuthors) > 0 {
+		before := time.UnixMilli(int64(from.Score))
+		if from == startPosition {
+			before = time.Now().Add(time.Hour)
+		}
+
+		celebrityPosts, err := s.PostRepository.GetPostsByUserIDsBefore(ctx, celebrityAuthors, before, from.PostID, limit+1)
+		if err != nil {
+			return nil, "", err
+		}
+		for i := range celebrityPosts {
+			candidates = append(candidates, position{Score: postScore(&celebrityPosts[i]), PostID: celebrityPosts[i].ID})
+		}
+	}
+
+	positions, nextCursor := pagination.RankedResult(page, mergePositions(candidates, from, limit+1), func(p position) (float64, int) {
+		return p.Score, p.PostID
+	})
+
+	ids := make([]int, 0, len(positions))
+	for _, p := range positions {
+		ids = append(ids, p.PostID)
+	}
+
+	posts, err := s.PostRepository.GetPostsByIDs(ctx, ids)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return orderPosts(posts, ids), nextCursor, nil
+}
+
+// splitFollowings returns the authors whose posts are pushed into the user's timeline, including the
+// user, and the celebrity authors whose posts are merged in at read time.
+func (s *TimelineServiceImpl) splitFollowings(ctx context.Context, userID int) ([]int, []int, error) {
+	followingIDs, err := s.UserRepository.GetFollowingIDs(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	followerCounts, err := s.UserRepository.GetFollowerCounts(ctx, followingIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pushedAuthors := []int{userID}
+	var celebrityAuthors []int
+	for _, followingID := range followingIDs {
+		if followerCounts[followingID] >= CelebrityFollowerThreshold {
+			celebrityAuthors = append(celebrityAuthors, followingID)
+		} else {
+			pushedAuthors = append(pushedAuthors, followingID)
+		}
+	}
+
+	return pushedAuthors, celebrityAuthors, nil
+}
+
+func (s *TimelineServiceImpl) rebuildTimeline(ctx context.Context, userID int, authorIDs []int) error {
+	posts, err := s.PostRepository.GetPostsByUserIDsBefore(ctx, authorIDs, time.Now().Add(time.Hour), startPosition.PostID, timelineMaxLength)
+	if err != nil {
+		return err
+	}
+
+	entries := make([]model.TimelineEntry, 0, len(posts))
+	for i := range posts {
+		entries = append(entries, model.TimelineEntry{PostID: posts[i].ID, Score: postScore(&posts[i])})
+	}
+
+	return s.TimelineRepository.ReplaceTimeline(ctx, userID, entries)
+}
+
+// mergePositions sorts the candidates newest first, drops duplicates and anything not strictly after
+// from in feed order, and returns at most limit positions.
+func mergePositions(candidates []position, from position, limit int) []position {
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].after(candidates[j])
+	})
+
+	merged := make([]position, 0, limit)
+	seen := make(map[int]bool, len(candidates))
+	for _, candidate := range candidates {
+		if seen[candidate.PostID] || !from.after(candidate) {
+			continue
+		}
+		seen[candidate.PostID] = true
+
+		merged = append(merged, candidate)
+		if len(merged) == limit {
+			break
+		}
+	}
+	return merged
+}
+
+// orderPosts returns the posts in the order of ids, skipping ids whose post no longer exists.
+func orderPosts(posts []model.Post, ids []int) []model.Post {
+	byID := make(map[int]model.Post, len(posts))
+	for _, post := range posts {
+		byID[post.ID] = post
+	}
+
+	ordered := make([]model.Post, 0, len(ids))
+	for _, id := range ids {
+		if post, ok := byID[id]; ok {
+			ordered = append(ordered, post)
+		}
+	}
+	return ordered
+}
@@ -0,0 +1,261 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/temuka-api-service/internal/model"
+	"github.com/temuka-api-service/internal/repository"
+	"github.com/temuka-api-service/pkg/clock"
+)
+
+const (
+	DefaultTrendingLimit = 20
+	MaxTrendingLimit     = 100
+
+	trendingPostWindow      = 7 * 24 * time.Hour
+	trendingPostCandidates  = 5000
+	trendingCommunityWindow = 7 * 24 * time.Hour
+	// trendingRankingSize is how many items are kept per ranking, enough for the largest page.
+	trendingRankingSize = MaxTrendingLimit
+)
+
+// Trending scores follow the Hacker News formula: points / (age in hours + 2) ^ gravity. A higher
+// gravity makes older items sink faster.
+const (
+	trendingGravity = 1.8
+
+	trendingReactionPoints = 1.0
+	trendingCommentPoints  = 2.0
+	trendingViewPoints     = 0.1
+
+	trendingJoinPoints = 1.0
+	trendingPostPoints = 2.0
+)
+
+// Kinds of trending rankings.
+const (
+	TrendingKindPosts       = "posts"
+	TrendingKindCommunities = "communities"
+)
+
+const trendingScopeGlobal = "global"
+
+// TrendingFilter narrows trending lists to the communities of a university, or of every university
+// in a location. Without either the global list is used.
+type TrendingFilter struct {
+	UniversityID int
+	LocationID   int
+}
+
+func (f TrendingFilter) scope() string {
+	switch {
+	case f.UniversityID != 0:
+		return universityScope(f.UniversityID)
+	case f.LocationID != 0:
+		return locationScope(f.LocationID)
+	}
+	return trendingScopeGlobal
+}
+
+func universityScope(universityID int) string {
+	return fmt.Sprintf("university:%d", universityID)
+}
+
+func locationScope(locationID int) string {
+	return fmt.Sprintf("location:%d", locationID)
+}
+
+// TrendingService ranks posts by their time-decayed reactions, comments and views, and communities by
+// how quickly they gain members and posts. Rankings are recomputed in the background into Redis and
+// served from there.
+type TrendingService interface {
+	Recompute(ctx context.Context) error
+	GetTrendingPosts(ctx context.Context, filter TrendingFilter, limit int) ([]model.TrendingPost, error)
+	GetTrendingCommunities(ctx context.Context, filter TrendingFilter, limit int) ([]model.TrendingCommunity, error)
+}
+
+type TrendingServiceImpl struct {
+	TrendingRepository        repository.TrendingRepository
+	TrendingRankingRepository repository.TrendingRankingRepository
+	PostRepository            repository.PostRepository
+	CommunityRepository       repository.CommunityRepository
+	Clock                     clock.Clock
+}
+
+func NewTrendingService(trendingRepo repository.TrendingRepository, trendingRankingRepo repository.TrendingRankingRepository, postRepo repository.PostRepository, communityRepo repository.CommunityRepository, clk clock.Clock) TrendingService {
+	return &TrendingServiceImpl{
+		TrendingRepository:        trendingRepo,
+		TrendingRankingRepository: trendingRankingRepo,
+		PostRepository:            postRepo,
+		CommunityRepository:       communityRepo,
+		Clock:                     clk,
+	}
+}
+
+// Recompute scores recent posts and communities and replaces the stored rankings. Every item is
+// ranked globally as well as in the scopes of its university and location.
+func (s *TrendingServiceImpl) Recompute(ctx context.Context) error {
+	now := s.Clock.Now()
+
+	signals, err := s.TrendingRepository.GetPostSignals(ctx, now.Add(-trendingPostWindow), trendingPostCandidates)
+	if err != nil {
+		return err
+	}
+	postRankings := newRankings()
+	for _, signal := range signals {
+		points := trendingReactionPoints*float64(signal.Reactions) +
+			trendingCommentPoints*float64(signal.Comments) +
+			trendingViewPoints*float64(signal.Views)
+		postRankings.add(signal.PostID, gravityScore(points, now.Sub(signal.PublishedAt)), signal.UniversityID, signal.LocationID)
+	}
+	if err := s.TrendingRankingRepository.ReplaceRankings(ctx, TrendingKindPosts, postRankings.top()); err != nil {
+		return err
+	}
+
+	activity, err := s.TrendingRepository.GetCommunityActivity(ctx, now.Add(-trendingCommunityWindow))
+	if err != nil {
+		return err
+	}
+	// Each hour of activity decays on its own, so a community is ranked by its recent velocity rather
+	// than by how much happened in total over the window.
+	communityRankings := newRankings()
+	for _, hour := range activity {
+		points := trendingJoinPoints*float64(hour.Joins) + trendingPostPoints*float64(hour.Posts)
+		communityRankings.add(hour.CommunityID, gravityScore(points, now.Sub(hour.Hour)), hour.UniversityID, hour.LocationID)
+	}
+	return s.TrendingRankingRepository.ReplaceRankings(ctx, TrendingKindCommunities, communityRankings.top())
+}
+
+// GetTrendingPosts returns up to limit trending posts, best first. Posts deleted since the last
+// recompute are left out.
+func (s *TrendingServiceImpl) GetTrendingPosts(ctx context.Context, filter TrendingFilter, limit int) ([]model.TrendingPost, error) {
+	scores, err := s.TrendingRankingRepository.GetRanking(ctx, TrendingKindPosts, filter.scope(), int64(trendingLimit(limit)))
+	if err != nil {
+		return nil, err
+	}
+
+	posts, err := s.PostRepository.GetPostsByIDs(ctx, scoreIDs(scores))
+	if err != nil {
+		return nil, err
+	}
+	postsByID := make(map[int]model.Post, len(posts))
+	for _, post := range posts {
+		postsByID[post.ID] = post
+	}
+
+	trending := make([]model.TrendingPost, 0, len(scores))
+	for _, score := range scores {
+		if post, found := postsByID[score.ID]; found {
+			trending = append(trending, model.TrendingPost{Post: post, Score: round(score.Score)})
+		}
+	}
+	return trending, nil
+}
+
+// GetTrendingCommunities returns up to limit trending communities, best first. Communities deleted
+// since the last recompute are left out.
+func (s *TrendingServiceImpl) GetTrendingCommunities(ctx context.Context, filter TrendingFilter, limit int) ([]model.TrendingCommunity, error) {
+	scores, err := s.TrendingRankingRepository.GetRanking(ctx, TrendingKindCommunities, filter.scope(), int64(trendingLimit(limit)))
+	if err != nil {
+		return nil, err
+	}
+
+	communities, err := s.CommunityRepository.GetCommunitiesByIDs(ctx, scoreIDs(scores))
+	if err != nil {
+		return nil, err
+	}
+	communitiesByID := make(map[int]model.Community, len(communities))
+	for _, community := range communities {
+		communitiesByID[community.ID] = community
+	}
+
+	trending := make([]model.TrendingCommunity, 0, len(scores))
+	for _, score := range scores {
+		if community, found := communitiesByID[score.ID]; found {
+			trending = append(trending, model.TrendingCommunity{Community: community, Score: round(score.Score)})
+		}
+	}
+	return trending, nil
+}
+
+// gravityScore decays points by age. Items without points do not trend at all. The score keeps its
+// full precision, since old items score tiny values that still have to rank above nothing; it is only
+// rounded when shown.
+func gravityScore(points float64, age time.Duration) float64 {
+	if points <= 0 {
+		return 0
+	}
+	hours := math.Max(age.Hours(), 0)
+	return points / math.Pow(hours+2, trendingGravity)
+}
+
+func trendingLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultTrendingLimit
+	}
+	if limit > MaxTrendingLimit {
+		return MaxTrendingLimit
+	}
+	return limit
+}
+
+func scoreIDs(scores []model.TrendingScore) []int {
+	ids := make([]int, 0, len(scores))
+	for _, score := range scores {
+		ids = append(ids, score.ID)
+	}
+	return ids
+}
+
+// rankings sums the scores of items per scope.
+type rankings map[string]map[int]float64
+
+func newRankings() rankings {
+	return rankings{trendingScopeGlobal: {}}
+}
+
+func (r rankings) add(id int, score float64, universityID, locationID *int) {
+	if score <= 0 {
+		return
+	}
+
+	scopes := []string{trendingScopeGlobal}
+	if universityID != nil {
+		scopes = append(scopes, universityScope(*universityID))
+	}
+	if locationID != nil {
+		scopes = append(scopes, locationScope(*locationID))
+	}
+	for _, scope := range scopes {
+		if r[scope] == nil {
+			r[scope] = make(map[int]float64)
+		}
+		r[scope][id] += score
+	}
+}
+
+// top returns the best trendingRankingSize items of every scope.
+func (r rankings) top() map[string][]model.TrendingScore {
+	top := make(map[string][]model.TrendingScore, len(r))
+	for scope, scoresByID := range r {
+		scores := make([]model.TrendingScore, 0, len(scoresByID))
+		for id, score := range scoresByID {
+			scores = append(scores, model.TrendingScore{ID: id, Score: score})
+		}
+		sort.Slice(scores, func(i, j int) bool {
+			if scores[i].Score != scores[j].Score {
+				return scores[i].Score > scores[j].Score
+			}
+			return scores[i].ID < scores[j].ID
+		})
+		if len(scores) > trendingRankingSize {
+			scores = scores[:trendingRankingSize]
+		}
+		top[scope] = scores
+	}
+	return top
+}
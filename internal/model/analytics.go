@@ -0,0 +1,76 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Kinds of post views. Impressions are posts shown in a feed or list; detail views are posts opened.
+const (
+	ViewKindImpression = "impression"
+	ViewKindDetail     = "detail"
+)
+
+// AnalyticsDateFormat is the format of the days analytics are bucketed by, in UTC.
+const AnalyticsDateFormat = "2006-01-02"
+
+// PostViewStat holds the views of a post of one kind on one day. A user counts once per day, so Views
+// is the number of distinct viewers that day. Viewers is the HyperLogLog those were counted with,
+// which allows counting distinct viewers across days and posts.
+type PostViewStat struct {
+	gorm.Model
+	ID        int       `gorm:"primary_key;column:id"`
+	PostID    int       `gorm:"column:post_id;uniqueIndex:idx_post_view_stat_post_kind_day"`
+	AuthorID  int       `gorm:"column:author_id;index"`
+	Kind      string    `gorm:"column:kind;uniqueIndex:idx_post_view_stat_post_kind_day"`
+	Day       time.Time `gorm:"column:day;type:date;uniqueIndex:idx_post_view_stat_post_kind_day;index"`
+	Views     int64     `gorm:"column:views"`
+	Viewers   []byte    `gorm:"column:viewers;type:bytea" json:"-"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"`
+}
+
+func (s *PostViewStat) TableName() string {
+	return "post_view_stats"
+}
+
+// AnalyticsCounts are the reach and engagement numbers of a post or an author.
+type AnalyticsCounts struct {
+	Impressions   int64 `json:"impressions"`
+	DetailViews   int64 `json:"detail_views"`
+	UniqueViewers int64 `json:"unique_viewers"`
+	Reactions     int64 `json:"reactions"`
+	Comments      int64 `json:"comments"`
+}
+
+type DailyAnalytics struct {
+	Date string `json:"date"`
+	AnalyticsCounts
+}
+
+// Analytics covers the days from From to To, both included. The unique viewers of the totals are the
+// distinct viewers over the whole range, not the sum of the daily ones.
+type Analytics struct {
+	From   string           `json:"from"`
+	To     string           `json:"to"`
+	Totals AnalyticsCounts  `json:"totals"`
+	Daily  []DailyAnalytics `json:"daily"`
+}
+
+// AuthorViewStat holds the HyperLogLog of everyone who viewed a post of the author on one day, in
+// either kind of view. Author analytics count distinct viewers from these, one sketch per day,
+// instead of from the sketches of every post.
+type AuthorViewStat struct {
+	gorm.Model
+	ID        int       `gorm:"primary_key;column:id"`
+	AuthorID  int       `gorm:"column:author_id;uniqueIndex:idx_author_view_stat_author_day"`
+	Day       time.Time `gorm:"column:day;type:date;uniqueIndex:idx_author_view_stat_author_day"`
+	Viewers   []byte    `gorm:"column:viewers;type:bytea" json:"-"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"`
+}
+
+func (s *AuthorViewStat) TableName() string {
+	return "author_view_stats"
+}
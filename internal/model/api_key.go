@@ -0,0 +1,28 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APIKey is a user-owned credential for scripts and bots. Only a hash of the key is stored;
+// Prefix is kept in clear text so users can tell their keys apart.
+type APIKey struct {
+	gorm.Model
+	ID         int        `gorm:"primary_key;column:id"`
+	UserID     int        `gorm:"column:user_id;index"`
+	Name       string     `gorm:"column:name"`
+	Prefix     string     `gorm:"column:prefix"`
+	KeyHash    string     `gorm:"column:key_hash;uniqueIndex" json:"-"`
+	Scopes     string     `gorm:"column:scopes"`
+	LastUsedAt *time.Time `gorm:"column:last_used_at;default:null"`
+	ExpiresAt  *time.Time `gorm:"column:expires_at;default:null"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at;default:null"`
+	CreatedAt  time.Time  `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt  time.Time  `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"`
+}
+
+func (a *APIKey) TableName() string {
+	return "api_keys"
+}
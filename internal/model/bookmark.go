@@ -0,0 +1,49 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	BookmarkTargetPost       = "post"
+	BookmarkTargetUniversity = "university"
+	BookmarkTargetCommunity  = "community"
+)
+
+// BookmarkCollection is a named, private group of a user's bookmarks.
+type BookmarkCollection struct {
+	gorm.Model
+	ID        int       `gorm:"primary_key;column:id"`
+	UserID    int       `gorm:"column:user_id;uniqueIndex:idx_bookmark_collection_user_name"`
+	Name      string    `gorm:"column:name;uniqueIndex:idx_bookmark_collection_user_name"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"`
+}
+
+func (c *BookmarkCollection) TableName() string {
+	return "bookmark_collections"
+}
+
+// Bookmark saves exactly one of a post, a university or a community for later. Bookmarks without a
+// collection are unsorted. Position orders the bookmarks within their collection.
+type Bookmark struct {
+	gorm.Model
+	ID           int         `gorm:"primary_key;column:id"`
+	UserID       int         `gorm:"column:user_id;index;uniqueIndex:idx_bookmark_user_post;uniqueIndex:idx_bookmark_user_university;uniqueIndex:idx_bookmark_user_community"`
+	CollectionID *int        `gorm:"column:collection_id;index;default:null"`
+	PostID       *int        `gorm:"column:post_id;uniqueIndex:idx_bookmark_user_post;default:null"`
+	UniversityID *int        `gorm:"column:university_id;uniqueIndex:idx_bookmark_user_university;default:null"`
+	CommunityID  *int        `gorm:"column:community_id;uniqueIndex:idx_bookmark_user_community;default:null"`
+	Position     int         `gorm:"column:position"`
+	Post         *Post       `gorm:"foreignKey:PostID"`
+	University   *University `gorm:"foreignKey:UniversityID"`
+	Community    *Community  `gorm:"foreignKey:CommunityID"`
+	CreatedAt    time.Time   `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt    time.Time   `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"`
+}
+
+func (b *Bookmark) TableName() string {
+	return "bookmarks"
+}
@@ -16,7 +16,8 @@ type Comment struct {
 	Replies       []Comment      `gorm:"foreignKey:ParentID;references:ID"`
 	Parent        *Comment       `gorm:"foreignKey:ParentID;references:ID"`
 	Votes         []*User        `gorm:"many2many:user_votes;"`
-	Notifications []Notification `gorm:foreignKey:CommentID`
+	Notifications []Notification `gorm:"foreignKey:CommentID"`
+	Entities      []TextEntity   `gorm:"-" json:"entities"`
 	CreatedAt     time.Time      `gorm:"column:created_at;autoCreateTime"`
 	UpdatedAt     time.Time      `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"`
 }
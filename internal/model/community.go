@@ -11,6 +11,8 @@ type Community struct {
 	ID               int               `gorm:"primary_key;column:id"`
 	Name             string            `gorm:"column:name"`
 	Slug             string            `gorm:"column:slug"`
+	UserID           int               `gorm:"column:user_id"`
+	UniversityID     *int              `gorm:"column:university_id;index;default:null"`
 	Description      string            `gorm:"column:desc"`
 	Rules            string            `gorm:"column:rules"`
 	MembersCount     int               `gorm:"column:members_count"`
@@ -0,0 +1,49 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DataExportStatusPending    = "pending"
+	DataExportStatusProcessing = "processing"
+	DataExportStatusCompleted  = "completed"
+	DataExportStatusFailed     = "failed"
+)
+
+type DataExport struct {
+	gorm.Model
+	ID          int        `gorm:"primary_key;column:id"`
+	UserID      int        `gorm:"column:user_id;index"`
+	Status      string     `gorm:"column:status;default:pending;index"`
+	FilePath    string     `gorm:"column:file_path" json:"-"`
+	Error       string     `gorm:"column:error"`
+	ClaimedAt   *time.Time `gorm:"column:claimed_at;default:null" json:"-"`
+	CompletedAt *time.Time `gorm:"column:completed_at;default:null"`
+	ExpiresAt   *time.Time `gorm:"column:expires_at;default:null"`
+	CreatedAt   time.Time  `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt   time.Time  `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"`
+}
+
+func (d *DataExport) TableName() string {
+	return "data_exports"
+}
+
+// UserDataSnapshot is everything a user authored or received, as bundled into a data export archive.
+type UserDataSnapshot struct {
+	Profile             User                 `json:"profile"`
+	Posts               []Post               `json:"posts"`
+	Comments            []Comment            `json:"comments"`
+	Reviews             []Review             `json:"reviews"`
+	Reactions           []PostReaction       `json:"reactions"`
+	Bookmarks           []Bookmark           `json:"bookmarks"`
+	BookmarkCollections []BookmarkCollection `json:"bookmark_collections"`
+	PollVotes           []PollVote           `json:"poll_votes"`
+	Media               []Media              `json:"media"`
+	Messages            []Message            `json:"messages"`
+	Followers           []UserFollow         `json:"followers"`
+	Followings          []UserFollow         `json:"followings"`
+	Notifications       []Notification       `json:"notifications"`
+}
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Hashtag is a tag used in at least one post or comment. Names are stored lower-cased without the #.
+type Hashtag struct {
+	gorm.Model
+	ID        int       `gorm:"primary_key;column:id"`
+	Name      string    `gorm:"column:name;uniqueIndex"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"`
+}
+
+func (h *Hashtag) TableName() string {
+	return "hashtags"
+}
+
+type PostHashtag struct {
+	gorm.Model
+	ID        int       `gorm:"primary_key;column:id"`
+	PostID    int       `gorm:"column:post_id;uniqueIndex:idx_post_hashtag_post_hashtag"`
+	HashtagID int       `gorm:"column:hashtag_id;uniqueIndex:idx_post_hashtag_post_hashtag;index"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime;index"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"`
+}
+
+func (h *PostHashtag) TableName() string {
+	return "post_hashtags"
+}
+
+type CommentHashtag struct {
+	gorm.Model
+	ID        int       `gorm:"primary_key;column:id"`
+	CommentID int       `gorm:"column:comment_id;uniqueIndex:idx_comment_hashtag_comment_hashtag"`
+	HashtagID int       `gorm:"column:hashtag_id;uniqueIndex:idx_comment_hashtag_comment_hashtag;index"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime;index"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"`
+}
+
+func (h *CommentHashtag) TableName() string {
+	return "comment_hashtags"
+}
+
+// TrendingHashtag counts the uses of a tag in the current window and in the window before it.
+type TrendingHashtag struct {
+	Tag           string `json:"tag"`
+	Count         int64  `json:"count"`
+	PreviousCount int64  `json:"previous_count"`
+}
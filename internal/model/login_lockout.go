@@ -0,0 +1,24 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type LoginLockout struct {
+	gorm.Model
+	ID             int       `gorm:"primary_key;column:id"`
+	Scope          string    `gorm:"column:scope;index"`
+	Identifier     string    `gorm:"column:identifier;index"`
+	UserID         *int      `gorm:"column:user_id;default:null"`
+	IPAddress      string    `gorm:"column:ip_address"`
+	FailedAttempts int       `gorm:"column:failed_attempts"`
+	LockedUntil    time.Time `gorm:"column:locked_until"`
+	CreatedAt      time.Time `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt      time.Time `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"`
+}
+
+func (l *LoginLockout) TableName() string {
+	return "login_lockouts"
+}
@@ -11,8 +11,8 @@ type MajorReview struct {
 	ID        int       `gorm:"primary_key;column:id"`
 	UserID    int       `gorm:"column:user_id"`
 	MajorID   int       `gorm:"column:major_id"`
-	Text      string    `gorm:column:text`
-	Stars     int       `gorm:column:stars`
+	Text      string    `gorm:"column:text"`
+	Stars     int       `gorm:"column:stars"`
 	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`
 	UpdatedAt time.Time `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"`
 }
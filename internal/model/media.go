@@ -0,0 +1,49 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	MaxPostMedia     = 10
+	MaxAltTextLength = 1000
+)
+
+// Media is a file a user uploaded. Width, height and the thumbnail are only known for images.
+type Media struct {
+	gorm.Model
+	ID           int       `gorm:"primary_key;column:id" json:"id"`
+	UserID       int       `gorm:"column:user_id;index" json:"user_id"`
+	StorageKey   string    `gorm:"column:storage_key" json:"-"`
+	URL          string    `gorm:"column:url" json:"url"`
+	MimeType     string    `gorm:"column:mime_type" json:"mime_type"`
+	Size         int64     `gorm:"column:size" json:"size"`
+	Width        int       `gorm:"column:width" json:"width,omitempty"`
+	Height       int       `gorm:"column:height" json:"height,omitempty"`
+	ThumbnailURL string    `gorm:"column:thumbnail_url" json:"thumbnail_url,omitempty"`
+	CreatedAt    time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time `gorm:"column:updated_at;autoCreateTime;autoUpdateTime" json:"updated_at"`
+}
+
+func (m *Media) TableName() string {
+	return "media"
+}
+
+// PostMedia attaches uploaded media to a post, in the order given by Position.
+type PostMedia struct {
+	gorm.Model
+	ID        int       `gorm:"primary_key;column:id" json:"-"`
+	PostID    int       `gorm:"column:post_id;uniqueIndex:idx_post_media_post_media" json:"-"`
+	MediaID   int       `gorm:"column:media_id;uniqueIndex:idx_post_media_post_media;index" json:"media_id"`
+	Position  int       `gorm:"column:position" json:"position"`
+	AltText   string    `gorm:"column:alt_text" json:"alt_text"`
+	Media     *Media    `gorm:"foreignKey:MediaID" json:"media,omitempty"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime" json:"-"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoCreateTime;autoUpdateTime" json:"-"`
+}
+
+func (m *PostMedia) TableName() string {
+	return "post_media"
+}
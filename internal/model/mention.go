@@ -0,0 +1,42 @@
+package model
+
+import (
+	"time"
+
+	"github.com/temuka-api-service/pkg/textproc"
+	"gorm.io/gorm"
+)
+
+type PostMention struct {
+	gorm.Model
+	ID        int       `gorm:"primary_key;column:id"`
+	PostID    int       `gorm:"column:post_id;uniqueIndex:idx_post_mention_post_user"`
+	UserID    int       `gorm:"column:user_id;uniqueIndex:idx_post_mention_post_user;index"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"`
+}
+
+func (m *PostMention) TableName() string {
+	return "post_mentions"
+}
+
+type CommentMention struct {
+	gorm.Model
+	ID        int       `gorm:"primary_key;column:id"`
+	CommentID int       `gorm:"column:comment_id;uniqueIndex:idx_comment_mention_comment_user"`
+	UserID    int       `gorm:"column:user_id;uniqueIndex:idx_comment_mention_comment_user;index"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"`
+}
+
+func (m *CommentMention) TableName() string {
+	return "comment_mentions"
+}
+
+// TextEntity is a hashtag or mention in one of the text fields of a post or comment. UserID is set
+// on mentions of existing users; mentions of unknown usernames are plain text.
+type TextEntity struct {
+	Field string `json:"field"`
+	textproc.Entity
+	UserID int `json:"user_id,omitempty"`
+}
@@ -0,0 +1,8 @@
+package model
+
+// OIDCAuthState is stored in Redis between the authorization redirect and the provider callback.
+type OIDCAuthState struct {
+	Provider     string `json:"provider"`
+	CodeVerifier string `json:"code_verifier"`
+	Nonce        string `json:"nonce"`
+}
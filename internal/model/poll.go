@@ -0,0 +1,79 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	MinPollOptions      = 2
+	MaxPollOptions      = 10
+	MaxPollOptionLength = 100
+)
+
+// Poll is attached to a post, whose title is the question. Votes of anonymous polls are only
+// counted; the voters of public polls can be listed per option.
+type Poll struct {
+	gorm.Model
+	ID             int          `gorm:"primary_key;column:id"`
+	PostID         int          `gorm:"column:post_id;uniqueIndex"`
+	MultipleChoice bool         `gorm:"column:multiple_choice;default:false"`
+	Anonymous      bool         `gorm:"column:anonymous;default:false"`
+	ClosesAt       *time.Time   `gorm:"column:closes_at;default:null;index"`
+	ClosedAt       *time.Time   `gorm:"column:closed_at;default:null"`
+	Options        []PollOption `gorm:"foreignKey:PollID"`
+	TotalVoters    int64        `gorm:"-" json:"total_voters"`
+	ViewerVotes    []int        `gorm:"-" json:"viewer_votes"`
+	CreatedAt      time.Time    `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt      time.Time    `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"`
+}
+
+func (p *Poll) TableName() string {
+	return "polls"
+}
+
+// IsClosed reports whether the poll stopped taking votes at now. A poll closes at its close time even
+// before the worker that notifies its author has processed it.
+func (p *Poll) IsClosed(now time.Time) bool {
+	return p.ClosedAt != nil || (p.ClosesAt != nil && !now.Before(*p.ClosesAt))
+}
+
+type PollOption struct {
+	gorm.Model
+	ID        int       `gorm:"primary_key;column:id"`
+	PollID    int       `gorm:"column:poll_id;index"`
+	Position  int       `gorm:"column:position"`
+	Text      string    `gorm:"column:text"`
+	Votes     int64     `gorm:"-" json:"votes"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"`
+}
+
+func (o *PollOption) TableName() string {
+	return "poll_options"
+}
+
+// PollVote is a user's vote for one option. Multiple choice polls get one row per chosen option.
+type PollVote struct {
+	gorm.Model
+	ID        int       `gorm:"primary_key;column:id"`
+	PollID    int       `gorm:"column:poll_id;index:idx_poll_vote_poll_user"`
+	OptionID  int       `gorm:"column:option_id;uniqueIndex:idx_poll_vote_option_user"`
+	UserID    int       `gorm:"column:user_id;uniqueIndex:idx_poll_vote_option_user;index:idx_poll_vote_poll_user"`
+	User      User      `gorm:"foreignKey:UserID" json:"-"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"`
+}
+
+func (v *PollVote) TableName() string {
+	return "poll_votes"
+}
+
+// PollResults are the vote counts of a poll as pushed to clients watching it live.
+type PollResults struct {
+	PollID      int           `json:"poll_id"`
+	Closed      bool          `json:"closed"`
+	TotalVoters int64         `json:"total_voters"`
+	Options     map[int]int64 `json:"options"`
+}
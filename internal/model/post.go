@@ -8,17 +8,65 @@ import (
 
 type Post struct {
 	gorm.Model
-	ID             int             `gorm:"primary_key;column:id"`
-	UserID         int             `gorm:"column:user_id"`
-	Title          string          `gorm:"column:title"`
-	Description    string          `gorm:"column:desc"`
-	Image          string          `gorm:"column:image"`
-	Likes          []*User         `gorm:"many2many:post_likes;"`
-	Comments       []Comment       `gorm:"foreignKey:PostID"`
-	CommunityPosts []CommunityPost `gorm:"foreignKey:PostID"`
-	Notification   []Notification  `gorm:"foreignKey:PostID"`
-	CreatedAt      time.Time       `gorm:"column:created_at;autoCreateTime"`
-	UpdatedAt      time.Time       `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"`
+	ID             int              `gorm:"primary_key;column:id"`
+	UserID         int              `gorm:"column:user_id"`
+	CommunityID    *int             `gorm:"column:community_id;index;default:null"`
+	OriginalPostID *int             `gorm:"column:original_post_id;index;default:null"`
+	ShareType      string           `gorm:"column:share_type"`
+	SharesCount    int              `gorm:"column:shares_count;default:0"`
+	Status         string           `gorm:"column:status;default:published;index"`
+	PublishAt      *time.Time       `gorm:"column:publish_at;default:null;index"`
+	PublishedAt    *time.Time       `gorm:"column:published_at;default:null;index"`
+	Edited         bool             `gorm:"column:edited;default:false" json:"edited"`
+	EditedAt       *time.Time       `gorm:"column:edited_at;default:null" json:"edited_at,omitempty"`
+	Title          string           `gorm:"column:title"`
+	Description    string           `gorm:"column:desc"`
+	Image          string           `gorm:"column:image"`
+	Media          []PostMedia      `gorm:"foreignKey:PostID" json:"media"`
+	Reactions      []PostReaction   `gorm:"foreignKey:PostID" json:"-"`
+	ReactionCounts map[string]int64 `gorm:"-" json:"reaction_counts"`
+	ViewerReaction string           `gorm:"-" json:"viewer_reaction,omitempty"`
+	Bookmarked     bool             `gorm:"-" json:"bookmarked"`
+	Original       *SharedPost      `gorm:"-" json:"original,omitempty"`
+	Entities       []TextEntity     `gorm:"-" json:"entities"`
+	Poll           *Poll            `gorm:"foreignKey:PostID" json:"poll,omitempty"`
+	Comments       []Comment        `gorm:"foreignKey:PostID"`
+	CommunityPosts []CommunityPost  `gorm:"foreignKey:PostID"`
+	Notification   []Notification   `gorm:"foreignKey:PostID"`
+	CreatedAt      time.Time        `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt      time.Time        `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"`
+}
+
+// Post statuses. Drafts and scheduled posts are only visible to their author. A post is publishing
+// while the instance that took it live runs its side effects; it counts as published once they are done.
+const (
+	PostStatusDraft      = "draft"
+	PostStatusScheduled  = "scheduled"
+	PostStatusPublishing = "publishing"
+	PostStatusPublished  = "published"
+)
+
+// LiveAt is when the post went live. Posts written before publishing could be scheduled fall back to
+// their creation time.
+func (p *Post) LiveAt() time.Time {
+	if p.PublishedAt != nil {
+		return *p.PublishedAt
+	}
+	return p.CreatedAt
+}
+
+// ShareType values of reposts and quote posts. Original posts have no share type.
+const (
+	ShareTypeRepost = "repost"
+	ShareTypeQuote  = "quote"
+)
+
+// SharedPost is the original a repost or quote post points to. Once the original is deleted only a
+// tombstone with its id remains.
+type SharedPost struct {
+	ID      int   `json:"id"`
+	Deleted bool  `json:"deleted"`
+	Post    *Post `json:"post,omitempty"`
 }
 
 func (p *Post) TableName() string {
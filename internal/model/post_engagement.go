@@ -0,0 +1,10 @@
+package model
+
+// PostEngagement holds the interaction counts the recommendation feed scores a post by. Reactions
+// counts reactions of every type.
+type PostEngagement struct {
+	PostID         int
+	Reactions      int64
+	Comments       int64
+	RecentComments int64
+}
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	ReactionLike       = "like"
+	ReactionInsightful = "insightful"
+	ReactionFunny      = "funny"
+	ReactionSupport    = "support"
+)
+
+var ReactionTypes = []string{ReactionLike, ReactionInsightful, ReactionFunny, ReactionSupport}
+
+func IsValidReactionType(reactionType string) bool {
+	for _, t := range ReactionTypes {
+		if t == reactionType {
+			return true
+		}
+	}
+	return false
+}
+
+// PostReaction is a user's reaction to a post. A user has at most one reaction per post.
+type PostReaction struct {
+	gorm.Model
+	ID        int       `gorm:"primary_key;column:id"`
+	PostID    int       `gorm:"column:post_id;uniqueIndex:idx_post_reaction_post_user"`
+	UserID    int       `gorm:"column:user_id;uniqueIndex:idx_post_reaction_post_user;index"`
+	Type      string    `gorm:"column:type"`
+	User      User      `gorm:"foreignKey:UserID" json:"-"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"`
+}
+
+func (r *PostReaction) TableName() string {
+	return "post_reactions"
+}
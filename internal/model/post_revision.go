@@ -0,0 +1,28 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PostRevision records one edit of a published post with the title and description before and after it.
+// Reverts are revisions too; RevertedFromID points at the revision whose old text was restored.
+type PostRevision struct {
+	gorm.Model
+	ID             int       `gorm:"primary_key;column:id"`
+	PostID         int       `gorm:"column:post_id;index"`
+	EditorID       int       `gorm:"column:editor_id;index"`
+	OldTitle       string    `gorm:"column:old_title"`
+	NewTitle       string    `gorm:"column:new_title"`
+	OldDescription string    `gorm:"column:old_desc"`
+	NewDescription string    `gorm:"column:new_desc"`
+	RevertedFromID *int      `gorm:"column:reverted_from_id;default:null"`
+	Editor         User      `gorm:"foreignKey:EditorID" json:"-"`
+	CreatedAt      time.Time `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt      time.Time `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"`
+}
+
+func (r *PostRevision) TableName() string {
+	return "post_revisions"
+}
@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// PublicUser is the part of a user profile that is safe to show to other users.
+type PublicUser struct {
+	ID             int       `json:"id"`
+	Username       string    `json:"username"`
+	Displayname    string    `json:"displayname"`
+	ProfilePicture string    `json:"profile_picture"`
+	CoverPicture   string    `json:"cover_picture"`
+	Description    string    `json:"description"`
+	Country        string    `json:"country"`
+	SocialPoint    int       `json:"social_point"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func (u *User) Public() PublicUser {
+	return PublicUser{
+		ID:             u.ID,
+		Username:       u.Username,
+		Displayname:    u.Displayname,
+		ProfilePicture: u.ProfilePicture,
+		CoverPicture:   u.CoverPicture,
+		Description:    u.Desc,
+		Country:        u.Country,
+		SocialPoint:    u.SocialPoint,
+		CreatedAt:      u.CreatedAt,
+	}
+}
+
+// UserSearchResult is a user matched by a search query together with its relevance.
+type UserSearchResult struct {
+	User
+	Rank float64 `gorm:"column:rank"`
+}
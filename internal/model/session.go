@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// Session is stored in Redis rather than Postgres; one exists per logged-in device.
+type Session struct {
+	ID               string    `json:"id"`
+	UserID           int       `json:"user_id"`
+	RefreshTokenHash string    `json:"-"`
+	UserAgent        string    `json:"user_agent"`
+	IPAddress        string    `json:"ip_address"`
+	CreatedAt        time.Time `json:"created_at"`
+	LastUsedAt       time.Time `json:"last_used_at"`
+}
@@ -0,0 +1,7 @@
+package model
+
+// TimelineEntry is a post reference in a user's Redis timeline, scored by the post's creation time in milliseconds.
+type TimelineEntry struct {
+	PostID int
+	Score  float64
+}
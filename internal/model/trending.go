@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+// TrendingScore is the rank of a post or community in a trending list.
+type TrendingScore struct {
+	ID    int     `json:"id"`
+	Score float64 `json:"score"`
+}
+
+// TrendingPostSignals are the interaction counts a post's trending score is computed from. The
+// university and location are those of the post's community, if any.
+type TrendingPostSignals struct {
+	PostID       int
+	PublishedAt  time.Time
+	UniversityID *int
+	LocationID   *int
+	Reactions    int64
+	Comments     int64
+	Views        int64
+}
+
+// TrendingCommunityActivity counts the joins of and posts in a community during one hour.
+type TrendingCommunityActivity struct {
+	CommunityID  int
+	UniversityID *int
+	LocationID   *int
+	Hour         time.Time
+	Joins        int64
+	Posts        int64
+}
+
+type TrendingPost struct {
+	Post  Post    `json:"post"`
+	Score float64 `json:"score"`
+}
+
+type TrendingCommunity struct {
+	Community Community `json:"community"`
+	Score     float64   `json:"score"`
+}
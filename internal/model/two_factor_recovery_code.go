@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type TwoFactorRecoveryCode struct {
+	gorm.Model
+	ID        int        `gorm:"primary_key;column:id"`
+	UserID    int        `gorm:"column:user_id;index"`
+	CodeHash  string     `gorm:"column:code_hash"`
+	UsedAt    *time.Time `gorm:"column:used_at;default:null"`
+	CreatedAt time.Time  `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt time.Time  `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"`
+}
+
+func (t *TwoFactorRecoveryCode) TableName() string {
+	return "two_factor_recovery_codes"
+}
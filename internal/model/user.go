@@ -8,27 +8,35 @@ import (
 
 type User struct {
 	gorm.Model
-	ID               int               `gorm:"primary_key;column:id"`
-	Username         string            `gorm:"column:username"`
-	Displayname      string            `gorm:"column:displayname"`
-	Email            string            `gorm:"column:email"`
-	Password         string            `gorm:"column:password"`
-	ProfilePicture   string            `gorm:"column:profile_picture"`
-	CoverPicture     string            `gorm:"column:cover_picture"`
-	Followers        []UserFollow      `gorm:"foreignKey:FollowerID"`
-	Followings       []UserFollow      `gorm:"foreignKey:FollowingID"`
-	SocialPoint      int               `gorm:"column:social_point"`
-	Desc             string            `gorm:"column:description"`
-	Country          string            `gorm:"column:country"`
-	CreatedAt        time.Time         `gorm:"column:created_at;autoCreateTime"`
-	UpdatedAt        time.Time         `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"`
-	Posts            []Post            `gorm:"foreignKey:UserID"`
-	Comments         []Comment         `gorm:"foreignKey:UserID"`
-	CommunityMembers []CommunityMember `gorm:"foreignKey:UserID"`
-	Conversations    []Conversation    `gorm:"foreignKey:UserID"`
-	Participants     []Participant     `gorm:"foreignKey:UserID"`
-	Notifications    []Notification    `gorm:"foreignKey:UserID"`
-	Reviews          []Review          `gorm:"foreignKey:UserID"`
+	ID                  int               `gorm:"primary_key;column:id"`
+	Username            string            `gorm:"column:username"`
+	Displayname         string            `gorm:"column:displayname"`
+	Email               string            `gorm:"column:email"`
+	EmailVerified       bool              `gorm:"column:email_verified;default:false"`
+	EmailVerifiedAt     *time.Time        `gorm:"column:email_verified_at;default:null"`
+	Password            string            `gorm:"column:password" json:"-"`
+	Role                string            `gorm:"column:role;default:user"`
+	TwoFactorEnabled    bool              `gorm:"column:two_factor_enabled;default:false"`
+	TwoFactorSecret     string            `gorm:"column:two_factor_secret" json:"-"`
+	TwoFactorStep       int64             `gorm:"column:two_factor_last_step;default:0" json:"-"`
+	DeletionScheduledAt *time.Time        `gorm:"column:deletion_scheduled_at;default:null"`
+	AnonymizedAt        *time.Time        `gorm:"column:anonymized_at;default:null"`
+	ProfilePicture      string            `gorm:"column:profile_picture"`
+	CoverPicture        string            `gorm:"column:cover_picture"`
+	Followers           []UserFollow      `gorm:"foreignKey:FollowerID"`
+	Followings          []UserFollow      `gorm:"foreignKey:FollowingID"`
+	SocialPoint         int               `gorm:"column:social_point"`
+	Desc                string            `gorm:"column:description"`
+	Country             string            `gorm:"column:country"`
+	CreatedAt           time.Time         `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt           time.Time         `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"`
+	Posts               []Post            `gorm:"foreignKey:UserID"`
+	Comments            []Comment         `gorm:"foreignKey:UserID"`
+	CommunityMembers    []CommunityMember `gorm:"foreignKey:UserID"`
+	Conversations       []Conversation    `gorm:"foreignKey:UserID"`
+	Participants        []Participant     `gorm:"foreignKey:UserID"`
+	Notifications       []Notification    `gorm:"foreignKey:UserID"`
+	Reviews             []Review          `gorm:"foreignKey:UserID"`
 }
 
 func (u *User) TableName() string {
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UserIdentity links a user to an account at an external OpenID Connect provider.
+type UserIdentity struct {
+	gorm.Model
+	ID        int       `gorm:"primary_key;column:id"`
+	UserID    int       `gorm:"column:user_id;index"`
+	Provider  string    `gorm:"column:provider;uniqueIndex:idx_user_identity_provider_subject"`
+	Subject   string    `gorm:"column:subject;uniqueIndex:idx_user_identity_provider_subject"`
+	Email     string    `gorm:"column:email"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoCreateTime;autoUpdateTime"`
+}
+
+func (u *UserIdentity) TableName() string {
+	return "user_identities"
+}
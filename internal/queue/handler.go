@@ -16,7 +16,7 @@ func subscribeToQueue(ctx context.Context, queueName string) {
 	log.Printf("Subscribing to queue: %s", queueName)
 
 	handler := func(msg *redis.Message) {
-		log.Printf("Received message from %s: UserID=%s, Action")
+		log.Printf("Received message from %s: UserID=%s, Action=%s", queueName, msg.UserID, msg.Action)
 	}
 
 	redis.Subscribe(ctx, queueName, handler)
@@ -0,0 +1,166 @@
+package rbac
+
+import (
+	"context"
+
+	"github.com/temuka-api-service/internal/repository"
+)
+
+// Global roles are stored on model.User.Role and copied into the access token.
+const (
+	RoleAdmin            = "admin"
+	RoleUniversityEditor = "university_editor"
+	RoleUser             = "user"
+)
+
+// Community roles are derived from model.Community, model.Moderator and model.CommunityMember.
+const (
+	CommunityRoleOwner     = "owner"
+	CommunityRoleModerator = "moderator"
+	CommunityRoleMember    = "member"
+	CommunityRoleNone      = ""
+)
+
+type Permission string
+
+const (
+	PermissionManageUniversities Permission = "university:manage"
+	PermissionManageLocations    Permission = "location:manage"
+	PermissionManageReports      Permission = "report:manage"
+	PermissionManageRoles        Permission = "role:manage"
+	PermissionViewSecurityEvents Permission = "security:view"
+
+	PermissionUpdateCommunity   Permission = "community:update"
+	PermissionDeleteCommunity   Permission = "community:delete"
+	PermissionManageModerators  Permission = "community:moderators"
+	PermissionModerateCommunity Permission = "community:moderate"
+	PermissionPostInCommunity   Permission = "community:post"
+)
+
+// API key scopes. A read key may only be used for safe methods, a write key for everything.
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+)
+
+func IsValidScope(scope string) bool {
+	return scope == ScopeRead || scope == ScopeWrite
+}
+
+var globalRolePermissions = map[string][]Permission{
+	RoleUniversityEditor: {
+		PermissionManageUniversities,
+		PermissionManageLocations,
+	},
+	RoleUser: {},
+}
+
+var communityRolePermissions = map[string][]Permission{
+	CommunityRoleOwner: {
+		PermissionUpdateCommunity,
+		PermissionDeleteCommunity,
+		PermissionManageModerators,
+		PermissionModerateCommunity,
+		PermissionPostInCommunity,
+	},
+	CommunityRoleModerator: {
+		PermissionUpdateCommunity,
+		PermissionModerateCommunity,
+		PermissionPostInCommunity,
+	},
+	CommunityRoleMember: {
+		PermissionPostInCommunity,
+	},
+}
+
+func IsValidRole(role string) bool {
+	return role == RoleAdmin || role == RoleUniversityEditor || role == RoleUser
+}
+
+// GlobalRoles returns the roles of a user with the given stored role. Users stored before roles
+// existed have none and are plain users.
+func GlobalRoles(role string) []string {
+	if role == "" {
+		role = RoleUser
+	}
+	return []string{role}
+}
+
+func hasPermission(permissions []Permission, permission Permission) bool {
+	for _, p := range permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// HasGlobalPermission reports whether any of the global roles grants the permission. Admins are granted everything.
+func HasGlobalPermission(roles []string, permission Permission) bool {
+	for _, role := range roles {
+		if role == RoleAdmin || hasPermission(globalRolePermissions[role], permission) {
+			return true
+		}
+	}
+	return false
+}
+
+type Authorizer interface {
+	GetCommunityRole(ctx context.Context, communityID, userID int) (string, error)
+	HasCommunityPermission(ctx context.Context, roles []string, userID, communityID int, permission Permission) (bool, error)
+}
+
+type AuthorizerImpl struct {
+	CommunityRepository repository.CommunityRepository
+	ModeratorRepository repository.ModeratorRepository
+}
+
+func NewAuthorizer(communityRepo repository.CommunityRepository, moderatorRepo repository.ModeratorRepository) Authorizer {
+	return &AuthorizerImpl{
+		CommunityRepository: communityRepo,
+		ModeratorRepository: moderatorRepo,
+	}
+}
+
+func (a *AuthorizerImpl) GetCommunityRole(ctx context.Context, communityID, userID int) (string, error) {
+	community, err := a.CommunityRepository.GetCommunityDetailByID(ctx, communityID)
+	if err != nil {
+		return CommunityRoleNone, err
+	}
+	if community.UserID == userID {
+		return CommunityRoleOwner, nil
+	}
+
+	isModerator, err := a.ModeratorRepository.IsCommunityModerator(ctx, communityID, userID)
+	if err != nil {
+		return CommunityRoleNone, err
+	}
+	if isModerator {
+		return CommunityRoleModerator, nil
+	}
+
+	member, err := a.CommunityRepository.CheckMembership(ctx, communityID, userID)
+	if err != nil {
+		return CommunityRoleNone, err
+	}
+	if member != nil && !member.Banned {
+		return CommunityRoleMember, nil
+	}
+
+	return CommunityRoleNone, nil
+}
+
+func (a *AuthorizerImpl) HasCommunityPermission(ctx context.Context, roles []string, userID, communityID int, permission Permission) (bool, error) {
+	for _, role := range roles {
+		if role == RoleAdmin {
+			return true, nil
+		}
+	}
+
+	communityRole, err := a.GetCommunityRole(ctx, communityID, userID)
+	if err != nil {
+		return false, err
+	}
+
+	return hasPermission(communityRolePermissions[communityRole], permission), nil
+}
@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/temuka-api-service/internal/model"
+	"gorm.io/gorm"
+)
+
+type AccountRepository interface {
+	GetUserDataSnapshot(ctx context.Context, userID int) (*model.UserDataSnapshot, error)
+	ScheduleDeletion(ctx context.Context, userID int, scheduledAt time.Time) error
+	CancelDeletion(ctx context.Context, userID int) (bool, error)
+	GetUsersDueForDeletion(ctx context.Context, now time.Time, limit int) ([]model.User, error)
+	AnonymizeUser(ctx context.Context, userID int, now time.Time) ([]string, error)
+}
+
+type AccountRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewAccountRepository(db *gorm.DB) AccountRepository {
+	return &AccountRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *AccountRepositoryImpl) GetUserDataSnapshot(ctx context.Context, userID int) (*model.UserDataSnapshot, error) {
+	db := r.db.WithContext(ctx)
+	var snapshot model.UserDataSnapshot
+
+	if err := db.First(&snapshot.Profile, userID).Error; err != nil {
+		return nil, err
+	}
+	snapshot.Profile.Password = ""
+
+	if err := db.Where("user_id = ?", userID).Order("created_at ASC").Find(&snapshot.Posts).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Where("user_id = ?", userID).Order("created_at ASC").Find(&snapshot.Comments).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Where("user_id = ?", userID).Order("created_at ASC").Find(&snapshot.Reviews).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Where("user_id = ?", userID).Order("created_at ASC").Find(&snapshot.Reactions).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Where("user_id = ?", userID).Order("created_at ASC").Find(&snapshot.Bookmarks).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Where("user_id = ?", userID).Order("created_at ASC").Find(&snapshot.BookmarkCollections).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Where("user_id = ?", userID).Order("created_at ASC").Find(&snapshot.PollVotes).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Where("user_id = ?", userID).Order("created_at ASC").Find(&snapshot.Media).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Joins("JOIN participants ON participants.id = messages.participant_id").
+		Where("participants.user_id = ?", userID).Order("messages.created_at ASC").Find(&snapshot.Messages).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Where("following_id = ?", userID).Find(&snapshot.Followers).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Where("follower_id = ?", userID).Find(&snapshot.Followings).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Where("user_id = ?", userID).Order("created_at ASC").Find(&snapshot.Notifications).Error; err != nil {
+		return nil, err
+	}
+
+	return &snapshot, nil
+}
+
+func (r *AccountRepositoryImpl) ScheduleDeletion(ctx context.Context, userID int, scheduledAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", userID).Update("deletion_scheduled_at", scheduledAt).Error
+}
+
+// CancelDeletion clears a pending deletion and reports false if none was scheduled.
+func (r *AccountRepositoryImpl) CancelDeletion(ctx context.Context, userID int) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.User{}).
+		Where("id = ? AND deletion_scheduled_at IS NOT NULL AND anonymized_at IS NULL", userID).
+		Update("deletion_scheduled_at", nil)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *AccountRepositoryImpl) GetUsersDueForDeletion(ctx context.Context, now time.Time, limit int) ([]model.User, error) {
+	var users []model.User
+	if err := r.db.WithContext(ctx).
+		Where("deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= ? AND anonymized_at IS NULL", now).
+		Limit(limit).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// AnonymizeUser strips personal data from the user row and deletes data that only makes sense for a
+// living account. Posts, comments, reviews and messages are kept and stay attributed to the anonymised
+// row, so no UserID reference is left dangling. It returns the files of the user's data exports so the
+// caller can remove them from disk.
+func (r *AccountRepositoryImpl) AnonymizeUser(ctx context.Context, userID int, now time.Time) ([]string, error) {
+	var exportFiles []string
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.DataExport{}).Where("user_id = ? AND file_path <> ''", userID).Pluck("file_path", &exportFiles).Error; err != nil {
+			return err
+		}
+
+		result := tx.Model(&model.User{}).Where("id = ? AND anonymized_at IS NULL", userID).Updates(map[string]interface{}{
+			"username":              fmt.Sprintf("deleted_user_%d", userID),
+			"displayname":           "Deleted user",
+			"email":                 fmt.Sprintf("deleted_user_%d@deleted.invalid", userID),
+			"email_verified":        false,
+			"email_verified_at":     nil,
+			"password":              "",
+			"two_factor_enabled":    false,
+			"two_factor_secret":     "",
+			"profile_picture":       "",
+			"cover_picture":         "",
+			"description":           "",
+			"country":               "",
+			"deletion_scheduled_at": nil,
+			"anonymized_at":         now,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		if err := tx.Unscoped().Where("follower_id = ? OR following_id = ?", userID, userID).Delete(&model.UserFollow{}).Error; err != nil {
+			return err
+		}
+
+		ownedRecords := []interface{}{
+			&model.Notification{},
+			&model.CommunityMember{},
+			&model.UserIdentity{},
+			&model.APIKey{},
+			&model.TwoFactorRecoveryCode{},
+			&model.PasswordResetToken{},
+			&model.DataExport{},
+			&model.PostReaction{},
+			&model.Bookmark{},
+			&model.BookmarkCollection{},
+			&model.PostMention{},
+			&model.CommentMention{},
+			&model.PollVote{},
+		}
+		for _, record := range ownedRecords {
+			if err := tx.Unscoped().Where("user_id = ?", userID).Delete(record).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Exec("DELETE FROM user_votes WHERE user_id = ?", userID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return exportFiles, nil
+}
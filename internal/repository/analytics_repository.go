@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/temuka-api-service/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AnalyticsTarget selects what analytics are computed for: the post with PostID, or else every post of
+// the author with AuthorID.
+type AnalyticsTarget struct {
+	PostID   int
+	AuthorID int
+}
+
+type AnalyticsRepository interface {
+	SaveViewStats(ctx context.Context, stats []model.PostViewStat) error
+	GetViewStats(ctx context.Context, target AnalyticsTarget, from, to time.Time) ([]model.PostViewStat, error)
+	SaveAuthorViewStats(ctx context.Context, stats []model.AuthorViewStat) error
+	GetAuthorViewStats(ctx context.Context, authorID int, from, to time.Time) ([]model.AuthorViewStat, error)
+	GetDailyReactions(ctx context.Context, target AnalyticsTarget, from, to time.Time) (map[string]int64, error)
+	GetDailyComments(ctx context.Context, target AnalyticsTarget, from, to time.Time) (map[string]int64, error)
+}
+
+type AnalyticsRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewAnalyticsRepository(db *gorm.DB) AnalyticsRepository {
+	return &AnalyticsRepositoryImpl{db: db}
+}
+
+// SaveViewStats stores the view counts flushed from Redis. The sketches there hold every view of the
+// day so far, so existing stats are overwritten rather than added to.
+func (r *AnalyticsRepositoryImpl) SaveViewStats(ctx context.Context, stats []model.PostViewStat) error {
+	if len(stats) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "post_id"}, {Name: "kind"}, {Name: "day"}},
+		DoUpdates: clause.AssignmentColumns([]string{"views", "viewers", "updated_at"}),
+	}).Create(&stats).Error
+}
+
+// GetViewStats returns the view stats of the days from from to to, both included. The viewer sketches
+// are only loaded for a post; those of an author are kept per day in the author view stats.
+func (r *AnalyticsRepositoryImpl) GetViewStats(ctx context.Context, target AnalyticsTarget, from, to time.Time) ([]model.PostViewStat, error) {
+	query := r.db.WithContext(ctx).Where("day BETWEEN ? AND ?", from, to)
+	if target.PostID != 0 {
+		query = query.Where("post_id = ?", target.PostID)
+	} else {
+		query = query.Where("author_id = ?", target.AuthorID).Omit("viewers")
+	}
+
+	var stats []model.PostViewStat
+	if err := query.Order("day").Find(&stats).Error; err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// SaveAuthorViewStats stores the author sketches flushed from Redis, overwriting those of the same day
+// like SaveViewStats does.
+func (r *AnalyticsRepositoryImpl) SaveAuthorViewStats(ctx context.Context, stats []model.AuthorViewStat) error {
+	if len(stats) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "author_id"}, {Name: "day"}},
+		DoUpdates: clause.AssignmentColumns([]string{"viewers", "updated_at"}),
+	}).Create(&stats).Error
+}
+
+// GetAuthorViewStats returns the author sketches of the days from from to to, both included.
+func (r *AnalyticsRepositoryImpl) GetAuthorViewStats(ctx context.Context, authorID int, from, to time.Time) ([]model.AuthorViewStat, error) {
+	var stats []model.AuthorViewStat
+	if err := r.db.WithContext(ctx).Where("author_id = ? AND day BETWEEN ? AND ?", authorID, from, to).Order("day").Find(&stats).Error; err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// GetDailyReactions counts the reactions left per UTC day, keyed by model.AnalyticsDateFormat.
+func (r *AnalyticsRepositoryImpl) GetDailyReactions(ctx context.Context, target AnalyticsTarget, from, to time.Time) (map[string]int64, error) {
+	return r.countDaily(ctx, &model.PostReaction{}, "post_reactions", target, from, to)
+}
+
+// GetDailyComments counts the comments written per UTC day, keyed by model.AnalyticsDateFormat.
+func (r *AnalyticsRepositoryImpl) GetDailyComments(ctx context.Context, target AnalyticsTarget, from, to time.Time) (map[string]int64, error) {
+	return r.countDaily(ctx, &model.Comment{}, "comments", target, from, to)
+}
+
+// countDaily counts the rows of a table with a post_id column per UTC day of their creation.
+func (r *AnalyticsRepositoryImpl) countDaily(ctx context.Context, record interface{}, table string, target AnalyticsTarget, from, to time.Time) (map[string]int64, error) {
+	query := r.db.WithContext(ctx).Model(record).
+		Select("to_char("+table+".created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD') AS day, COUNT(*) AS count").
+		Where(table+".created_at >= ? AND "+table+".created_at < ?", from, to.AddDate(0, 0, 1))
+	if target.PostID != 0 {
+		query = query.Where(table+".post_id = ?", target.PostID)
+	} else {
+		query = query.Joins("JOIN posts ON posts.id = "+table+".post_id AND posts.deleted_at IS NULL").
+			Where("posts.user_id = ?", target.AuthorID)
+	}
+
+	var rows []struct {
+		Day   string
+		Count int64
+	}
+	if err := query.Group("day").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Day] = row.Count
+	}
+	return counts, nil
+}
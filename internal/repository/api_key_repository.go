@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/temuka-api-service/internal/model"
+	"gorm.io/gorm"
+)
+
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, apiKey *model.APIKey) error
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (*model.APIKey, error)
+	GetUserAPIKeys(ctx context.Context, userID int) ([]model.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id, userID int) (bool, error)
+	TouchAPIKey(ctx context.Context, id int, usedAt time.Time) error
+}
+
+type APIKeyRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &APIKeyRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *APIKeyRepositoryImpl) CreateAPIKey(ctx context.Context, apiKey *model.APIKey) error {
+	return r.db.WithContext(ctx).Create(apiKey).Error
+}
+
+func (r *APIKeyRepositoryImpl) GetAPIKeyByHash(ctx context.Context, keyHash string) (*model.APIKey, error) {
+	var apiKey model.APIKey
+	if err := r.db.WithContext(ctx).Where("key_hash = ?", keyHash).First(&apiKey).Error; err != nil {
+		return nil, err
+	}
+	return &apiKey, nil
+}
+
+func (r *APIKeyRepositoryImpl) GetUserAPIKeys(ctx context.Context, userID int) ([]model.APIKey, error) {
+	var apiKeys []model.APIKey
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").Find(&apiKeys).Error; err != nil {
+		return nil, err
+	}
+	return apiKeys, nil
+}
+
+// RevokeAPIKey revokes a key owned by the user and reports false if no such active key exists.
+func (r *APIKeyRepositoryImpl) RevokeAPIKey(ctx context.Context, id, userID int) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// TouchAPIKey records usage at most once a minute so busy keys do not write on every request.
+func (r *APIKeyRepositoryImpl) TouchAPIKey(ctx context.Context, id int, usedAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, usedAt.Add(-time.Minute)).
+		Update("last_used_at", usedAt).Error
+}
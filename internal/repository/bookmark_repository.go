@@ -0,0 +1,231 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/temuka-api-service/internal/model"
+	"github.com/temuka-api-service/pkg/pagination"
+	"gorm.io/gorm"
+)
+
+var ErrBookmarkOrderMismatch = errors.New("bookmark order does not match the collection")
+
+type BookmarkRepository interface {
+	CreateCollection(ctx context.Context, collection *model.BookmarkCollection) error
+	GetCollections(ctx context.Context, userID int) ([]model.BookmarkCollection, error)
+	GetCollection(ctx context.Context, userID, id int) (*model.BookmarkCollection, error)
+	RenameCollection(ctx context.Context, userID, id int, name string) error
+	DeleteCollection(ctx context.Context, userID, id int) error
+	SaveBookmark(ctx context.Context, bookmark *model.Bookmark) error
+	DeleteBookmark(ctx context.Context, userID, id int) (bool, error)
+	GetBookmarks(ctx context.Context, userID int, collectionID *int, page pagination.Page) ([]model.Bookmark, string, error)
+	ReorderBookmarks(ctx context.Context, userID int, collectionID *int, bookmarkIDs []int) error
+	GetBookmarkedPostIDs(ctx context.Context, userID int, postIDs []int) (map[int]bool, error)
+}
+
+type BookmarkRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewBookmarkRepository(db *gorm.DB) BookmarkRepository {
+	return &BookmarkRepositoryImpl{db: db}
+}
+
+func (r *BookmarkRepositoryImpl) CreateCollection(ctx context.Context, collection *model.BookmarkCollection) error {
+	return r.db.WithContext(ctx).Create(collection).Error
+}
+
+func (r *BookmarkRepositoryImpl) GetCollections(ctx context.Context, userID int) ([]model.BookmarkCollection, error) {
+	var collections []model.BookmarkCollection
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("name ASC").Find(&collections).Error; err != nil {
+		return nil, err
+	}
+	return collections, nil
+}
+
+func (r *BookmarkRepositoryImpl) GetCollection(ctx context.Context, userID, id int) (*model.BookmarkCollection, error) {
+	var collection model.BookmarkCollection
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&collection, id).Error; err != nil {
+		return nil, err
+	}
+	return &collection, nil
+}
+
+func (r *BookmarkRepositoryImpl) RenameCollection(ctx context.Context, userID, id int, name string) error {
+	return r.db.WithContext(ctx).Model(&model.BookmarkCollection{}).Where("id = ? AND user_id = ?", id, userID).Update("name", name).Error
+}
+
+// DeleteCollection deletes a collection and moves its bookmarks to the end of the unsorted ones.
+func (r *BookmarkRepositoryImpl) DeleteCollection(ctx context.Context, userID, id int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		lastPosition, err := lastBookmarkPosition(tx, userID, nil)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(&model.Bookmark{}).Where("user_id = ? AND collection_id = ?", userID, id).
+			Updates(map[string]interface{}{
+				"collection_id": nil,
+				"position":      gorm.Expr("position + ?", lastPosition),
+			}).Error; err != nil {
+			return err
+		}
+
+		return tx.Unscoped().Where("id = ? AND user_id = ?", id, userID).Delete(&model.BookmarkCollection{}).Error
+	})
+}
+
+// SaveBookmark bookmarks the target of the bookmark at the end of its collection. A target the user
+// already bookmarked is moved instead, and keeps its position if it stays in the same collection.
+func (r *BookmarkRepositoryImpl) SaveBookmark(ctx context.Context, bookmark *model.Bookmark) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing model.Bookmark
+		err := tx.Where("user_id = ?", bookmark.UserID).Where(bookmarkTarget(bookmark)).First(&existing).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		found := err == nil
+
+		if found && sameCollection(existing.CollectionID, bookmark.CollectionID) {
+			*bookmark = existing
+			return nil
+		}
+
+		lastPosition, err := lastBookmarkPosition(tx, bookmark.UserID, bookmark.CollectionID)
+		if err != nil {
+			return err
+		}
+		bookmark.Position = lastPosition + 1
+
+		if !found {
+			return tx.Create(bookmark).Error
+		}
+
+		bookmark.ID = existing.ID
+		bookmark.CreatedAt = existing.CreatedAt
+		return tx.Model(&model.Bookmark{}).Where("id = ?", existing.ID).Updates(map[string]interface{}{
+			"collection_id": bookmark.CollectionID,
+			"position":      bookmark.Position,
+		}).Error
+	})
+}
+
+func (r *BookmarkRepositoryImpl) DeleteBookmark(ctx context.Context, userID, id int) (bool, error) {
+	result := r.db.WithContext(ctx).Unscoped().Where("id = ? AND user_id = ?", id, userID).Delete(&model.Bookmark{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// GetBookmarks pages through a collection, or the unsorted bookmarks when collectionID is nil, in
+// position order.
+func (r *BookmarkRepositoryImpl) GetBookmarks(ctx context.Context, userID int, collectionID *int, page pagination.Page) ([]model.Bookmark, string, error) {
+	var bookmarks []model.Bookmark
+	if err := r.db.WithContext(ctx).
+		Preload("Post").Preload("University").Preload("Community").
+		Where("user_id = ?", userID).
+		Scopes(inBookmarkCollection(collectionID), page.ScopeBy("position", false)).
+		Find(&bookmarks).Error; err != nil {
+		return nil, "", err
+	}
+	bookmarks, nextCursor := pagination.Result(page, bookmarks, func(bookmark model.Bookmark) int { return bookmark.Position })
+	return bookmarks, nextCursor, nil
+}
+
+// ReorderBookmarks puts the bookmarks of a collection in the given order. bookmarkIDs must list every
+// bookmark of the collection exactly once.
+func (r *BookmarkRepositoryImpl) ReorderBookmarks(ctx context.Context, userID int, collectionID *int, bookmarkIDs []int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var currentIDs []int
+		if err := tx.Model(&model.Bookmark{}).Where("user_id = ?", userID).
+			Scopes(inBookmarkCollection(collectionID)).
+			Pluck("id", &currentIDs).Error; err != nil {
+			return err
+		}
+
+		if !sameIDSet(currentIDs, bookmarkIDs) {
+			return ErrBookmarkOrderMismatch
+		}
+
+		for i, id := range bookmarkIDs {
+			if err := tx.Model(&model.Bookmark{}).Where("id = ?", id).Update("position", i+1).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *BookmarkRepositoryImpl) GetBookmarkedPostIDs(ctx context.Context, userID int, postIDs []int) (map[int]bool, error) {
+	bookmarked := make(map[int]bool)
+	if len(postIDs) == 0 {
+		return bookmarked, nil
+	}
+
+	var ids []int
+	if err := r.db.WithContext(ctx).Model(&model.Bookmark{}).
+		Where("user_id = ? AND post_id IN ?", userID, postIDs).
+		Pluck("post_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		bookmarked[id] = true
+	}
+	return bookmarked, nil
+}
+
+func lastBookmarkPosition(tx *gorm.DB, userID int, collectionID *int) (int, error) {
+	var lastPosition int
+	err := tx.Model(&model.Bookmark{}).Where("user_id = ?", userID).
+		Scopes(inBookmarkCollection(collectionID)).
+		Select("COALESCE(MAX(position), 0)").
+		Scan(&lastPosition).Error
+	return lastPosition, err
+}
+
+func inBookmarkCollection(collectionID *int) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if collectionID == nil {
+			return db.Where("collection_id IS NULL")
+		}
+		return db.Where("collection_id = ?", *collectionID)
+	}
+}
+
+// bookmarkTarget is the condition matching bookmarks of the same target as the given one.
+func bookmarkTarget(bookmark *model.Bookmark) map[string]interface{} {
+	switch {
+	case bookmark.PostID != nil:
+		return map[string]interface{}{"post_id": *bookmark.PostID}
+	case bookmark.UniversityID != nil:
+		return map[string]interface{}{"university_id": *bookmark.UniversityID}
+	default:
+		return map[string]interface{}{"community_id": bookmark.CommunityID}
+	}
+}
+
+func sameCollection(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func sameIDSet(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := make(map[int]bool, len(a))
+	for _, id := range a {
+		seen[id] = true
+	}
+	for _, id := range b {
+		if !seen[id] {
+			return false
+		}
+		delete(seen, id)
+	}
+	return true
+}
@@ -4,14 +4,15 @@ import (
 	"context"
 
 	"github.com/temuka-api-service/internal/model"
+	"github.com/temuka-api-service/pkg/pagination"
 	"gorm.io/gorm"
 )
 
 type CommentRepository interface {
 	CreateComment(ctx context.Context, comment *model.Comment) error
-	GetCommentsByPostID(ctx context.Context, postID int) ([]model.Comment, error)
+	GetCommentsByPostID(ctx context.Context, postID int, page pagination.Page) ([]model.Comment, string, error)
 	DeleteComment(ctx context.Context, commentID int) error
-	GetRepliesByParentID(ctx context.Context, parentID int) ([]model.Comment, error)
+	GetRepliesByParentID(ctx context.Context, parentID int, page pagination.Page) ([]model.Comment, string, error)
 	GetCommentDetailByID(ctx context.Context, id int) (*model.Comment, error)
 }
 
@@ -29,24 +30,26 @@ func (r *CommentRepositoryImpl) CreateComment(ctx context.Context, comment *mode
 	return r.db.WithContext(ctx).Create(comment).Error
 }
 
-func (r *CommentRepositoryImpl) GetCommentsByPostID(ctx context.Context, postID int) ([]model.Comment, error) {
+func (r *CommentRepositoryImpl) GetCommentsByPostID(ctx context.Context, postID int, page pagination.Page) ([]model.Comment, string, error) {
 	var comments []model.Comment
-	if err := r.db.Where("post_id = ?", postID).Find(&comments).Error; err != nil {
-		return nil, err
+	if err := r.db.WithContext(ctx).Where("post_id = ?", postID).Scopes(page.Scope(false)).Find(&comments).Error; err != nil {
+		return nil, "", err
 	}
-	return comments, nil
+	comments, nextCursor := pagination.Result(page, comments, func(comment model.Comment) int { return comment.ID })
+	return comments, nextCursor, nil
 }
 
 func (r *CommentRepositoryImpl) DeleteComment(ctx context.Context, commentID int) error {
 	return r.db.Delete(&model.Comment{}, commentID).Error
 }
 
-func (r *CommentRepositoryImpl) GetRepliesByParentID(ctx context.Context, parentID int) ([]model.Comment, error) {
+func (r *CommentRepositoryImpl) GetRepliesByParentID(ctx context.Context, parentID int, page pagination.Page) ([]model.Comment, string, error) {
 	var comments []model.Comment
-	if err := r.db.Where("parent_id = ?", parentID).Find(&comments).Error; err != nil {
-		return nil, err
+	if err := r.db.WithContext(ctx).Where("parent_id = ?", parentID).Scopes(page.Scope(false)).Find(&comments).Error; err != nil {
+		return nil, "", err
 	}
-	return comments, nil
+	comments, nextCursor := pagination.Result(page, comments, func(comment model.Comment) int { return comment.ID })
+	return comments, nextCursor, nil
 }
 
 func (r *CommentRepositoryImpl) GetCommentDetailByID(ctx context.Context, id int) (*model.Comment, error) {
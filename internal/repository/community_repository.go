@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/temuka-api-service/internal/model"
+	"github.com/temuka-api-service/pkg/pagination"
 	"gorm.io/gorm"
 )
 
@@ -11,12 +12,13 @@ type CommunityRepository interface {
 	CreateCommunity(context context.Context, community *model.Community) error
 	CheckCommunityNameAvailability(ctx context.Context, name string) bool
 	UpdateCommunity(context context.Context, id int, community *model.Community) error
-	GetCommunities(context context.Context) ([]model.Community, error)
-	GetUserJoinedCommunities(context context.Context, userID int) ([]model.Community, error)
+	GetCommunities(context context.Context, page pagination.Page) ([]model.Community, string, error)
+	GetUserJoinedCommunities(context context.Context, userID int, page pagination.Page) ([]model.Community, string, error)
 	GetCommunityDetailByID(context context.Context, id int) (*model.Community, error)
+	GetCommunitiesByIDs(ctx context.Context, ids []int) ([]model.Community, error)
 	CheckMembership(ctx context.Context, communityID, userID int) (*model.CommunityMember, error)
 	AddCommunityMember(ctx context.Context, member *model.CommunityMember) error
-	GetCommunityPosts(ctx context.Context, id int, filters map[string]interface{}) ([]model.CommunityPost, error)
+	GetCommunityPosts(ctx context.Context, id int, filters map[string]interface{}, page pagination.Page, newestFirst bool) ([]model.CommunityPost, string, error)
 	UpdateCommunityPostsCount(context context.Context, id int) error
 	UpdateCommunityMembersCount(context context.Context, id int) error
 	DeleteCommunity(context context.Context, id int) error
@@ -58,14 +60,26 @@ func (r *CommunityRepositoryImpl) GetCommunityDetailByID(ctx context.Context, id
 	return &community, nil
 }
 
-func (r *CommunityRepositoryImpl) GetCommunities(context context.Context) ([]model.Community, error) {
+func (r *CommunityRepositoryImpl) GetCommunitiesByIDs(ctx context.Context, ids []int) ([]model.Community, error) {
 	var communities []model.Community
-	if err := r.db.WithContext(context).Find(&communities).Error; err != nil {
+	if len(ids) == 0 {
+		return communities, nil
+	}
+	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&communities).Error; err != nil {
 		return nil, err
 	}
 	return communities, nil
 }
 
+func (r *CommunityRepositoryImpl) GetCommunities(context context.Context, page pagination.Page) ([]model.Community, string, error) {
+	var communities []model.Community
+	if err := r.db.WithContext(context).Scopes(page.Scope(false)).Find(&communities).Error; err != nil {
+		return nil, "", err
+	}
+	communities, nextCursor := pagination.Result(page, communities, func(community model.Community) int { return community.ID })
+	return communities, nextCursor, nil
+}
+
 func (r *CommunityRepositoryImpl) DeleteCommunity(context context.Context, id int) error {
 	return r.db.WithContext(context).Delete(&model.Community{}, id).Error
 }
@@ -95,51 +109,36 @@ func (r *CommunityRepositoryImpl) CheckMembership(ctx context.Context, community
 	return &member, nil
 }
 
-func (r *CommunityRepositoryImpl) GetCommunityPosts(ctx context.Context, communityID int, filters map[string]interface{}) ([]model.CommunityPost, error) {
+// GetCommunityPosts pages through the posts of a community matching every column in filters. The
+// filter keys must be trusted column names.
+func (r *CommunityRepositoryImpl) GetCommunityPosts(ctx context.Context, communityID int, filters map[string]interface{}, page pagination.Page, newestFirst bool) ([]model.CommunityPost, string, error) {
 	var communityPosts []model.CommunityPost
 
 	data := r.db.WithContext(ctx).Where("community_id = ?", communityID)
-
 	for key, val := range filters {
-		if key == "sort" || key == "sort_by" {
-			continue
-		}
 		data = data.Where(key+" = ?", val)
 	}
 
-	sortBy, sortByExists := filters["sort_by"].(string)
-	sortOrder, sortOrderExists := filters["sort"].(string)
-
-	if sortByExists && sortOrderExists {
-		data = data.Order(sortBy + " " + sortOrder)
-	} else if sortByExists {
-		data = data.Order(sortBy + "asc")
-	} else {
-		data = data.Order("created_at desc")
-	}
-
-	if err := data.Find(&communityPosts).Error; err != nil {
-		return nil, err
+	if err := data.Scopes(page.Scope(newestFirst)).Find(&communityPosts).Error; err != nil {
+		return nil, "", err
 	}
 
-	return communityPosts, nil
+	communityPosts, nextCursor := pagination.Result(page, communityPosts, func(communityPost model.CommunityPost) int { return communityPost.ID })
+	return communityPosts, nextCursor, nil
 }
 
-func (r *CommunityRepositoryImpl) GetUserJoinedCommunities(context context.Context, userID int) ([]model.Community, error) {
+func (r *CommunityRepositoryImpl) GetUserJoinedCommunities(context context.Context, userID int, page pagination.Page) ([]model.Community, string, error) {
 	var communities []model.Community
 
-	query := `
-		SELECT c.*
-		FROM community_members cm
-		INNER JOIN communities c ON cm.community_id = c.id
-		WHERE cm.user_id = ? AND cm.banned = false
-	`
-
-	if err := r.db.WithContext(context).Raw(query, userID).Scan(&communities).Error; err != nil {
-		return nil, err
+	if err := r.db.WithContext(context).
+		Joins("JOIN community_members ON community_members.community_id = communities.id").
+		Where("community_members.user_id = ? AND community_members.banned = false", userID).
+		Scopes(page.ScopeBy("communities.id", false)).Find(&communities).Error; err != nil {
+		return nil, "", err
 	}
 
-	return communities, nil
+	communities, nextCursor := pagination.Result(page, communities, func(community model.Community) int { return community.ID })
+	return communities, nextCursor, nil
 }
 
 func (r *CommunityRepositoryImpl) GetCommunityDetailBySlug(ctx context.Context, slug string) (*model.Community, error) {
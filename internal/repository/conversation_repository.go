@@ -4,17 +4,20 @@ import (
 	"context"
 
 	"github.com/temuka-api-service/internal/model"
+	"github.com/temuka-api-service/pkg/pagination"
 	"gorm.io/gorm"
 )
 
 type ConversationRepository interface {
 	CreateConversation(ctx context.Context, conversation *model.Conversation) error
-	GetConversationsByUserID(ctx context.Context, userID int) ([]model.Conversation, error)
+	GetConversationsByUserID(ctx context.Context, userID int, page pagination.Page) ([]model.Conversation, string, error)
 	DeleteConversation(ctx context.Context, id int) error
 	GetConversationDetailByID(ctx context.Context, id int) (*model.Conversation, error)
 	AddParticipant(ctx context.Context, participant *model.Participant) error
+	GetParticipantByID(ctx context.Context, id int) (*model.Participant, error)
+	IsParticipant(ctx context.Context, conversationID, userID int) (bool, error)
 	AddMessage(ctx context.Context, message *model.Message) error
-	GetMessagesByConversationID(ctx context.Context, conversationID int) ([]model.Message, error)
+	GetMessagesByConversationID(ctx context.Context, conversationID int, page pagination.Page) ([]model.Message, string, error)
 }
 
 type ConversationRepositoryImpl struct {
@@ -31,12 +34,14 @@ func (r *ConversationRepositoryImpl) CreateConversation(ctx context.Context, con
 	return r.db.WithContext(ctx).Create(conversation).Error
 }
 
-func (r *ConversationRepositoryImpl) GetConversationsByUserID(ctx context.Context, userID int) ([]model.Conversation, error) {
+// GetConversationsByUserID pages through the conversations a user started, newest first.
+func (r *ConversationRepositoryImpl) GetConversationsByUserID(ctx context.Context, userID int, page pagination.Page) ([]model.Conversation, string, error) {
 	var conversations []model.Conversation
-	if err := r.db.Where("user_id = ?", userID).Find(&conversations).Error; err != nil {
-		return nil, err
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Scopes(page.Scope(true)).Find(&conversations).Error; err != nil {
+		return nil, "", err
 	}
-	return conversations, nil
+	conversations, nextCursor := pagination.Result(page, conversations, func(conversation model.Conversation) int { return conversation.ID })
+	return conversations, nextCursor, nil
 }
 
 func (r *ConversationRepositoryImpl) DeleteConversation(ctx context.Context, id int) error {
@@ -59,10 +64,32 @@ func (r *ConversationRepositoryImpl) AddParticipant(ctx context.Context, partici
 	return r.db.WithContext(ctx).Create(participant).Error
 }
 
-func (r *ConversationRepositoryImpl) GetMessagesByConversationID(ctx context.Context, conversationID int) ([]model.Message, error) {
-	var messages []model.Message
-	if err := r.db.WithContext(ctx).Where("conversation_id = ?", conversationID).Find(&messages).Error; err != nil {
+func (r *ConversationRepositoryImpl) GetParticipantByID(ctx context.Context, id int) (*model.Participant, error) {
+	var participant model.Participant
+	if err := r.db.WithContext(ctx).First(&participant, id).Error; err != nil {
 		return nil, err
 	}
-	return messages, nil
+	return &participant, nil
+}
+
+func (r *ConversationRepositoryImpl) IsParticipant(ctx context.Context, conversationID, userID int) (bool, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&model.Participant{}).
+		Where("conversation_id = ? AND user_id = ?", conversationID, userID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// GetMessagesByConversationID pages through a conversation from the newest message backwards. Messages
+// belong to a conversation through the participant who sent them.
+func (r *ConversationRepositoryImpl) GetMessagesByConversationID(ctx context.Context, conversationID int, page pagination.Page) ([]model.Message, string, error) {
+	var messages []model.Message
+	if err := r.db.WithContext(ctx).Joins("JOIN participants ON participants.id = messages.participant_id").
+		Where("participants.conversation_id = ?", conversationID).
+		Scopes(page.ScopeBy("messages.id", true)).Find(&messages).Error; err != nil {
+		return nil, "", err
+	}
+	messages, nextCursor := pagination.Result(page, messages, func(message model.Message) int { return message.ID })
+	return messages, nextCursor, nil
 }
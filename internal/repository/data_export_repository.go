@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/temuka-api-service/internal/model"
+	"gorm.io/gorm"
+)
+
+type DataExportRepository interface {
+	CreateDataExport(ctx context.Context, export *model.DataExport) error
+	GetDataExportByID(ctx context.Context, id int) (*model.DataExport, error)
+	GetLatestUserDataExport(ctx context.Context, userID int) (*model.DataExport, error)
+	ClaimDataExports(ctx context.Context, now, staleBefore time.Time, limit int) ([]model.DataExport, error)
+	CompleteDataExport(ctx context.Context, id int, filePath string, completedAt, expiresAt time.Time) error
+	FailDataExport(ctx context.Context, id int, reason string) error
+	GetExpiredDataExports(ctx context.Context, now time.Time) ([]model.DataExport, error)
+	DeleteDataExport(ctx context.Context, id int) error
+}
+
+type DataExportRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewDataExportRepository(db *gorm.DB) DataExportRepository {
+	return &DataExportRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *DataExportRepositoryImpl) CreateDataExport(ctx context.Context, export *model.DataExport) error {
+	return r.db.WithContext(ctx).Create(export).Error
+}
+
+func (r *DataExportRepositoryImpl) GetDataExportByID(ctx context.Context, id int) (*model.DataExport, error) {
+	var export model.DataExport
+	if err := r.db.WithContext(ctx).First(&export, id).Error; err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (r *DataExportRepositoryImpl) GetLatestUserDataExport(ctx context.Context, userID int) (*model.DataExport, error) {
+	var export model.DataExport
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").First(&export).Error; err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+// ClaimDataExports moves up to limit pending exports to processing, together with exports left
+// processing since before staleBefore by an instance that did not finish them. Rows locked by a
+// concurrent claim are skipped, so every export is handed to a single caller.
+func (r *DataExportRepositoryImpl) ClaimDataExports(ctx context.Context, now, staleBefore time.Time, limit int) ([]model.DataExport, error) {
+	var exports []model.DataExport
+	err := r.db.WithContext(ctx).Raw(`UPDATE data_exports SET status = @processing, claimed_at = @now, updated_at = @now
+	WHERE id IN (
+		SELECT id FROM data_exports
+		WHERE deleted_at IS NULL AND (
+			status = @pending OR
+			(status = @processing AND (claimed_at IS NULL OR claimed_at < @stale_before)))
+		ORDER BY created_at, id
+		LIMIT @limit
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING *`, map[string]interface{}{
+		"processing":   model.DataExportStatusProcessing,
+		"pending":      model.DataExportStatusPending,
+		"now":          now,
+		"stale_before": staleBefore,
+		"limit":        limit,
+	}).Scan(&exports).Error
+	if err != nil {
+		return nil, err
+	}
+	return exports, nil
+}
+
+func (r *DataExportRepositoryImpl) CompleteDataExport(ctx context.Context, id int, filePath string, completedAt, expiresAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.DataExport{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":       model.DataExportStatusCompleted,
+		"file_path":    filePath,
+		"completed_at": completedAt,
+		"expires_at":   expiresAt,
+	}).Error
+}
+
+func (r *DataExportRepositoryImpl) FailDataExport(ctx context.Context, id int, reason string) error {
+	return r.db.WithContext(ctx).Model(&model.DataExport{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status": model.DataExportStatusFailed,
+		"error":  reason,
+	}).Error
+}
+
+func (r *DataExportRepositoryImpl) GetExpiredDataExports(ctx context.Context, now time.Time) ([]model.DataExport, error) {
+	var exports []model.DataExport
+	if err := r.db.WithContext(ctx).Where("expires_at IS NOT NULL AND expires_at < ?", now).Find(&exports).Error; err != nil {
+		return nil, err
+	}
+	return exports, nil
+}
+
+func (r *DataExportRepositoryImpl) DeleteDataExport(ctx context.Context, id int) error {
+	return r.db.WithContext(ctx).Delete(&model.DataExport{}, id).Error
+}
@@ -0,0 +1,378 @@
+package repository
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// fakeRedis is a Redis server with the few commands the repositories use, whose keys expire by a
+// clock the test moves. Scripts are run by a small interpreter for the straight-line Lua the
+// repositories send: redis.call statements, if blocks comparing a call to an argument, and returns.
+type fakeRedis struct {
+	mu   sync.Mutex
+	now  time.Time
+	keys map[string]*fakeRedisEntry
+}
+
+type fakeRedisEntry struct {
+	hash      map[string]string
+	set       map[string]bool
+	expiresAt time.Time
+}
+
+// fakeRedisNil is the nil bulk reply.
+type fakeRedisNil struct{}
+
+// fakeRedisStatus is a simple string reply.
+type fakeRedisStatus string
+
+var errFakeRedisNoScript = errors.New("NOSCRIPT No matching script. Please use EVAL.")
+
+// newFakeRedis starts a fake Redis server and returns a client connected to it.
+func newFakeRedis(t *testing.T) (*fakeRedis, *redis.Client) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &fakeRedis{now: time.Unix(1700000000, 0), keys: make(map[string]*fakeRedisEntry)}
+	go server.serve(listener)
+
+	client := redis.NewClient(&redis.Options{Addr: listener.Addr().String()})
+	t.Cleanup(func() {
+		client.Close()
+		listener.Close()
+	})
+	return server, client
+}
+
+func (s *fakeRedis) advance(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = s.now.Add(d)
+}
+
+func (s *fakeRedis) serve(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeRedis) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	writer := bufio.NewWriter(conn)
+
+	var queued [][]string
+	inMulti := false
+	for {
+		args, err := readFakeRedisCommand(reader)
+		if err != nil {
+			return
+		}
+
+		name := strings.ToUpper(args[0])
+		switch {
+		case name == "MULTI":
+			inMulti = true
+			writeFakeRedisReply(writer, fakeRedisStatus("OK"))
+		case name == "EXEC":
+			s.mu.Lock()
+			replies := make([]interface{}, 0, len(queued))
+			for _, command := range queued {
+				reply, err := s.call(command)
+				if err != nil {
+					reply = err
+				}
+				replies = append(replies, reply)
+			}
+			s.mu.Unlock()
+			queued, inMulti = nil, false
+			writeFakeRedisReply(writer, replies)
+		case inMulti:
+			queued = append(queued, args)
+			writeFakeRedisReply(writer, fakeRedisStatus("QUEUED"))
+		default:
+			s.mu.Lock()
+			reply, err := s.call(args)
+			s.mu.Unlock()
+			if err != nil {
+				reply = err
+			}
+			writeFakeRedisReply(writer, reply)
+		}
+		if err := writer.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// entry returns the live entry of a key, dropping it once expired.
+func (s *fakeRedis) entry(key string) *fakeRedisEntry {
+	entry, found := s.keys[key]
+	if !found {
+		return nil
+	}
+	if !entry.expiresAt.IsZero() && !s.now.Before(entry.expiresAt) {
+		delete(s.keys, key)
+		return nil
+	}
+	return entry
+}
+
+func (s *fakeRedis) call(args []string) (interface{}, error) {
+	name := strings.ToUpper(args[0])
+	switch name {
+	case "PING":
+		return fakeRedisStatus("PONG"), nil
+	case "HSET":
+		entry := s.entry(args[1])
+		if entry == nil {
+			entry = &fakeRedisEntry{hash: make(map[string]string)}
+			s.keys[args[1]] = entry
+		}
+		added := int64(0)
+		for i := 2; i+1 < len(args); i += 2 {
+			if _, found := entry.hash[args[i]]; !found {
+				added++
+			}
+			entry.hash[args[i]] = args[i+1]
+		}
+		return added, nil
+	case "HGET":
+		entry := s.entry(args[1])
+		if entry == nil {
+			return fakeRedisNil{}, nil
+		}
+		value, found := entry.hash[args[2]]
+		if !found {
+			return fakeRedisNil{}, nil
+		}
+		return value, nil
+	case "HGETALL":
+		values := []interface{}{}
+		if entry := s.entry(args[1]); entry != nil {
+			for field, value := range entry.hash {
+				values = append(values, field, value)
+			}
+		}
+		return values, nil
+	case "SADD":
+		entry := s.entry(args[1])
+		if entry == nil {
+			entry = &fakeRedisEntry{set: make(map[string]bool)}
+			s.keys[args[1]] = entry
+		}
+		added := int64(0)
+		for _, member := range args[2:] {
+			if !entry.set[member] {
+				added++
+			}
+			entry.set[member] = true
+		}
+		return added, nil
+	case "SREM":
+		removed := int64(0)
+		if entry := s.entry(args[1]); entry != nil {
+			for _, member := range args[2:] {
+				if entry.set[member] {
+					removed++
+				}
+				delete(entry.set, member)
+			}
+		}
+		return removed, nil
+	case "SMEMBERS":
+		members := []interface{}{}
+		if entry := s.entry(args[1]); entry != nil {
+			for member := range entry.set {
+				members = append(members, member)
+			}
+		}
+		return members, nil
+	case "EXISTS", "DEL":
+		count := int64(0)
+		for _, key := range args[1:] {
+			if s.entry(key) != nil {
+				count++
+				if name == "DEL" {
+					delete(s.keys, key)
+				}
+			}
+		}
+		return count, nil
+	case "EXPIRE", "PEXPIRE":
+		entry := s.entry(args[1])
+		if entry == nil {
+			return int64(0), nil
+		}
+		ttl, err := strconv.ParseInt(args[2], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		unit := time.Millisecond
+		if name == "EXPIRE" {
+			unit = time.Second
+		}
+		entry.expiresAt = s.now.Add(time.Duration(ttl) * unit)
+		return int64(1), nil
+	case "EVALSHA":
+		return nil, errFakeRedisNoScript
+	case "EVAL":
+		keyCount, err := strconv.Atoi(args[2])
+		if err != nil {
+			return nil, err
+		}
+		return s.eval(args[1], args[3:3+keyCount], args[3+keyCount:])
+	}
+	return nil, fmt.Errorf("ERR unknown command '%s'", args[0])
+}
+
+var (
+	fakeRedisIfCall = regexp.MustCompile(`^if redis\.call\((.*)\) == (.*) then$`)
+	fakeRedisCall   = regexp.MustCompile(`^redis\.call\((.*)\)$`)
+	fakeRedisReturn = regexp.MustCompile(`^return (-?\d+)$`)
+)
+
+// eval runs a script line by line, skipping the body of an if whose condition does not hold.
+func (s *fakeRedis) eval(script string, keys, argv []string) (interface{}, error) {
+	skipping := 0
+	for _, line := range strings.Split(script, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if skipping > 0 {
+			if strings.HasPrefix(line, "if ") {
+				skipping++
+			} else if line == "end" {
+				skipping--
+			}
+			continue
+		}
+
+		if match := fakeRedisIfCall.FindStringSubmatch(line); match != nil {
+			reply, err := s.evalCall(match[1], keys, argv)
+			if err != nil {
+				return nil, err
+			}
+			want, err := fakeRedisScriptValue(match[2], keys, argv)
+			if err != nil {
+				return nil, err
+			}
+			if value, ok := reply.(string); !ok || value != want {
+				skipping = 1
+			}
+		} else if match := fakeRedisCall.FindStringSubmatch(line); match != nil {
+			if _, err := s.evalCall(match[1], keys, argv); err != nil {
+				return nil, err
+			}
+		} else if match := fakeRedisReturn.FindStringSubmatch(line); match != nil {
+			return strconv.ParseInt(match[1], 10, 64)
+		} else if line != "end" {
+			return nil, fmt.Errorf("ERR unsupported script line %q", line)
+		}
+	}
+	return fakeRedisNil{}, nil
+}
+
+func (s *fakeRedis) evalCall(list string, keys, argv []string) (interface{}, error) {
+	var args []string
+	for _, expr := range strings.Split(list, ",") {
+		value, err := fakeRedisScriptValue(strings.TrimSpace(expr), keys, argv)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, value)
+	}
+	return s.call(args)
+}
+
+var fakeRedisScriptIndex = regexp.MustCompile(`^(KEYS|ARGV)\[(\d+)\]$`)
+
+func fakeRedisScriptValue(expr string, keys, argv []string) (string, error) {
+	if unquoted, err := strconv.Unquote(expr); err == nil {
+		return unquoted, nil
+	}
+	match := fakeRedisScriptIndex.FindStringSubmatch(expr)
+	if match == nil {
+		return "", fmt.Errorf("ERR unsupported script expression %q", expr)
+	}
+	values := keys
+	if match[1] == "ARGV" {
+		values = argv
+	}
+	i, _ := strconv.Atoi(match[2])
+	if i < 1 || i > len(values) {
+		return "", fmt.Errorf("ERR script index %s out of range", expr)
+	}
+	return values[i-1], nil
+}
+
+func readFakeRedisCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return nil, fmt.Errorf("unexpected command line %q", line)
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, count)
+	for i := range args {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return nil, err
+		}
+		arg := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, arg); err != nil {
+			return nil, err
+		}
+		args[i] = string(arg[:size])
+	}
+	return args, nil
+}
+
+func writeFakeRedisReply(writer *bufio.Writer, reply interface{}) {
+	switch reply := reply.(type) {
+	case fakeRedisNil:
+		writer.WriteString("$-1\r\n")
+	case fakeRedisStatus:
+		fmt.Fprintf(writer, "+%s\r\n", reply)
+	case error:
+		fmt.Fprintf(writer, "-%s\r\n", reply)
+	case int64:
+		fmt.Fprintf(writer, ":%d\r\n", reply)
+	case string:
+		fmt.Fprintf(writer, "$%d\r\n%s\r\n", len(reply), reply)
+	case []interface{}:
+		fmt.Fprintf(writer, "*%d\r\n", len(reply))
+		for _, item := range reply {
+			writeFakeRedisReply(writer, item)
+		}
+	}
+}
@@ -4,13 +4,14 @@ import (
 	"context"
 
 	"github.com/temuka-api-service/internal/model"
+	"github.com/temuka-api-service/pkg/pagination"
 	"gorm.io/gorm"
 )
 
 type LocationRepository interface {
 	AddLocation(ctx context.Context, location *model.Location) error
 	UpdateLocation(ctx context.Context, id int, location *model.Location) error
-	GetLocations(ctx context.Context) ([]model.Location, error)
+	GetLocations(ctx context.Context, page pagination.Page) ([]model.Location, string, error)
 	DeleteLocation(ctx context.Context, id int) error
 	GetLocationById(ctx context.Context, id int) (*model.Location, error)
 }
@@ -37,12 +38,13 @@ func (r *LocationRepositoryImpl) UpdateLocation(ctx context.Context, id int, loc
 	return r.db.WithContext(ctx).Model(&model.Location{}).Where("id = ?", id).Updates(location).Error
 }
 
-func (r *LocationRepositoryImpl) GetLocations(ctx context.Context) ([]model.Location, error) {
+func (r *LocationRepositoryImpl) GetLocations(ctx context.Context, page pagination.Page) ([]model.Location, string, error) {
 	var locations []model.Location
-	if err := r.db.WithContext(ctx).Find(&locations).Error; err != nil {
-		return nil, err
+	if err := r.db.WithContext(ctx).Scopes(page.Scope(false)).Find(&locations).Error; err != nil {
+		return nil, "", err
 	}
-	return locations, nil
+	locations, nextCursor := pagination.Result(page, locations, func(location model.Location) int { return location.ID })
+	return locations, nextCursor, nil
 }
 
 func (r *LocationRepositoryImpl) GetLocationById(ctx context.Context, id int) (*model.Location, error) {
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+type LockRepository interface {
+	Acquire(ctx context.Context, name string, ttl time.Duration) (string, bool, error)
+	Release(ctx context.Context, name, lockToken string) error
+}
+
+type LockRepositoryImpl struct {
+	client *redis.Client
+}
+
+func NewLockRepository(client *redis.Client) LockRepository {
+	return &LockRepositoryImpl{
+		client: client,
+	}
+}
+
+// releaseLockScript deletes the lock only while it still holds the caller's token, so a holder whose
+// lock expired cannot release the lock of the next holder.
+var releaseLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+func lockKey(name string) string {
+	return fmt.Sprintf("lock:%s", name)
+}
+
+// Acquire takes the named lock for ttl. It returns the token needed to release it, or false when
+// another holder has it.
+func (r *LockRepositoryImpl) Acquire(ctx context.Context, name string, ttl time.Duration) (string, bool, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", false, err
+	}
+	lockToken := hex.EncodeToString(b)
+
+	acquired, err := r.client.SetNX(ctx, lockKey(name), lockToken, ttl).Result()
+	if err != nil || !acquired {
+		return "", false, err
+	}
+	return lockToken, true, nil
+}
+
+func (r *LockRepositoryImpl) Release(ctx context.Context, name, lockToken string) error {
+	return releaseLockScript.Run(ctx, r.client, []string{lockKey(name)}, lockToken).Err()
+}
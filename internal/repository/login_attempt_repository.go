@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+type LoginAttemptRepository interface {
+	GetLockout(ctx context.Context, subject string) (time.Duration, error)
+	RegisterFailure(ctx context.Context, subject string, window time.Duration) (int64, error)
+	IncrementLockoutLevel(ctx context.Context, subject string, ttl time.Duration) (int64, error)
+	Lock(ctx context.Context, subject string, duration time.Duration) error
+	ClearFailures(ctx context.Context, subject string) error
+}
+
+type LoginAttemptRepositoryImpl struct {
+	client *redis.Client
+}
+
+func NewLoginAttemptRepository(client *redis.Client) LoginAttemptRepository {
+	return &LoginAttemptRepositoryImpl{
+		client: client,
+	}
+}
+
+func loginFailuresKey(subject string) string {
+	return fmt.Sprintf("login_failures:%s", subject)
+}
+
+func loginLockoutKey(subject string) string {
+	return fmt.Sprintf("login_lockout:%s", subject)
+}
+
+func loginLockoutLevelKey(subject string) string {
+	return fmt.Sprintf("login_lockout_level:%s", subject)
+}
+
+// GetLockout returns how long the subject stays locked out, or zero when it is not locked.
+func (r *LoginAttemptRepositoryImpl) GetLockout(ctx context.Context, subject string) (time.Duration, error) {
+	remaining, err := r.client.PTTL(ctx, loginLockoutKey(subject)).Result()
+	if err != nil {
+		return 0, err
+	}
+	if remaining < 0 {
+		return 0, nil
+	}
+	return remaining, nil
+}
+
+// RegisterFailure counts a failed attempt inside a fixed window that starts with the first failure.
+func (r *LoginAttemptRepositoryImpl) RegisterFailure(ctx context.Context, subject string, window time.Duration) (int64, error) {
+	key := loginFailuresKey(subject)
+
+	count, err := r.client.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if count == 1 {
+		if err := r.client.Expire(ctx, key, window).Err(); err != nil {
+			return 0, err
+		}
+	}
+	return count, nil
+}
+
+// IncrementLockoutLevel returns how many times the subject has been locked out within ttl, counting
+// the lockout that is about to start, so callers can grow each lockout exponentially.
+func (r *LoginAttemptRepositoryImpl) IncrementLockoutLevel(ctx context.Context, subject string, ttl time.Duration) (int64, error) {
+	pipe := r.client.TxPipeline()
+	level := pipe.Incr(ctx, loginLockoutLevelKey(subject))
+	pipe.Expire(ctx, loginLockoutLevelKey(subject), ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return level.Val(), nil
+}
+
+// Lock locks the subject out for the given duration and resets its failure counter.
+func (r *LoginAttemptRepositoryImpl) Lock(ctx context.Context, subject string, duration time.Duration) error {
+	pipe := r.client.TxPipeline()
+	pipe.Set(ctx, loginLockoutKey(subject), 1, duration)
+	pipe.Del(ctx, loginFailuresKey(subject))
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (r *LoginAttemptRepositoryImpl) ClearFailures(ctx context.Context, subject string) error {
+	return r.client.Del(ctx, loginFailuresKey(subject), loginLockoutLevelKey(subject)).Err()
+}
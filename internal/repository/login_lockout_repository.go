@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/temuka-api-service/internal/model"
+	"gorm.io/gorm"
+)
+
+type LoginLockoutRepository interface {
+	CreateLoginLockout(ctx context.Context, lockout *model.LoginLockout) error
+	GetLoginLockouts(ctx context.Context, limit int) ([]model.LoginLockout, error)
+}
+
+type LoginLockoutRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewLoginLockoutRepository(db *gorm.DB) LoginLockoutRepository {
+	return &LoginLockoutRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *LoginLockoutRepositoryImpl) CreateLoginLockout(ctx context.Context, lockout *model.LoginLockout) error {
+	return r.db.WithContext(ctx).Create(lockout).Error
+}
+
+func (r *LoginLockoutRepositoryImpl) GetLoginLockouts(ctx context.Context, limit int) ([]model.LoginLockout, error) {
+	var lockouts []model.LoginLockout
+	if err := r.db.WithContext(ctx).Order("created_at DESC").Limit(limit).Find(&lockouts).Error; err != nil {
+		return nil, err
+	}
+	return lockouts, nil
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/temuka-api-service/internal/model"
+	"gorm.io/gorm"
+)
+
+type MediaRepository interface {
+	CreateMedia(ctx context.Context, media *model.Media) error
+	GetMediaByIDs(ctx context.Context, userID int, ids []int) ([]model.Media, error)
+	GetPostMedia(ctx context.Context, postIDs []int) (map[int][]model.PostMedia, error)
+	ReplacePostMedia(ctx context.Context, postID int, media []model.PostMedia, image string) error
+}
+
+type MediaRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewMediaRepository(db *gorm.DB) MediaRepository {
+	return &MediaRepositoryImpl{db: db}
+}
+
+func (r *MediaRepositoryImpl) CreateMedia(ctx context.Context, media *model.Media) error {
+	return r.db.WithContext(ctx).Create(media).Error
+}
+
+// GetMediaByIDs returns the media among ids that the user uploaded.
+func (r *MediaRepositoryImpl) GetMediaByIDs(ctx context.Context, userID int, ids []int) ([]model.Media, error) {
+	var media []model.Media
+	if len(ids) == 0 {
+		return media, nil
+	}
+	if err := r.db.WithContext(ctx).Where("user_id = ? AND id IN ?", userID, ids).Find(&media).Error; err != nil {
+		return nil, err
+	}
+	return media, nil
+}
+
+// GetPostMedia returns the media of each post in order.
+func (r *MediaRepositoryImpl) GetPostMedia(ctx context.Context, postIDs []int) (map[int][]model.PostMedia, error) {
+	mediaByPostID := make(map[int][]model.PostMedia)
+	if len(postIDs) == 0 {
+		return mediaByPostID, nil
+	}
+
+	var postMedia []model.PostMedia
+	if err := r.db.WithContext(ctx).Preload("Media").Where("post_id IN ?", postIDs).Order("post_id, position").Find(&postMedia).Error; err != nil {
+		return nil, err
+	}
+	for _, item := range postMedia {
+		mediaByPostID[item.PostID] = append(mediaByPostID[item.PostID], item)
+	}
+	return mediaByPostID, nil
+}
+
+// ReplacePostMedia swaps the media of a post for the given ones and sets the post's image, which
+// older clients show as its only picture.
+func (r *MediaRepositoryImpl) ReplacePostMedia(ctx context.Context, postID int, media []model.PostMedia, image string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("post_id = ?", postID).Delete(&model.PostMedia{}).Error; err != nil {
+			return err
+		}
+		if len(media) > 0 {
+			for i := range media {
+				media[i].PostID = postID
+			}
+			if err := tx.Omit("Media").Create(&media).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&model.Post{}).Where("id = ?", postID).Update("image", image).Error
+	})
+}
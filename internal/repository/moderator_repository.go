@@ -10,6 +10,8 @@ import (
 type ModeratorRepository interface {
 	CreateModerator(ctx context.Context, moderator *model.Moderator) error
 	GetModeratorsByCommunityID(ctx context.Context, communityId int) ([]model.Moderator, error)
+	GetModeratorByID(ctx context.Context, id int) (*model.Moderator, error)
+	IsCommunityModerator(ctx context.Context, communityID, userID int) (bool, error)
 	DeleteModerator(ctx context.Context, id int) error
 }
 
@@ -29,12 +31,32 @@ func (r *ModeratorRepositoryImpl) CreateModerator(ctx context.Context, moderator
 
 func (r *ModeratorRepositoryImpl) GetModeratorsByCommunityID(ctx context.Context, communityId int) ([]model.Moderator, error) {
 	var moderators []model.Moderator
-	if err := r.db.WithContext(ctx).Where("community_id = ?", communityId).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("community_id = ?", communityId).Find(&moderators).Error; err != nil {
 		return nil, err
 	}
 	return moderators, nil
 }
 
+func (r *ModeratorRepositoryImpl) GetModeratorByID(ctx context.Context, id int) (*model.Moderator, error) {
+	var moderator model.Moderator
+	if err := r.db.WithContext(ctx).First(&moderator, id).Error; err != nil {
+		return nil, err
+	}
+	return &moderator, nil
+}
+
+func (r *ModeratorRepositoryImpl) IsCommunityModerator(ctx context.Context, communityID, userID int) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.Moderator{}).
+		Joins("INNER JOIN community_members cm ON cm.id = moderators.communitymember_id AND cm.deleted_at IS NULL").
+		Where("moderators.community_id = ? AND cm.user_id = ? AND cm.banned = false", communityID, userID).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
 func (r *ModeratorRepositoryImpl) DeleteModerator(ctx context.Context, id int) error {
 	return r.db.WithContext(ctx).Delete(&model.Moderator{}, id).Error
 }
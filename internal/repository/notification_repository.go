@@ -4,12 +4,14 @@ import (
 	"context"
 
 	"github.com/temuka-api-service/internal/model"
+	"github.com/temuka-api-service/pkg/pagination"
 	"gorm.io/gorm"
 )
 
 type NotificationRepository interface {
 	CreateNotification(ctx context.Context, notification *model.Notification) error
-	GetNotificationsByUserID(ctx context.Context, userId int) ([]model.Notification, error)
+	GetNotificationsByUserID(ctx context.Context, userId int, page pagination.Page) ([]model.Notification, string, error)
+	HasNotification(ctx context.Context, userID, actorID, postID int, notificationTypes []string) (bool, error)
 }
 
 type NotificationRepositoryImpl struct {
@@ -24,10 +26,22 @@ func (r *NotificationRepositoryImpl) CreateNotification(ctx context.Context, not
 	return r.db.WithContext(ctx).Create(notification).Error
 }
 
-func (r *NotificationRepositoryImpl) GetNotificationsByUserID(ctx context.Context, userId int) ([]model.Notification, error) {
+func (r *NotificationRepositoryImpl) GetNotificationsByUserID(ctx context.Context, userId int, page pagination.Page) ([]model.Notification, string, error) {
 	var notifications []model.Notification
-	if err := r.db.WithContext(ctx).Where("user_id", userId).Find(&notifications).Error; err != nil {
-		return nil, err
+	if err := r.db.WithContext(ctx).Where("user_id", userId).Scopes(page.Scope(true)).Find(&notifications).Error; err != nil {
+		return nil, "", err
 	}
-	return notifications, nil
+	notifications, nextCursor := pagination.Result(page, notifications, func(notification model.Notification) int { return notification.ID })
+	return notifications, nextCursor, nil
+}
+
+// HasNotification reports whether the actor already notified the user about the post with one of the given types.
+func (r *NotificationRepositoryImpl) HasNotification(ctx context.Context, userID, actorID, postID int, notificationTypes []string) (bool, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&model.Notification{}).
+		Where("user_id = ? AND actor_id = ? AND post_id = ? AND type IN ?", userID, actorID, postID, notificationTypes).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
 }
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/temuka-api-service/internal/model"
+)
+
+type OIDCStateRepository interface {
+	SaveState(ctx context.Context, state string, authState *model.OIDCAuthState, ttl time.Duration) error
+	ConsumeState(ctx context.Context, state string) (*model.OIDCAuthState, error)
+}
+
+type OIDCStateRepositoryImpl struct {
+	client *redis.Client
+}
+
+func NewOIDCStateRepository(client *redis.Client) OIDCStateRepository {
+	return &OIDCStateRepositoryImpl{
+		client: client,
+	}
+}
+
+func oidcStateKey(state string) string {
+	return fmt.Sprintf("oidc_state:%s", state)
+}
+
+func (r *OIDCStateRepositoryImpl) SaveState(ctx context.Context, state string, authState *model.OIDCAuthState, ttl time.Duration) error {
+	data, err := json.Marshal(authState)
+	if err != nil {
+		return err
+	}
+	return r.client.Set(ctx, oidcStateKey(state), data, ttl).Err()
+}
+
+// ConsumeState returns the stored state and deletes it, so a callback can only be completed once.
+func (r *OIDCStateRepositoryImpl) ConsumeState(ctx context.Context, state string) (*model.OIDCAuthState, error) {
+	data, err := r.client.GetDel(ctx, oidcStateKey(state)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var authState model.OIDCAuthState
+	if err := json.Unmarshal([]byte(data), &authState); err != nil {
+		return nil, err
+	}
+	return &authState, nil
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/temuka-api-service/internal/model"
+	"gorm.io/gorm"
+)
+
+type PasswordResetRepository interface {
+	CreatePasswordResetToken(ctx context.Context, resetToken *model.PasswordResetToken) error
+	GetPasswordResetTokenByHash(ctx context.Context, tokenHash string) (*model.PasswordResetToken, error)
+	ConsumePasswordResetToken(ctx context.Context, id int) (bool, error)
+	InvalidateUserPasswordResetTokens(ctx context.Context, userID int) error
+}
+
+type PasswordResetRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewPasswordResetRepository(db *gorm.DB) PasswordResetRepository {
+	return &PasswordResetRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *PasswordResetRepositoryImpl) CreatePasswordResetToken(ctx context.Context, resetToken *model.PasswordResetToken) error {
+	return r.db.WithContext(ctx).Create(resetToken).Error
+}
+
+func (r *PasswordResetRepositoryImpl) GetPasswordResetTokenByHash(ctx context.Context, tokenHash string) (*model.PasswordResetToken, error) {
+	var resetToken model.PasswordResetToken
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&resetToken).Error; err != nil {
+		return nil, err
+	}
+	return &resetToken, nil
+}
+
+// ConsumePasswordResetToken marks the token as used and reports false if it was already used or has expired.
+func (r *PasswordResetRepositoryImpl) ConsumePasswordResetToken(ctx context.Context, id int) (bool, error) {
+	now := time.Now()
+	result := r.db.WithContext(ctx).Model(&model.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL AND expires_at > ?", id, now).
+		Update("used_at", now)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *PasswordResetRepositoryImpl) InvalidateUserPasswordResetTokens(ctx context.Context, userID int) error {
+	return r.db.WithContext(ctx).Model(&model.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", time.Now()).Error
+}
@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/temuka-api-service/internal/model"
+	"github.com/temuka-api-service/pkg/pagination"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrPollClosed        = errors.New("poll is closed")
+	ErrAlreadyVoted      = errors.New("user already voted in this poll")
+	ErrInvalidPollOption = errors.New("invalid poll option")
+)
+
+type PollRepository interface {
+	GetPollByPostID(ctx context.Context, postID int) (*model.Poll, error)
+	GetPollsByPostIDs(ctx context.Context, postIDs []int) ([]model.Poll, error)
+	Vote(ctx context.Context, pollID, userID int, optionIDs []int, now time.Time) error
+	GetResults(ctx context.Context, pollIDs []int) (map[int]model.PollResults, error)
+	GetUserVotes(ctx context.Context, userID int, pollIDs []int) (map[int][]int, error)
+	GetVoters(ctx context.Context, pollID, optionID int, page pagination.Page) ([]model.PollVote, string, error)
+	ClaimClosedPolls(ctx context.Context, now time.Time, limit int) ([]model.Poll, error)
+}
+
+type PollRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewPollRepository(db *gorm.DB) PollRepository {
+	return &PollRepositoryImpl{db: db}
+}
+
+func preloadPollOptions(db *gorm.DB) *gorm.DB {
+	return db.Order("position ASC")
+}
+
+func (r *PollRepositoryImpl) GetPollByPostID(ctx context.Context, postID int) (*model.Poll, error) {
+	var poll model.Poll
+	if err := r.db.WithContext(ctx).Preload("Options", preloadPollOptions).Where("post_id = ?", postID).First(&poll).Error; err != nil {
+		return nil, err
+	}
+	return &poll, nil
+}
+
+func (r *PollRepositoryImpl) GetPollsByPostIDs(ctx context.Context, postIDs []int) ([]model.Poll, error) {
+	var polls []model.Poll
+	if len(postIDs) == 0 {
+		return polls, nil
+	}
+	if err := r.db.WithContext(ctx).Preload("Options", preloadPollOptions).Where("post_id IN ?", postIDs).Find(&polls).Error; err != nil {
+		return nil, err
+	}
+	return polls, nil
+}
+
+// Vote stores the user's choice. The poll row is locked while the vote is checked and written, so
+// that a user cannot vote twice by sending concurrent requests.
+func (r *PollRepositoryImpl) Vote(ctx context.Context, pollID, userID int, optionIDs []int, now time.Time) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var poll model.Poll
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&poll, pollID).Error; err != nil {
+			return err
+		}
+		if poll.IsClosed(now) {
+			return ErrPollClosed
+		}
+		if len(optionIDs) == 0 || (!poll.MultipleChoice && len(optionIDs) > 1) {
+			return ErrInvalidPollOption
+		}
+
+		var validOptions int64
+		if err := tx.Model(&model.PollOption{}).Where("poll_id = ? AND id IN ?", pollID, optionIDs).Count(&validOptions).Error; err != nil {
+			return err
+		}
+		if int(validOptions) != len(optionIDs) {
+			return ErrInvalidPollOption
+		}
+
+		var existingVotes int64
+		if err := tx.Model(&model.PollVote{}).Where("poll_id = ? AND user_id = ?", pollID, userID).Count(&existingVotes).Error; err != nil {
+			return err
+		}
+		if existingVotes > 0 {
+			return ErrAlreadyVoted
+		}
+
+		votes := make([]model.PollVote, 0, len(optionIDs))
+		for _, optionID := range optionIDs {
+			votes = append(votes, model.PollVote{PollID: pollID, OptionID: optionID, UserID: userID})
+		}
+		return tx.Create(&votes).Error
+	})
+}
+
+// GetResults counts the votes per option and the distinct voters of each poll. Closed is left for
+// the caller to fill in.
+func (r *PollRepositoryImpl) GetResults(ctx context.Context, pollIDs []int) (map[int]model.PollResults, error) {
+	results := make(map[int]model.PollResults, len(pollIDs))
+	if len(pollIDs) == 0 {
+		return results, nil
+	}
+	for _, pollID := range pollIDs {
+		results[pollID] = model.PollResults{PollID: pollID, Options: map[int]int64{}}
+	}
+
+	var optionRows []struct {
+		PollID   int
+		OptionID int
+		Count    int64
+	}
+	if err := r.db.WithContext(ctx).Model(&model.PollVote{}).
+		Select("poll_id, option_id, COUNT(*) AS count").
+		Where("poll_id IN ?", pollIDs).
+		Group("poll_id, option_id").
+		Scan(&optionRows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range optionRows {
+		results[row.PollID].Options[row.OptionID] = row.Count
+	}
+
+	var voterRows []struct {
+		PollID int
+		Count  int64
+	}
+	if err := r.db.WithContext(ctx).Model(&model.PollVote{}).
+		Select("poll_id, COUNT(DISTINCT user_id) AS count").
+		Where("poll_id IN ?", pollIDs).
+		Group("poll_id").
+		Scan(&voterRows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range voterRows {
+		result := results[row.PollID]
+		result.TotalVoters = row.Count
+		results[row.PollID] = result
+	}
+
+	return results, nil
+}
+
+// GetUserVotes returns the options the user chose per poll, for the polls the user voted in.
+func (r *PollRepositoryImpl) GetUserVotes(ctx context.Context, userID int, pollIDs []int) (map[int][]int, error) {
+	votes := make(map[int][]int)
+	if len(pollIDs) == 0 {
+		return votes, nil
+	}
+
+	var rows []model.PollVote
+	if err := r.db.WithContext(ctx).Where("user_id = ? AND poll_id IN ?", userID, pollIDs).Order("option_id").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		votes[row.PollID] = append(votes[row.PollID], row.OptionID)
+	}
+	return votes, nil
+}
+
+// GetVoters pages through the votes for an option, newest first.
+func (r *PollRepositoryImpl) GetVoters(ctx context.Context, pollID, optionID int, page pagination.Page) ([]model.PollVote, string, error) {
+	var votes []model.PollVote
+	if err := r.db.WithContext(ctx).Preload("User").
+		Where("poll_id = ? AND option_id = ?", pollID, optionID).
+		Scopes(page.Scope(true)).
+		Find(&votes).Error; err != nil {
+		return nil, "", err
+	}
+	votes, nextCursor := pagination.Result(page, votes, func(vote model.PollVote) int { return vote.ID })
+	return votes, nextCursor, nil
+}
+
+// ClaimClosedPolls marks up to limit polls whose close time has passed as closed and returns them.
+// Each poll is returned to exactly one caller, so its author is notified once.
+func (r *PollRepositoryImpl) ClaimClosedPolls(ctx context.Context, now time.Time, limit int) ([]model.Poll, error) {
+	var polls []model.Poll
+	err := r.db.WithContext(ctx).Raw(`UPDATE polls SET closed_at = @now, updated_at = @now
+	WHERE id IN (
+		SELECT id FROM polls
+		WHERE deleted_at IS NULL AND closed_at IS NULL AND closes_at <= @now
+		ORDER BY closes_at, id
+		LIMIT @limit
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING *`, map[string]interface{}{
+		"now":   now,
+		"limit": limit,
+	}).Scan(&polls).Error
+	if err != nil {
+		return nil, err
+	}
+	return polls, nil
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/temuka-api-service/internal/model"
+)
+
+// PollStreamRepository relays poll results between server instances, so that a client streaming a
+// poll from one instance sees votes cast through any other.
+type PollStreamRepository interface {
+	PublishResults(ctx context.Context, results model.PollResults) error
+	SubscribeResults(ctx context.Context, pollID int) (<-chan model.PollResults, func() error)
+}
+
+type PollStreamRepositoryImpl struct {
+	client *redis.Client
+}
+
+func NewPollStreamRepository(client *redis.Client) PollStreamRepository {
+	return &PollStreamRepositoryImpl{
+		client: client,
+	}
+}
+
+func pollResultsChannel(pollID int) string {
+	return fmt.Sprintf("poll:%d", pollID)
+}
+
+func (r *PollStreamRepositoryImpl) PublishResults(ctx context.Context, results model.PollResults) error {
+	data, err := json.Marshal(results)
+	if err != nil {
+		return err
+	}
+	return r.client.Publish(ctx, pollResultsChannel(results.PollID), data).Err()
+}
+
+// SubscribeResults delivers the results published for the poll until ctx is done or the returned
+// close function is called. Slow readers miss intermediate results rather than block the relay.
+func (r *PollStreamRepositoryImpl) SubscribeResults(ctx context.Context, pollID int) (<-chan model.PollResults, func() error) {
+	sub := r.client.Subscribe(ctx, pollResultsChannel(pollID))
+	results := make(chan model.PollResults, 1)
+
+	go func() {
+		defer close(results)
+		for msg := range sub.Channel() {
+			var result model.PollResults
+			if err := json.Unmarshal([]byte(msg.Payload), &result); err != nil {
+				log.Printf("Error decoding results of poll %d: %v", pollID, err)
+				continue
+			}
+
+			select {
+			case results <- result:
+			case <-ctx.Done():
+				return
+			default:
+				// Drop the stale result the reader has not picked up yet in favour of this one.
+				select {
+				case <-results:
+				default:
+				}
+				results <- result
+			}
+		}
+	}()
+
+	return results, sub.Close
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/temuka-api-service/internal/model"
+	"github.com/temuka-api-service/pkg/pagination"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PostReactionRepository interface {
+	UpsertReaction(ctx context.Context, reaction *model.PostReaction) error
+	DeleteReaction(ctx context.Context, postID, userID int) (bool, error)
+	GetReactions(ctx context.Context, postID int, reactionType string, page pagination.Page) ([]model.PostReaction, string, error)
+	GetReactionCounts(ctx context.Context, postIDs []int) (map[int]map[string]int64, error)
+	GetUserReactions(ctx context.Context, userID int, postIDs []int) (map[int]string, error)
+}
+
+type PostReactionRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewPostReactionRepository(db *gorm.DB) PostReactionRepository {
+	return &PostReactionRepositoryImpl{db: db}
+}
+
+// UpsertReaction stores the user's reaction to a post, replacing the type of an existing one.
+func (r *PostReactionRepositoryImpl) UpsertReaction(ctx context.Context, reaction *model.PostReaction) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "post_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"type", "updated_at"}),
+	}).Create(reaction).Error
+}
+
+// DeleteReaction removes the user's reaction to a post and reports false if there was none.
+func (r *PostReactionRepositoryImpl) DeleteReaction(ctx context.Context, postID, userID int) (bool, error) {
+	result := r.db.WithContext(ctx).Unscoped().Where("post_id = ? AND user_id = ?", postID, userID).Delete(&model.PostReaction{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// GetReactions pages through the reactions to a post, newest first. An empty reactionType matches every type.
+func (r *PostReactionRepositoryImpl) GetReactions(ctx context.Context, postID int, reactionType string, page pagination.Page) ([]model.PostReaction, string, error) {
+	query := r.db.WithContext(ctx).Preload("User").Where("post_id = ?", postID)
+	if reactionType != "" {
+		query = query.Where("type = ?", reactionType)
+	}
+
+	var reactions []model.PostReaction
+	if err := query.Scopes(page.Scope(true)).Find(&reactions).Error; err != nil {
+		return nil, "", err
+	}
+	reactions, nextCursor := pagination.Result(page, reactions, func(reaction model.PostReaction) int { return reaction.ID })
+	return reactions, nextCursor, nil
+}
+
+// GetReactionCounts returns the number of reactions of each type per post.
+func (r *PostReactionRepositoryImpl) GetReactionCounts(ctx context.Context, postIDs []int) (map[int]map[string]int64, error) {
+	counts := make(map[int]map[string]int64, len(postIDs))
+	if len(postIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		PostID int
+		Type   string
+		Count  int64
+	}
+	if err := r.db.WithContext(ctx).Model(&model.PostReaction{}).
+		Select("post_id, type, COUNT(*) AS count").
+		Where("post_id IN ?", postIDs).
+		Group("post_id, type").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		if counts[row.PostID] == nil {
+			counts[row.PostID] = make(map[string]int64)
+		}
+		counts[row.PostID][row.Type] = row.Count
+	}
+	return counts, nil
+}
+
+// GetUserReactions returns the user's reaction type per post, for the posts the user reacted to.
+func (r *PostReactionRepositoryImpl) GetUserReactions(ctx context.Context, userID int, postIDs []int) (map[int]string, error) {
+	reactionTypes := make(map[int]string)
+	if len(postIDs) == 0 {
+		return reactionTypes, nil
+	}
+
+	var reactions []model.PostReaction
+	if err := r.db.WithContext(ctx).Where("user_id = ? AND post_id IN ?", userID, postIDs).Find(&reactions).Error; err != nil {
+		return nil, err
+	}
+	for _, reaction := range reactions {
+		reactionTypes[reaction.PostID] = reaction.Type
+	}
+	return reactionTypes, nil
+}
@@ -2,17 +2,37 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/temuka-api-service/internal/model"
+	"github.com/temuka-api-service/pkg/pagination"
 	"gorm.io/gorm"
 )
 
 type PostRepository interface {
 	CreatePost(ctx context.Context, post *model.Post) error
 	GetPostDetailByID(ctx context.Context, id int) (*model.Post, error)
-	GetPostsByUserID(ctx context.Context, userId int) ([]model.Post, error)
+	GetPostByIDAnyStatus(ctx context.Context, id int) (*model.Post, error)
+	GetUnpublishedPostsByUserID(ctx context.Context, userID int, page pagination.Page) ([]model.Post, string, error)
+	GetPostsByUserID(ctx context.Context, userId int, page pagination.Page) ([]model.Post, string, error)
+	GetPostsByIDs(ctx context.Context, ids []int) ([]model.Post, error)
+	GetPostsByUserIDsBefore(ctx context.Context, userIDs []int, before time.Time, beforeID int, limit int) ([]model.Post, error)
 	UpdatePost(ctx context.Context, id int, post *model.Post) error
 	DeletePost(ctx context.Context, id int) error
+	SharePost(ctx context.Context, post *model.Post) error
+	GetRepost(ctx context.Context, userID, originalPostID int) (*model.Post, error)
+	UpdatePostSchedule(ctx context.Context, id int, status string, publishAt *time.Time) error
+	ClaimPost(ctx context.Context, id int, now time.Time) error
+	ClaimDuePosts(ctx context.Context, now, staleBefore time.Time, limit int) ([]model.Post, error)
+	MarkPublished(ctx context.Context, post *model.Post) error
+}
+
+var ErrPostAlreadyPublished = errors.New("post has already been published")
+
+// publishedPosts hides drafts, scheduled posts and posts that are still being published.
+func publishedPosts(db *gorm.DB) *gorm.DB {
+	return db.Where("posts.status = ?", model.PostStatusPublished)
 }
 
 type PostRepositoryImpl struct {
@@ -27,7 +47,17 @@ func (r *PostRepositoryImpl) CreatePost(ctx context.Context, post *model.Post) e
 	return r.db.WithContext(ctx).Create(post).Error
 }
 
+// GetPostDetailByID returns a published post.
 func (r *PostRepositoryImpl) GetPostDetailByID(ctx context.Context, id int) (*model.Post, error) {
+	var post model.Post
+	if err := r.db.WithContext(ctx).Scopes(publishedPosts).First(&post, id).Error; err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+// GetPostByIDAnyStatus also returns drafts and scheduled posts, for their author.
+func (r *PostRepositoryImpl) GetPostByIDAnyStatus(ctx context.Context, id int) (*model.Post, error) {
 	var post model.Post
 	if err := r.db.WithContext(ctx).First(&post, id).Error; err != nil {
 		return nil, err
@@ -35,17 +65,174 @@ func (r *PostRepositoryImpl) GetPostDetailByID(ctx context.Context, id int) (*mo
 	return &post, nil
 }
 
+// GetUnpublishedPostsByUserID pages through the user's drafts and scheduled posts, newest first.
+func (r *PostRepositoryImpl) GetUnpublishedPostsByUserID(ctx context.Context, userID int, page pagination.Page) ([]model.Post, string, error) {
+	var posts []model.Post
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ? AND status <> ?", userID, model.PostStatusPublished).
+		Scopes(page.Scope(true)).
+		Find(&posts).Error; err != nil {
+		return nil, "", err
+	}
+	posts, nextCursor := pagination.Result(page, posts, func(post model.Post) int { return post.ID })
+	return posts, nextCursor, nil
+}
+
+// DeletePost soft-deletes a post. Deleting a repost or quote post also takes it off the share count
+// of its original.
 func (r *PostRepositoryImpl) DeletePost(ctx context.Context, id int) error {
-	return r.db.WithContext(ctx).Delete(&model.Post{}, id).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var post model.Post
+		if err := tx.First(&post, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&model.Post{}, id).Error; err != nil {
+			return err
+		}
+		if post.OriginalPostID == nil {
+			return nil
+		}
+		return tx.Unscoped().Model(&model.Post{}).Where("id = ?", *post.OriginalPostID).
+			UpdateColumn("shares_count", gorm.Expr("GREATEST(shares_count - 1, 0)")).Error
+	})
+}
+
+// SharePost creates a repost or quote post and counts it as a share of the original.
+func (r *PostRepositoryImpl) SharePost(ctx context.Context, post *model.Post) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(post).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.Post{}).Where("id = ?", *post.OriginalPostID).
+			UpdateColumn("shares_count", gorm.Expr("shares_count + 1")).Error
+	})
+}
+
+func (r *PostRepositoryImpl) GetRepost(ctx context.Context, userID, originalPostID int) (*model.Post, error) {
+	var post model.Post
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ? AND original_post_id = ? AND share_type = ?", userID, originalPostID, model.ShareTypeRepost).
+		First(&post).Error; err != nil {
+		return nil, err
+	}
+	return &post, nil
 }
 
 func (r *PostRepositoryImpl) UpdatePost(ctx context.Context, id int, post *model.Post) error {
 	return r.db.WithContext(ctx).Model(&model.Post{}).Where("id = ?", id).Updates(post).Error
 }
 
-func (r *PostRepositoryImpl) GetPostsByUserID(ctx context.Context, userId int) ([]model.Post, error) {
+// UpdatePostSchedule turns a post that is not live yet into a draft or reschedules it. It returns
+// ErrPostAlreadyPublished once the post has gone live.
+func (r *PostRepositoryImpl) UpdatePostSchedule(ctx context.Context, id int, status string, publishAt *time.Time) error {
+	result := r.db.WithContext(ctx).Model(&model.Post{}).
+		Where("id = ? AND status IN ?", id, []string{model.PostStatusDraft, model.PostStatusScheduled}).
+		Select("status", "publish_at").
+		Updates(&model.Post{Status: status, PublishAt: publishAt})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrPostAlreadyPublished
+	}
+	return nil
+}
+
+// ClaimPost moves a draft or scheduled post into the publishing state so that no other request or
+// scheduler instance publishes it too. It returns ErrPostAlreadyPublished when the post was claimed before.
+func (r *PostRepositoryImpl) ClaimPost(ctx context.Context, id int, now time.Time) error {
+	result := r.db.WithContext(ctx).Model(&model.Post{}).
+		Where("id = ? AND status IN ?", id, []string{model.PostStatusDraft, model.PostStatusScheduled}).
+		Updates(map[string]interface{}{"status": model.PostStatusPublishing, "published_at": now})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrPostAlreadyPublished
+	}
+	return nil
+}
+
+// ClaimDuePosts claims up to limit scheduled posts whose publish time has passed, together with posts
+// left in the publishing state since before staleBefore by an instance that did not finish them.
+// Rows locked by a concurrent claim are skipped, so every post is handed to a single caller.
+func (r *PostRepositoryImpl) ClaimDuePosts(ctx context.Context, now, staleBefore time.Time, limit int) ([]model.Post, error) {
+	var posts []model.Post
+	err := r.db.WithContext(ctx).Raw(`UPDATE posts SET status = @publishing, published_at = @now, updated_at = @now
+	WHERE id IN (
+		SELECT id FROM posts
+		WHERE deleted_at IS NULL AND (
+			(status = @scheduled AND publish_at <= @now) OR
+			(status = @publishing AND published_at < @stale_before))
+		ORDER BY publish_at, id
+		LIMIT @limit
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING *`, map[string]interface{}{
+		"publishing":   model.PostStatusPublishing,
+		"scheduled":    model.PostStatusScheduled,
+		"now":          now,
+		"stale_before": staleBefore,
+		"limit":        limit,
+	}).Scan(&posts).Error
+	if err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+// MarkPublished finishes publishing a claimed post and counts it in its community. It does nothing
+// when another instance finished the post first.
+func (r *PostRepositoryImpl) MarkPublished(ctx context.Context, post *model.Post) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Post{}).
+			Where("id = ? AND status = ?", post.ID, model.PostStatusPublishing).
+			Update("status", model.PostStatusPublished)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 || post.CommunityID == nil {
+			return nil
+		}
+		return tx.Model(&model.Community{}).Where("id = ?", *post.CommunityID).
+			Update("posts_count", gorm.Expr("posts_count + 1")).Error
+	})
+}
+
+func (r *PostRepositoryImpl) GetPostsByUserID(ctx context.Context, userId int, page pagination.Page) ([]model.Post, string, error) {
 	var posts []model.Post
-	if err := r.db.WithContext(ctx).Where("user_id", userId).Find(&posts).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("user_id", userId).Scopes(publishedPosts, page.Scope(true)).Find(&posts).Error; err != nil {
+		return nil, "", err
+	}
+	posts, nextCursor := pagination.Result(page, posts, func(post model.Post) int { return post.ID })
+	return posts, nextCursor, nil
+}
+
+func (r *PostRepositoryImpl) GetPostsByIDs(ctx context.Context, ids []int) ([]model.Post, error) {
+	var posts []model.Post
+	if len(ids) == 0 {
+		return posts, nil
+	}
+	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Scopes(publishedPosts).Find(&posts).Error; err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+// GetPostsByUserIDsBefore returns the newest posts of the given users that went live before the
+// (before, beforeID) position, ordered newest first.
+func (r *PostRepositoryImpl) GetPostsByUserIDsBefore(ctx context.Context, userIDs []int, before time.Time, beforeID int, limit int) ([]model.Post, error) {
+	var posts []model.Post
+	if len(userIDs) == 0 {
+		return posts, nil
+	}
+	if err := r.db.WithContext(ctx).
+		Where("user_id IN ?", userIDs).
+		Scopes(publishedPosts).
+		Where("published_at < ? OR (published_at = ? AND id < ?)", before, before, beforeID).
+		Order("published_at DESC, id DESC").
+		Limit(limit).
+		Find(&posts).Error; err != nil {
 		return nil, err
 	}
 	return posts, nil
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/temuka-api-service/internal/model"
+	"github.com/temuka-api-service/pkg/pagination"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PostRevisionRepository interface {
+	RevisePost(ctx context.Context, postID, editorID int, title, description *string, revertedFromID *int) (*model.Post, *model.PostRevision, error)
+	GetRevisions(ctx context.Context, postID int, page pagination.Page) ([]model.PostRevision, string, error)
+	GetRevision(ctx context.Context, postID, revisionID int) (*model.PostRevision, error)
+}
+
+type PostRevisionRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewPostRevisionRepository(db *gorm.DB) PostRevisionRepository {
+	return &PostRevisionRepositoryImpl{db: db}
+}
+
+// RevisePost changes the title and description of a published post and records the change as a
+// revision. A nil title or description keeps the current one. The post row is locked while the
+// revision is written so that concurrent edits each record the text they replaced. The revision is
+// nil when nothing changed.
+func (r *PostRevisionRepositoryImpl) RevisePost(ctx context.Context, postID, editorID int, title, description *string, revertedFromID *int) (*model.Post, *model.PostRevision, error) {
+	var post model.Post
+	var revision *model.PostRevision
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Scopes(publishedPosts).First(&post, postID).Error; err != nil {
+			return err
+		}
+
+		newTitle, newDescription := post.Title, post.Description
+		if title != nil {
+			newTitle = *title
+		}
+		if description != nil {
+			newDescription = *description
+		}
+		if newTitle == post.Title && newDescription == post.Description {
+			return nil
+		}
+
+		revision = &model.PostRevision{
+			PostID:         postID,
+			EditorID:       editorID,
+			OldTitle:       post.Title,
+			NewTitle:       newTitle,
+			OldDescription: post.Description,
+			NewDescription: newDescription,
+			RevertedFromID: revertedFromID,
+		}
+		if err := tx.Create(revision).Error; err != nil {
+			return err
+		}
+
+		post.Title, post.Description = newTitle, newDescription
+		post.Edited = true
+		post.EditedAt = &revision.CreatedAt
+		return tx.Model(&model.Post{}).Where("id = ?", postID).Updates(map[string]interface{}{
+			"title":     newTitle,
+			"desc":      newDescription,
+			"edited":    true,
+			"edited_at": revision.CreatedAt,
+		}).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &post, revision, nil
+}
+
+// GetRevisions pages through the revisions of a post, newest first.
+func (r *PostRevisionRepositoryImpl) GetRevisions(ctx context.Context, postID int, page pagination.Page) ([]model.PostRevision, string, error) {
+	var revisions []model.PostRevision
+	if err := r.db.WithContext(ctx).Preload("Editor").Where("post_id = ?", postID).Scopes(page.Scope(true)).Find(&revisions).Error; err != nil {
+		return nil, "", err
+	}
+	revisions, nextCursor := pagination.Result(page, revisions, func(revision model.PostRevision) int { return revision.ID })
+	return revisions, nextCursor, nil
+}
+
+func (r *PostRevisionRepositoryImpl) GetRevision(ctx context.Context, postID, revisionID int) (*model.PostRevision, error) {
+	var revision model.PostRevision
+	if err := r.db.WithContext(ctx).Where("post_id = ?", postID).First(&revision, revisionID).Error; err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/temuka-api-service/internal/model"
+	"gorm.io/gorm"
+)
+
+type RecommendationRepository interface {
+	GetCandidatePosts(ctx context.Context, userID int, authorIDs, communityIDs []int, since time.Time, limit int) ([]model.Post, error)
+	GetPostEngagement(ctx context.Context, postIDs []int, recentSince time.Time) (map[int]model.PostEngagement, error)
+	GetAuthorAffinity(ctx context.Context, userID int, authorIDs []int) (map[int]int64, error)
+	GetReviewedUniversityIDs(ctx context.Context, userID int) ([]int, error)
+	GetUniversityCommunityIDs(ctx context.Context, universityIDs []int) ([]int, error)
+	GetJoinedCommunities(ctx context.Context, userID int) ([]model.Community, error)
+}
+
+type RecommendationRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewRecommendationRepository(db *gorm.DB) RecommendationRepository {
+	return &RecommendationRepositoryImpl{
+		db: db,
+	}
+}
+
+// GetCandidatePosts returns recent posts by the given authors or in the given communities, excluding the user's own posts.
+func (r *RecommendationRepositoryImpl) GetCandidatePosts(ctx context.Context, userID int, authorIDs, communityIDs []int, since time.Time, limit int) ([]model.Post, error) {
+	var posts []model.Post
+	if len(authorIDs) == 0 && len(communityIDs) == 0 {
+		return posts, nil
+	}
+
+	sources := r.db.Where("1 = 0")
+	if len(authorIDs) > 0 {
+		sources = sources.Or("user_id IN ?", authorIDs)
+	}
+	if len(communityIDs) > 0 {
+		sources = sources.Or("community_id IN ?", communityIDs)
+	}
+
+	if err := r.db.WithContext(ctx).
+		Where(sources).
+		Scopes(publishedPosts).
+		Where("user_id <> ? AND published_at >= ?", userID, since).
+		Order("published_at DESC").
+		Limit(limit).
+		Find(&posts).Error; err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+func (r *RecommendationRepositoryImpl) GetPostEngagement(ctx context.Context, postIDs []int, recentSince time.Time) (map[int]model.PostEngagement, error) {
+	engagement := make(map[int]model.PostEngagement, len(postIDs))
+	if len(postIDs) == 0 {
+		return engagement, nil
+	}
+
+	var reactions []struct {
+		PostID int
+		Count  int64
+	}
+	if err := r.db.WithContext(ctx).Model(&model.PostReaction{}).
+		Select("post_id, COUNT(*) AS count").
+		Where("post_id IN ?", postIDs).
+		Group("post_id").
+		Scan(&reactions).Error; err != nil {
+		return nil, err
+	}
+
+	var comments []struct {
+		PostID int
+		Total  int64
+		Recent int64
+	}
+	if err := r.db.WithContext(ctx).Model(&model.Comment{}).
+		Select("post_id, COUNT(*) AS total, COUNT(*) FILTER (WHERE created_at >= ?) AS recent", recentSince).
+		Where("post_id IN ?", postIDs).
+		Group("post_id").
+		Scan(&comments).Error; err != nil {
+		return nil, err
+	}
+
+	for _, postID := range postIDs {
+		engagement[postID] = model.PostEngagement{PostID: postID}
+	}
+	for _, row := range reactions {
+		e := engagement[row.PostID]
+		e.Reactions = row.Count
+		engagement[row.PostID] = e
+	}
+	for _, row := range comments {
+		e := engagement[row.PostID]
+		e.Comments = row.Total
+		e.RecentComments = row.Recent
+		engagement[row.PostID] = e
+	}
+
+	return engagement, nil
+}
+
+// GetAuthorAffinity counts how often the user reacted to or commented on posts of each author.
+func (r *RecommendationRepositoryImpl) GetAuthorAffinity(ctx context.Context, userID int, authorIDs []int) (map[int]int64, error) {
+	affinity := make(map[int]int64, len(authorIDs))
+	if len(authorIDs) == 0 {
+		return affinity, nil
+	}
+
+	query := `
+		SELECT author_id, COUNT(*) AS count FROM (
+			SELECT p.user_id AS author_id
+			FROM post_reactions pr
+			INNER JOIN posts p ON p.id = pr.post_id
+			WHERE pr.user_id = ? AND p.user_id IN ? AND pr.deleted_at IS NULL
+			UNION ALL
+			SELECT p.user_id AS author_id
+			FROM comments c
+			INNER JOIN posts p ON p.id = c.post_id
+			WHERE c.user_id = ? AND p.user_id IN ? AND c.deleted_at IS NULL
+		) interactions
+		GROUP BY author_id
+	`
+
+	var rows []struct {
+		AuthorID int
+		Count    int64
+	}
+	if err := r.db.WithContext(ctx).Raw(query, userID, authorIDs, userID, authorIDs).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		affinity[row.AuthorID] = row.Count
+	}
+	return affinity, nil
+}
+
+func (r *RecommendationRepositoryImpl) GetReviewedUniversityIDs(ctx context.Context, userID int) ([]int, error) {
+	var universityIDs []int
+	if err := r.db.WithContext(ctx).Model(&model.Review{}).Where("user_id = ?", userID).Distinct().Pluck("university_id", &universityIDs).Error; err != nil {
+		return nil, err
+	}
+	return universityIDs, nil
+}
+
+func (r *RecommendationRepositoryImpl) GetUniversityCommunityIDs(ctx context.Context, universityIDs []int) ([]int, error) {
+	var communityIDs []int
+	if len(universityIDs) == 0 {
+		return communityIDs, nil
+	}
+	if err := r.db.WithContext(ctx).Model(&model.Community{}).Where("university_id IN ?", universityIDs).Pluck("id", &communityIDs).Error; err != nil {
+		return nil, err
+	}
+	return communityIDs, nil
+}
+
+// GetJoinedCommunities returns every community the user is a member of and not banned from.
+func (r *RecommendationRepositoryImpl) GetJoinedCommunities(ctx context.Context, userID int) ([]model.Community, error) {
+	var communities []model.Community
+	if err := r.db.WithContext(ctx).
+		Joins("JOIN community_members ON community_members.community_id = communities.id").
+		Where("community_members.user_id = ? AND community_members.banned = false", userID).
+		Find(&communities).Error; err != nil {
+		return nil, err
+	}
+	return communities, nil
+}
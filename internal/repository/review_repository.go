@@ -4,13 +4,14 @@ import (
 	"context"
 
 	"github.com/temuka-api-service/internal/model"
+	"github.com/temuka-api-service/pkg/pagination"
 	"gorm.io/gorm"
 )
 
 type ReviewRepository interface {
 	CreateReview(ctx context.Context, review *model.Review) error
 	DeleteReview(ctx context.Context, id int) error
-	GetReviewsByUniversityID(ctx context.Context, universityID int) ([]model.Review, error)
+	GetReviewsByUniversityID(ctx context.Context, universityID int, page pagination.Page) ([]model.Review, string, error)
 }
 
 type ReviewRepositoryImpl struct {
@@ -31,10 +32,12 @@ func (r *ReviewRepositoryImpl) DeleteReview(ctx context.Context, id int) error {
 	return r.db.WithContext(ctx).Delete(&model.Review{}, id).Error
 }
 
-func (r *ReviewRepositoryImpl) GetReviewsByUniversityID(ctx context.Context, universityID int) ([]model.Review, error) {
+// GetReviewsByUniversityID pages through the reviews of a university, newest first.
+func (r *ReviewRepositoryImpl) GetReviewsByUniversityID(ctx context.Context, universityID int, page pagination.Page) ([]model.Review, string, error) {
 	var reviews []model.Review
-	if err := r.db.WithContext(ctx).Where("university_id = ?", universityID).Find(&reviews).Error; err != nil {
-		return nil, err
+	if err := r.db.WithContext(ctx).Where("university_id = ?", universityID).Scopes(page.Scope(true)).Find(&reviews).Error; err != nil {
+		return nil, "", err
 	}
-	return reviews, nil
+	reviews, nextCursor := pagination.Result(page, reviews, func(review model.Review) int { return review.ID })
+	return reviews, nextCursor, nil
 }
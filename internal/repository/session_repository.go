@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/temuka-api-service/internal/model"
+)
+
+type SessionRepository interface {
+	CreateSession(ctx context.Context, session *model.Session, ttl time.Duration) error
+	GetSession(ctx context.Context, id string) (*model.Session, error)
+	SessionExists(ctx context.Context, id string) (bool, error)
+	RotateRefreshToken(ctx context.Context, id string, userID int, oldHash, newHash string, ttl time.Duration) (bool, error)
+	DeleteSession(ctx context.Context, id string) error
+	DeleteUserSessions(ctx context.Context, userID int) error
+}
+
+type SessionRepositoryImpl struct {
+	client *redis.Client
+}
+
+func NewSessionRepository(client *redis.Client) SessionRepository {
+	return &SessionRepositoryImpl{
+		client: client,
+	}
+}
+
+// rotateScript swaps the refresh token hash only if the presented one is still current,
+// so two concurrent refreshes with the same token cannot both succeed. The user's session index
+// is extended along with the session, so that revoking all sessions still finds it.
+var rotateScript = redis.NewScript(`
+if redis.call("HGET", KEYS[1], "refresh_token_hash") == ARGV[1] then
+	redis.call("HSET", KEYS[1], "refresh_token_hash", ARGV[2], "last_used_at", ARGV[3])
+	redis.call("PEXPIRE", KEYS[1], ARGV[4])
+	redis.call("PEXPIRE", KEYS[2], ARGV[4])
+	return 1
+end
+return 0
+`)
+
+func sessionKey(id string) string {
+	return fmt.Sprintf("session:%s", id)
+}
+
+func userSessionsKey(userID int) string {
+	return fmt.Sprintf("user_sessions:%d", userID)
+}
+
+func (r *SessionRepositoryImpl) CreateSession(ctx context.Context, session *model.Session, ttl time.Duration) error {
+	pipe := r.client.TxPipeline()
+	pipe.HSet(ctx, sessionKey(session.ID), map[string]interface{}{
+		"user_id":            session.UserID,
+		"refresh_token_hash": session.RefreshTokenHash,
+		"user_agent":         session.UserAgent,
+		"ip_address":         session.IPAddress,
+		"created_at":         session.CreatedAt.Unix(),
+		"last_used_at":       session.LastUsedAt.Unix(),
+	})
+	pipe.Expire(ctx, sessionKey(session.ID), ttl)
+	pipe.SAdd(ctx, userSessionsKey(session.UserID), session.ID)
+	pipe.Expire(ctx, userSessionsKey(session.UserID), ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (r *SessionRepositoryImpl) GetSession(ctx context.Context, id string) (*model.Session, error) {
+	values, err := r.client.HGetAll(ctx, sessionKey(id)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, redis.Nil
+	}
+
+	userID, _ := strconv.Atoi(values["user_id"])
+	createdAt, _ := strconv.ParseInt(values["created_at"], 10, 64)
+	lastUsedAt, _ := strconv.ParseInt(values["last_used_at"], 10, 64)
+
+	return &model.Session{
+		ID:               id,
+		UserID:           userID,
+		RefreshTokenHash: values["refresh_token_hash"],
+		UserAgent:        values["user_agent"],
+		IPAddress:        values["ip_address"],
+		CreatedAt:        time.Unix(createdAt, 0),
+		LastUsedAt:       time.Unix(lastUsedAt, 0),
+	}, nil
+}
+
+func (r *SessionRepositoryImpl) SessionExists(ctx context.Context, id string) (bool, error) {
+	count, err := r.client.Exists(ctx, sessionKey(id)).Result()
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *SessionRepositoryImpl) RotateRefreshToken(ctx context.Context, id string, userID int, oldHash, newHash string, ttl time.Duration) (bool, error) {
+	result, err := rotateScript.Run(ctx, r.client, []string{sessionKey(id), userSessionsKey(userID)},
+		oldHash, newHash, time.Now().Unix(), ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return result == 1, nil
+}
+
+func (r *SessionRepositoryImpl) DeleteSession(ctx context.Context, id string) error {
+	userID, err := r.client.HGet(ctx, sessionKey(id), "user_id").Int()
+	if err != nil && err != redis.Nil {
+		return err
+	}
+
+	pipe := r.client.TxPipeline()
+	pipe.Del(ctx, sessionKey(id))
+	if err == nil {
+		pipe.SRem(ctx, userSessionsKey(userID), id)
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (r *SessionRepositoryImpl) DeleteUserSessions(ctx context.Context, userID int) error {
+	ids, err := r.client.SMembers(ctx, userSessionsKey(userID)).Result()
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(ids)+1)
+	for _, id := range ids {
+		keys = append(keys, sessionKey(id))
+	}
+	keys = append(keys, userSessionsKey(userID))
+
+	return r.client.Del(ctx, keys...).Err()
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/temuka-api-service/internal/model"
+)
+
+func TestSessionRepository(t *testing.T) {
+	const (
+		userID = 7
+		ttl    = time.Hour
+	)
+
+	tests := []struct {
+		name string
+		run  func(t *testing.T, server *fakeRedis, repo SessionRepository)
+	}{
+		{
+			name: "revokes sessions refreshed past the ttl of the login",
+			run: func(t *testing.T, server *fakeRedis, repo SessionRepository) {
+				ctx := context.Background()
+				hash := "hash-0"
+				for i := 1; i <= 3; i++ {
+					server.advance(ttl / 2)
+					newHash := fmt.Sprintf("hash-%d", i)
+					rotated, err := repo.RotateRefreshToken(ctx, "refreshed", userID, hash, newHash, ttl)
+					if err != nil || !rotated {
+						t.Fatalf("refresh %d: rotated = %v, err = %v", i, rotated, err)
+					}
+					hash = newHash
+				}
+				if exists, _ := repo.SessionExists(ctx, "refreshed"); !exists {
+					t.Fatal("refreshed session expired")
+				}
+
+				if err := repo.DeleteUserSessions(ctx, userID); err != nil {
+					t.Fatal(err)
+				}
+				if exists, _ := repo.SessionExists(ctx, "refreshed"); exists {
+					t.Error("refreshed session survived revoking all sessions")
+				}
+			},
+		},
+		{
+			name: "rejects a rotated refresh token",
+			run: func(t *testing.T, server *fakeRedis, repo SessionRepository) {
+				ctx := context.Background()
+				if rotated, err := repo.RotateRefreshToken(ctx, "refreshed", userID, "hash-0", "hash-1", ttl); err != nil || !rotated {
+					t.Fatalf("rotated = %v, err = %v", rotated, err)
+				}
+				if rotated, err := repo.RotateRefreshToken(ctx, "refreshed", userID, "hash-0", "hash-2", ttl); err != nil || rotated {
+					t.Errorf("replayed token: rotated = %v, err = %v", rotated, err)
+				}
+			},
+		},
+		{
+			name: "expires sessions that are not refreshed",
+			run: func(t *testing.T, server *fakeRedis, repo SessionRepository) {
+				server.advance(ttl)
+				if exists, _ := repo.SessionExists(context.Background(), "refreshed"); exists {
+					t.Error("session outlived its ttl")
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, client := newFakeRedis(t)
+			repo := NewSessionRepository(client)
+			session := &model.Session{
+				ID:               "refreshed",
+				UserID:           userID,
+				RefreshTokenHash: "hash-0",
+				CreatedAt:        server.now,
+				LastUsedAt:       server.now,
+			}
+			if err := repo.CreateSession(context.Background(), session, ttl); err != nil {
+				t.Fatal(err)
+			}
+			tt.run(t, server, repo)
+		})
+	}
+}
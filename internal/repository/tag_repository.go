@@ -0,0 +1,218 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/temuka-api-service/internal/model"
+	"github.com/temuka-api-service/pkg/pagination"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TagRepository interface {
+	ReplacePostTags(ctx context.Context, postID int, tags []string, mentionedUserIDs []int) ([]int, error)
+	ReplaceCommentTags(ctx context.Context, commentID int, tags []string, mentionedUserIDs []int) ([]int, error)
+	GetPostsByHashtag(ctx context.Context, tag string, page pagination.Page) ([]model.Post, string, error)
+	GetTrendingHashtags(ctx context.Context, since, previousSince time.Time, limit int) ([]model.TrendingHashtag, error)
+}
+
+type TagRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewTagRepository(db *gorm.DB) TagRepository {
+	return &TagRepositoryImpl{db: db}
+}
+
+// ReplacePostTags makes tags and mentionedUserIDs the hashtags and mentions of a post. Links that are
+// kept retain their creation time, so editing a post does not count its tags again. It returns the
+// users that were not mentioned before.
+func (r *TagRepositoryImpl) ReplacePostTags(ctx context.Context, postID int, tags []string, mentionedUserIDs []int) ([]int, error) {
+	var newMentions []int
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		hashtagIDs, err := ensureHashtags(tx, tags)
+		if err != nil {
+			return err
+		}
+
+		if err := replaceLinks(tx, &model.PostHashtag{}, "post_id", postID, "hashtag_id", hashtagIDs); err != nil {
+			return err
+		}
+		links := make([]model.PostHashtag, 0, len(hashtagIDs))
+		for _, hashtagID := range hashtagIDs {
+			links = append(links, model.PostHashtag{PostID: postID, HashtagID: hashtagID})
+		}
+		if err := createLinks(tx, &links); err != nil {
+			return err
+		}
+
+		newMentions, err = newLinkTargets(tx, &model.PostMention{}, "post_id", postID, "user_id", mentionedUserIDs)
+		if err != nil {
+			return err
+		}
+		if err := replaceLinks(tx, &model.PostMention{}, "post_id", postID, "user_id", mentionedUserIDs); err != nil {
+			return err
+		}
+		mentions := make([]model.PostMention, 0, len(newMentions))
+		for _, userID := range newMentions {
+			mentions = append(mentions, model.PostMention{PostID: postID, UserID: userID})
+		}
+		return createLinks(tx, &mentions)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newMentions, nil
+}
+
+// ReplaceCommentTags is ReplacePostTags for comments.
+func (r *TagRepositoryImpl) ReplaceCommentTags(ctx context.Context, commentID int, tags []string, mentionedUserIDs []int) ([]int, error) {
+	var newMentions []int
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		hashtagIDs, err := ensureHashtags(tx, tags)
+		if err != nil {
+			return err
+		}
+
+		if err := replaceLinks(tx, &model.CommentHashtag{}, "comment_id", commentID, "hashtag_id", hashtagIDs); err != nil {
+			return err
+		}
+		links := make([]model.CommentHashtag, 0, len(hashtagIDs))
+		for _, hashtagID := range hashtagIDs {
+			links = append(links, model.CommentHashtag{CommentID: commentID, HashtagID: hashtagID})
+		}
+		if err := createLinks(tx, &links); err != nil {
+			return err
+		}
+
+		newMentions, err = newLinkTargets(tx, &model.CommentMention{}, "comment_id", commentID, "user_id", mentionedUserIDs)
+		if err != nil {
+			return err
+		}
+		if err := replaceLinks(tx, &model.CommentMention{}, "comment_id", commentID, "user_id", mentionedUserIDs); err != nil {
+			return err
+		}
+		mentions := make([]model.CommentMention, 0, len(newMentions))
+		for _, userID := range newMentions {
+			mentions = append(mentions, model.CommentMention{CommentID: commentID, UserID: userID})
+		}
+		return createLinks(tx, &mentions)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newMentions, nil
+}
+
+// GetPostsByHashtag pages through the posts tagged with tag, newest first.
+func (r *TagRepositoryImpl) GetPostsByHashtag(ctx context.Context, tag string, page pagination.Page) ([]model.Post, string, error) {
+	var posts []model.Post
+	if err := r.db.WithContext(ctx).
+		Joins("JOIN post_hashtags ON post_hashtags.post_id = posts.id AND post_hashtags.deleted_at IS NULL").
+		Joins("JOIN hashtags ON hashtags.id = post_hashtags.hashtag_id").
+		Where("hashtags.name = ?", tag).
+		Scopes(publishedPosts, page.ScopeBy("posts.id", true)).
+		Find(&posts).Error; err != nil {
+		return nil, "", err
+	}
+	posts, nextCursor := pagination.Result(page, posts, func(post model.Post) int { return post.ID })
+	return posts, nextCursor, nil
+}
+
+// GetTrendingHashtags counts the uses of each tag in posts and comments created since since, and in
+// the preceding window starting at previousSince. Tags unused in the current window are left out.
+func (r *TagRepositoryImpl) GetTrendingHashtags(ctx context.Context, since, previousSince time.Time, limit int) ([]model.TrendingHashtag, error) {
+	var trending []model.TrendingHashtag
+	err := r.db.WithContext(ctx).Raw(`SELECT hashtags.name AS tag,
+		count(*) FILTER (WHERE usages.created_at >= @since) AS count,
+		count(*) FILTER (WHERE usages.created_at < @since) AS previous_count
+	FROM (
+		SELECT post_hashtags.hashtag_id, post_hashtags.created_at FROM post_hashtags
+		JOIN posts ON posts.id = post_hashtags.post_id AND posts.deleted_at IS NULL AND posts.status = @published
+		WHERE post_hashtags.deleted_at IS NULL AND post_hashtags.created_at >= @previous_since
+		UNION ALL
+		SELECT comment_hashtags.hashtag_id, comment_hashtags.created_at FROM comment_hashtags
+		JOIN comments ON comments.id = comment_hashtags.comment_id AND comments.deleted_at IS NULL
+		JOIN posts ON posts.id = comments.post_id AND posts.deleted_at IS NULL AND posts.status = @published
+		WHERE comment_hashtags.deleted_at IS NULL AND comment_hashtags.created_at >= @previous_since
+	) AS usages
+	JOIN hashtags ON hashtags.id = usages.hashtag_id
+	GROUP BY hashtags.name
+	HAVING count(*) FILTER (WHERE usages.created_at >= @since) > 0
+	ORDER BY count DESC, previous_count ASC, tag
+	LIMIT @limit`, map[string]interface{}{
+		"since":          since,
+		"previous_since": previousSince,
+		"published":      model.PostStatusPublished,
+		"limit":          limit,
+	}).Scan(&trending).Error
+	if err != nil {
+		return nil, err
+	}
+	return trending, nil
+}
+
+// ensureHashtags creates the tags that do not exist yet and returns the ids of all of them.
+func ensureHashtags(tx *gorm.DB, tags []string) ([]int, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	hashtags := make([]model.Hashtag, 0, len(tags))
+	for _, tag := range tags {
+		hashtags = append(hashtags, model.Hashtag{Name: tag})
+	}
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoNothing: true,
+	}).Create(&hashtags).Error; err != nil {
+		return nil, err
+	}
+
+	var hashtagIDs []int
+	if err := tx.Model(&model.Hashtag{}).Where("name IN ?", tags).Pluck("id", &hashtagIDs).Error; err != nil {
+		return nil, err
+	}
+	return hashtagIDs, nil
+}
+
+// replaceLinks deletes the links of the owner whose target is not in targetIDs. Column names must be trusted.
+func replaceLinks(tx *gorm.DB, link interface{}, ownerColumn string, ownerID int, targetColumn string, targetIDs []int) error {
+	query := tx.Unscoped().Where(ownerColumn+" = ?", ownerID)
+	if len(targetIDs) > 0 {
+		query = query.Where(targetColumn+" NOT IN ?", targetIDs)
+	}
+	return query.Delete(link).Error
+}
+
+// newLinkTargets returns the targetIDs the owner is not linked to yet.
+func newLinkTargets(tx *gorm.DB, link interface{}, ownerColumn string, ownerID int, targetColumn string, targetIDs []int) ([]int, error) {
+	if len(targetIDs) == 0 {
+		return nil, nil
+	}
+
+	var existing []int
+	if err := tx.Model(link).Where(ownerColumn+" = ?", ownerID).Pluck(targetColumn, &existing).Error; err != nil {
+		return nil, err
+	}
+	linked := make(map[int]bool, len(existing))
+	for _, id := range existing {
+		linked[id] = true
+	}
+
+	var added []int
+	for _, id := range targetIDs {
+		if !linked[id] {
+			added = append(added, id)
+		}
+	}
+	return added, nil
+}
+
+func createLinks[T any](tx *gorm.DB, links *[]T) error {
+	if len(*links) == 0 {
+		return nil
+	}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(links).Error
+}
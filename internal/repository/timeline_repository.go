@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/temuka-api-service/internal/model"
+)
+
+// timelineTTL lets the timelines of inactive users expire; they are rebuilt from Postgres on the next read.
+const timelineTTL = 7 * 24 * time.Hour
+
+// timelineSentinel keeps an empty timeline alive so it is not rebuilt on every read.
+const timelineSentinel = "0"
+
+type TimelineRepository interface {
+	AddPost(ctx context.Context, userIDs []int, entry model.TimelineEntry, maxLength int64) error
+	RemovePost(ctx context.Context, userIDs []int, postID int) error
+	TimelineExists(ctx context.Context, userID int) (bool, error)
+	ReplaceTimeline(ctx context.Context, userID int, entries []model.TimelineEntry) error
+	GetTimeline(ctx context.Context, userID int, maxScore float64, limit int64) ([]model.TimelineEntry, error)
+	DeleteTimeline(ctx context.Context, userID int) error
+}
+
+type TimelineRepositoryImpl struct {
+	client *redis.Client
+}
+
+func NewTimelineRepository(client *redis.Client) TimelineRepository {
+	return &TimelineRepositoryImpl{
+		client: client,
+	}
+}
+
+// addToTimelineScript only adds to timelines that already exist, so a missing timeline is rebuilt
+// completely on its next read instead of starting out with just the newest post.
+var addToTimelineScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	redis.call("ZADD", KEYS[1], ARGV[1], ARGV[2])
+	redis.call("ZREMRANGEBYRANK", KEYS[1], 0, -tonumber(ARGV[3]) - 1)
+end
+return 0
+`)
+
+func timelineKey(userID int) string {
+	return fmt.Sprintf("timeline:%d", userID)
+}
+
+func (r *TimelineRepositoryImpl) AddPost(ctx context.Context, userIDs []int, entry model.TimelineEntry, maxLength int64) error {
+	pipe := r.client.Pipeline()
+	for _, userID := range userIDs {
+		addToTimelineScript.Eval(ctx, pipe, []string{timelineKey(userID)}, entry.Score, entry.PostID, maxLength)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (r *TimelineRepositoryImpl) RemovePost(ctx context.Context, userIDs []int, postID int) error {
+	pipe := r.client.Pipeline()
+	for _, userID := range userIDs {
+		pipe.ZRem(ctx, timelineKey(userID), postID)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (r *TimelineRepositoryImpl) TimelineExists(ctx context.Context, userID int) (bool, error) {
+	count, err := r.client.Exists(ctx, timelineKey(userID)).Result()
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *TimelineRepositoryImpl) ReplaceTimeline(ctx context.Context, userID int, entries []model.TimelineEntry) error {
+	members := make([]*redis.Z, 0, len(entries)+1)
+	members = append(members, &redis.Z{Score: 0, Member: timelineSentinel})
+	for _, entry := range entries {
+		members = append(members, &redis.Z{Score: entry.Score, Member: entry.PostID})
+	}
+
+	pipe := r.client.TxPipeline()
+	pipe.Del(ctx, timelineKey(userID))
+	pipe.ZAdd(ctx, timelineKey(userID), members...)
+	pipe.Expire(ctx, timelineKey(userID), timelineTTL)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// GetTimeline returns up to limit entries with a score of at most maxScore, newest first.
+func (r *TimelineRepositoryImpl) GetTimeline(ctx context.Context, userID int, maxScore float64, limit int64) ([]model.TimelineEntry, error) {
+	results, err := r.client.ZRevRangeByScoreWithScores(ctx, timelineKey(userID), &redis.ZRangeBy{
+		Max:   strconv.FormatFloat(maxScore, 'f', -1, 64),
+		Min:   "(0",
+		Count: limit,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.client.Expire(ctx, timelineKey(userID), timelineTTL).Err(); err != nil {
+		return nil, err
+	}
+
+	entries := make([]model.TimelineEntry, 0, len(results))
+	for _, result := range results {
+		member, _ := result.Member.(string)
+		postID, err := strconv.Atoi(member)
+		if err != nil || member == timelineSentinel {
+			continue
+		}
+		entries = append(entries, model.TimelineEntry{PostID: postID, Score: result.Score})
+	}
+	return entries, nil
+}
+
+func (r *TimelineRepositoryImpl) DeleteTimeline(ctx context.Context, userID int) error {
+	return r.client.Del(ctx, timelineKey(userID)).Err()
+}
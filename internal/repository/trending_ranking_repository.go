@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/temuka-api-service/internal/model"
+)
+
+// TrendingRankingRepository keeps the computed trending lists in Redis sorted sets, one per kind of
+// item and scope.
+type TrendingRankingRepository interface {
+	ReplaceRankings(ctx context.Context, kind string, rankings map[string][]model.TrendingScore) error
+	GetRanking(ctx context.Context, kind, scope string, limit int64) ([]model.TrendingScore, error)
+}
+
+type TrendingRankingRepositoryImpl struct {
+	client *redis.Client
+}
+
+func NewTrendingRankingRepository(client *redis.Client) TrendingRankingRepository {
+	return &TrendingRankingRepositoryImpl{
+		client: client,
+	}
+}
+
+func trendingKey(kind, scope string) string {
+	return "trending:" + kind + ":" + scope
+}
+
+// trendingScopesKey lists the scopes that have a ranking of the kind, so that rankings of scopes
+// without trending items anymore can be removed.
+func trendingScopesKey(kind string) string {
+	return "trending:" + kind + ":scopes"
+}
+
+// ReplaceRankings swaps all rankings of the kind for the given ones, keyed by scope, in one
+// transaction so that readers never see a partial update.
+func (r *TrendingRankingRepositoryImpl) ReplaceRankings(ctx context.Context, kind string, rankings map[string][]model.TrendingScore) error {
+	oldScopes, err := r.client.SMembers(ctx, trendingScopesKey(kind)).Result()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, scope := range oldScopes {
+			if _, found := rankings[scope]; !found {
+				pipe.Del(ctx, trendingKey(kind, scope))
+			}
+		}
+		pipe.Del(ctx, trendingScopesKey(kind))
+
+		for scope, scores := range rankings {
+			key := trendingKey(kind, scope)
+			pipe.Del(ctx, key)
+			if len(scores) == 0 {
+				continue
+			}
+
+			members := make([]*redis.Z, 0, len(scores))
+			for _, score := range scores {
+				members = append(members, &redis.Z{Score: score.Score, Member: score.ID})
+			}
+			pipe.ZAdd(ctx, key, members...)
+			pipe.SAdd(ctx, trendingScopesKey(kind), scope)
+		}
+		return nil
+	})
+	return err
+}
+
+// GetRanking returns the top limit items of a ranking, best first. A scope without ranking is empty.
+func (r *TrendingRankingRepositoryImpl) GetRanking(ctx context.Context, kind, scope string, limit int64) ([]model.TrendingScore, error) {
+	members, err := r.client.ZRevRangeWithScores(ctx, trendingKey(kind, scope), 0, limit-1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make([]model.TrendingScore, 0, len(members))
+	for _, member := range members {
+		value, _ := member.Member.(string)
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, err
+		}
+		scores = append(scores, model.TrendingScore{ID: id, Score: member.Score})
+	}
+	return scores, nil
+}
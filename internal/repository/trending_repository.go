@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/temuka-api-service/internal/model"
+	"gorm.io/gorm"
+)
+
+// TrendingRepository reads the signals trending scores are computed from out of Postgres.
+type TrendingRepository interface {
+	GetPostSignals(ctx context.Context, since time.Time, limit int) ([]model.TrendingPostSignals, error)
+	GetCommunityActivity(ctx context.Context, since time.Time) ([]model.TrendingCommunityActivity, error)
+}
+
+type TrendingRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewTrendingRepository(db *gorm.DB) TrendingRepository {
+	return &TrendingRepositoryImpl{db: db}
+}
+
+// GetPostSignals returns the reactions, comments and detail views of up to limit of the newest posts
+// published since the given time. Reposts are left out, as they only point at another post.
+func (r *TrendingRepositoryImpl) GetPostSignals(ctx context.Context, since time.Time, limit int) ([]model.TrendingPostSignals, error) {
+	query := `
+		SELECT p.id AS post_id, p.published_at, c.university_id, u.location_id,
+			(SELECT COUNT(*) FROM post_reactions pr WHERE pr.post_id = p.id AND pr.deleted_at IS NULL) AS reactions,
+			(SELECT COUNT(*) FROM comments cm WHERE cm.post_id = p.id AND cm.deleted_at IS NULL) AS comments,
+			(SELECT COALESCE(SUM(v.views), 0) FROM post_view_stats v WHERE v.post_id = p.id AND v.kind = @detail AND v.deleted_at IS NULL) AS views
+		FROM posts p
+		LEFT JOIN communities c ON c.id = p.community_id AND c.deleted_at IS NULL
+		LEFT JOIN universities u ON u.id = c.university_id AND u.deleted_at IS NULL
+		WHERE p.deleted_at IS NULL AND p.status = @published AND p.published_at >= @since
+			AND p.share_type IS DISTINCT FROM @repost
+		ORDER BY p.published_at DESC
+		LIMIT @limit
+	`
+
+	var signals []model.TrendingPostSignals
+	if err := r.db.WithContext(ctx).Raw(query, map[string]interface{}{
+		"detail":    model.ViewKindDetail,
+		"published": model.PostStatusPublished,
+		"repost":    model.ShareTypeRepost,
+		"since":     since,
+		"limit":     limit,
+	}).Scan(&signals).Error; err != nil {
+		return nil, err
+	}
+	return signals, nil
+}
+
+// GetCommunityActivity counts the members who joined and the posts published per community and hour
+// since the given time.
+func (r *TrendingRepositoryImpl) GetCommunityActivity(ctx context.Context, since time.Time) ([]model.TrendingCommunityActivity, error) {
+	query := `
+		SELECT c.id AS community_id, c.university_id, u.location_id, e.hour,
+			SUM(e.joins) AS joins, SUM(e.posts) AS posts
+		FROM (
+			SELECT community_id, date_trunc('hour', created_at) AS hour, 1 AS joins, 0 AS posts
+			FROM community_members
+			WHERE deleted_at IS NULL AND banned = false AND created_at >= @since
+			UNION ALL
+			SELECT community_id, date_trunc('hour', published_at) AS hour, 0 AS joins, 1 AS posts
+			FROM posts
+			WHERE deleted_at IS NULL AND status = @published AND community_id IS NOT NULL AND published_at >= @since
+		) e
+		INNER JOIN communities c ON c.id = e.community_id AND c.deleted_at IS NULL
+		LEFT JOIN universities u ON u.id = c.university_id AND u.deleted_at IS NULL
+		GROUP BY c.id, c.university_id, u.location_id, e.hour
+	`
+
+	var activity []model.TrendingCommunityActivity
+	if err := r.db.WithContext(ctx).Raw(query, map[string]interface{}{
+		"published": model.PostStatusPublished,
+		"since":     since,
+	}).Scan(&activity).Error; err != nil {
+		return nil, err
+	}
+	return activity, nil
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// TwoFactorChallengeRepository tracks the two-factor challenges handed out after a correct password.
+// A challenge completes at most one login and is dropped after too many wrong codes.
+type TwoFactorChallengeRepository interface {
+	CreateChallenge(ctx context.Context, id string, ttl time.Duration) error
+	ChallengeExists(ctx context.Context, id string) (bool, error)
+	RegisterChallengeFailure(ctx context.Context, id string, maxFailures int64) (int64, error)
+	ConsumeChallenge(ctx context.Context, id string) (bool, error)
+}
+
+type TwoFactorChallengeRepositoryImpl struct {
+	client *redis.Client
+}
+
+func NewTwoFactorChallengeRepository(client *redis.Client) TwoFactorChallengeRepository {
+	return &TwoFactorChallengeRepositoryImpl{
+		client: client,
+	}
+}
+
+// failChallengeScript counts a wrong code against a live challenge, keeping its expiry, and deletes
+// the challenge once it reaches the limit. It returns zero for challenges that are gone.
+var failChallengeScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return 0
+end
+local failures = redis.call("INCR", KEYS[1])
+if failures >= tonumber(ARGV[1]) then
+	redis.call("DEL", KEYS[1])
+end
+return failures
+`)
+
+func twoFactorChallengeKey(id string) string {
+	return fmt.Sprintf("two_factor_challenge:%s", id)
+}
+
+func (r *TwoFactorChallengeRepositoryImpl) CreateChallenge(ctx context.Context, id string, ttl time.Duration) error {
+	return r.client.Set(ctx, twoFactorChallengeKey(id), 0, ttl).Err()
+}
+
+func (r *TwoFactorChallengeRepositoryImpl) ChallengeExists(ctx context.Context, id string) (bool, error) {
+	count, err := r.client.Exists(ctx, twoFactorChallengeKey(id)).Result()
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// RegisterChallengeFailure returns how many wrong codes were sent for the challenge, including this
+// one. The challenge is deleted once that reaches maxFailures.
+func (r *TwoFactorChallengeRepositoryImpl) RegisterChallengeFailure(ctx context.Context, id string, maxFailures int64) (int64, error) {
+	return failChallengeScript.Run(ctx, r.client, []string{twoFactorChallengeKey(id)}, maxFailures).Int64()
+}
+
+// ConsumeChallenge deletes the challenge and reports whether it still existed, so that of two
+// concurrent logins with the same challenge only one succeeds.
+func (r *TwoFactorChallengeRepositoryImpl) ConsumeChallenge(ctx context.Context, id string) (bool, error) {
+	deleted, err := r.client.Del(ctx, twoFactorChallengeKey(id)).Result()
+	if err != nil {
+		return false, err
+	}
+	return deleted > 0, nil
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/temuka-api-service/internal/model"
+	"gorm.io/gorm"
+)
+
+type TwoFactorRepository interface {
+	SetTwoFactorSecret(ctx context.Context, userID int, secret string) error
+	EnableTwoFactor(ctx context.Context, userID int) error
+	DisableTwoFactor(ctx context.Context, userID int) error
+	MarkTwoFactorStepUsed(ctx context.Context, userID int, step int64) (bool, error)
+	ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error
+	ConsumeRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error)
+}
+
+type TwoFactorRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewTwoFactorRepository(db *gorm.DB) TwoFactorRepository {
+	return &TwoFactorRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *TwoFactorRepositoryImpl) SetTwoFactorSecret(ctx context.Context, userID int, secret string) error {
+	return r.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", userID).
+		Updates(map[string]interface{}{"two_factor_secret": secret, "two_factor_enabled": false, "two_factor_last_step": 0}).Error
+}
+
+func (r *TwoFactorRepositoryImpl) EnableTwoFactor(ctx context.Context, userID int) error {
+	return r.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", userID).Update("two_factor_enabled", true).Error
+}
+
+func (r *TwoFactorRepositoryImpl) DisableTwoFactor(ctx context.Context, userID int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.User{}).Where("id = ?", userID).
+			Updates(map[string]interface{}{"two_factor_secret": "", "two_factor_enabled": false, "two_factor_last_step": 0}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&model.TwoFactorRecoveryCode{}).Error
+	})
+}
+
+// MarkTwoFactorStepUsed records the TOTP step of an accepted code and reports false if that step,
+// or a later one, was already used, which stops a captured code from being replayed.
+func (r *TwoFactorRepositoryImpl) MarkTwoFactorStepUsed(ctx context.Context, userID int, step int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.User{}).
+		Where("id = ? AND two_factor_last_step < ?", userID, step).
+		Update("two_factor_last_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *TwoFactorRepositoryImpl) ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&model.TwoFactorRecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		codes := make([]model.TwoFactorRecoveryCode, 0, len(codeHashes))
+		for _, hash := range codeHashes {
+			codes = append(codes, model.TwoFactorRecoveryCode{UserID: userID, CodeHash: hash})
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+func (r *TwoFactorRepositoryImpl) ConsumeRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.TwoFactorRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
@@ -4,13 +4,14 @@ import (
 	"context"
 
 	"github.com/temuka-api-service/internal/model"
+	"github.com/temuka-api-service/pkg/pagination"
 	"gorm.io/gorm"
 )
 
 type UniversityRepository interface {
 	CreateUniversity(ctx context.Context, university *model.University) error
 	UpdateUniversity(ctx context.Context, id int, university *model.University) error
-	GetUniversities(ctx context.Context) ([]model.University, error)
+	GetUniversities(ctx context.Context, page pagination.Page) ([]model.University, string, error)
 	DeleteUniversity(ctx context.Context, id int) error
 	GetUniversityDetailByID(ctx context.Context, id int) (*model.University, error)
 	GetUniversityDetailBySlug(ctx context.Context, slug string) (*model.University, error)
@@ -38,12 +39,13 @@ func (r *UniversityRepositoryImpl) UpdateUniversity(ctx context.Context, id int,
 	return r.db.WithContext(ctx).Model(&model.University{}).Where("id = ?", id).Updates(post).Error
 }
 
-func (r *UniversityRepositoryImpl) GetUniversities(ctx context.Context) ([]model.University, error) {
+func (r *UniversityRepositoryImpl) GetUniversities(ctx context.Context, page pagination.Page) ([]model.University, string, error) {
 	var universities []model.University
-	if err := r.db.WithContext(ctx).Find(&universities).Error; err != nil {
-		return nil, err
+	if err := r.db.WithContext(ctx).Scopes(page.Scope(false)).Find(&universities).Error; err != nil {
+		return nil, "", err
 	}
-	return universities, nil
+	universities, nextCursor := pagination.Result(page, universities, func(university model.University) int { return university.ID })
+	return universities, nextCursor, nil
 }
 
 func (r *UniversityRepositoryImpl) GetUniversityDetailByID(ctx context.Context, id int) (*model.University, error) {
@@ -2,17 +2,29 @@ package repository
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	"github.com/temuka-api-service/internal/model"
+	"github.com/temuka-api-service/pkg/pagination"
 	"gorm.io/gorm"
 )
 
 type UserRepository interface {
 	CreateUser(ctx context.Context, user *model.User) error
 	GetUserByID(ctx context.Context, id int) (*model.User, error)
-	GetAllUsers(ctx context.Context) ([]model.User, error)
-	GetFollowers(ctx context.Context, userId int) ([]model.UserFollow, error)
+	GetAllUsers(ctx context.Context, page pagination.Page) ([]model.User, string, error)
+	SearchUsers(ctx context.Context, query string, page pagination.RankedPage) ([]model.UserSearchResult, string, error)
+	GetFollowers(ctx context.Context, userId int, page pagination.Page) ([]model.UserFollow, string, error)
+	GetFollowerIDs(ctx context.Context, userID int) ([]int, error)
+	GetFollowingIDs(ctx context.Context, userID int) ([]int, error)
+	GetFollowerCounts(ctx context.Context, userIDs []int) (map[int]int64, error)
 	GetUserByEmail(ctx context.Context, email string) (*model.User, error)
+	GetUsersByUsernames(ctx context.Context, usernames []string) ([]model.User, error)
+	CheckEmailAvailability(ctx context.Context, email string) bool
+	CheckUsernameAvailability(ctx context.Context, username string) bool
+	MarkEmailVerified(ctx context.Context, userID int) error
+	UpdateUserRole(ctx context.Context, userID int, role string) error
 	UpdateUser(ctx context.Context, userId int, user *model.User) error
 	DeleteUser(ctx context.Context, id int) error
 	CreateUserFollow(ctx context.Context, user_follow *model.UserFollow) error
@@ -44,20 +56,128 @@ func (r *UserRepositoryImpl) GetUserByID(ctx context.Context, id int) (*model.Us
 
 func (r *UserRepositoryImpl) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
 	var user model.User
-	if err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("LOWER(email) = LOWER(?)", email).First(&user).Error; err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *UserRepositoryImpl) GetAllUsers(ctx context.Context) ([]model.User, error) {
+// GetUsersByUsernames matches usernames case-insensitively and skips anonymized accounts.
+func (r *UserRepositoryImpl) GetUsersByUsernames(ctx context.Context, usernames []string) ([]model.User, error) {
 	var users []model.User
-	if err := r.db.WithContext(ctx).Find(&users).Error; err != nil {
+	if len(usernames) == 0 {
+		return users, nil
+	}
+
+	lowered := make([]string, 0, len(usernames))
+	for _, username := range usernames {
+		lowered = append(lowered, strings.ToLower(username))
+	}
+	if err := r.db.WithContext(ctx).Where("LOWER(username) IN ? AND anonymized_at IS NULL", lowered).Find(&users).Error; err != nil {
 		return nil, err
 	}
 	return users, nil
 }
 
+func (r *UserRepositoryImpl) CheckEmailAvailability(ctx context.Context, email string) bool {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.User{}).Where("LOWER(email) = LOWER(?)", email).Count(&count).Error
+	if err != nil {
+		return false
+	}
+	return count == 0
+}
+
+func (r *UserRepositoryImpl) CheckUsernameAvailability(ctx context.Context, username string) bool {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.User{}).Where("LOWER(username) = LOWER(?)", username).Count(&count).Error
+	if err != nil {
+		return false
+	}
+	return count == 0
+}
+
+func (r *UserRepositoryImpl) MarkEmailVerified(ctx context.Context, userID int) error {
+	return r.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", userID).
+		Updates(map[string]interface{}{"email_verified": true, "email_verified_at": time.Now()}).Error
+}
+
+func (r *UserRepositoryImpl) UpdateUserRole(ctx context.Context, userID int, role string) error {
+	return r.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", userID).Update("role", role).Error
+}
+
+func (r *UserRepositoryImpl) GetAllUsers(ctx context.Context, page pagination.Page) ([]model.User, string, error) {
+	var users []model.User
+	if err := r.db.WithContext(ctx).Scopes(page.Scope(false)).Find(&users).Error; err != nil {
+		return nil, "", err
+	}
+	users, nextCursor := pagination.Result(page, users, func(user model.User) int { return user.ID })
+	return users, nextCursor, nil
+}
+
+const userSearchDocument = `to_tsvector('simple', coalesce(username, '') || ' ' || coalesce(displayname, '') || ' ' || coalesce(description, ''))`
+
+// UserSearchIndexes back SearchUsers and are created by the migration command after AutoMigrate.
+var UserSearchIndexes = []string{
+	`CREATE EXTENSION IF NOT EXISTS pg_trgm`,
+	`CREATE INDEX IF NOT EXISTS idx_users_username_trgm ON users USING gin (lower(username) gin_trgm_ops)`,
+	`CREATE INDEX IF NOT EXISTS idx_users_displayname_trgm ON users USING gin (lower(displayname) gin_trgm_ops)`,
+	`CREATE INDEX IF NOT EXISTS idx_users_search_document ON users USING gin ((` + userSearchDocument + `))`,
+}
+
+// userSearchQuery ranks users by trigram similarity of their username and display name, full-text
+// match over username, display name and description, and a boost for exact or prefix username matches.
+// It relies on UserSearchIndexes.
+const userSearchQuery = `
+SELECT * FROM (
+	SELECT users.*,
+		2 * GREATEST(similarity(coalesce(lower(username), ''), @query), similarity(coalesce(lower(displayname), ''), @query))
+		+ word_similarity(@query, coalesce(lower(description), ''))
+		+ ts_rank(` + userSearchDocument + `, plainto_tsquery('simple', @query))
+		+ CASE
+			WHEN lower(username) = @query THEN 3
+			WHEN lower(username) LIKE @prefix ESCAPE '\' THEN 2
+			WHEN lower(displayname) LIKE @prefix ESCAPE '\' THEN 1
+			ELSE 0
+		END AS rank
+	FROM users
+	WHERE deleted_at IS NULL AND anonymized_at IS NULL AND (
+		lower(username) % @query
+		OR lower(displayname) % @query
+		OR lower(username) LIKE @infix ESCAPE '\'
+		OR lower(displayname) LIKE @infix ESCAPE '\'
+		OR ` + userSearchDocument + ` @@ plainto_tsquery('simple', @query)
+	)
+) AS ranked
+WHERE @first OR rank < @after_rank OR (rank = @after_rank AND id < @after_id)
+ORDER BY rank DESC, id DESC
+LIMIT @limit`
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func (r *UserRepositoryImpl) SearchUsers(ctx context.Context, query string, page pagination.RankedPage) ([]model.UserSearchResult, string, error) {
+	query = strings.ToLower(strings.TrimSpace(query))
+	escaped := likeEscaper.Replace(query)
+
+	var results []model.UserSearchResult
+	if err := r.db.WithContext(ctx).Raw(userSearchQuery, map[string]interface{}{
+		"query":      query,
+		"prefix":     escaped + "%",
+		"infix":      "%" + escaped + "%",
+		"first":      page.IsFirst(),
+		"after_rank": page.AfterScore,
+		"after_id":   page.AfterID,
+		"limit":      page.Limit + 1,
+	}).Scan(&results).Error; err != nil {
+		return nil, "", err
+	}
+
+	results, nextCursor := pagination.RankedResult(page, results, func(result model.UserSearchResult) (float64, int) {
+		return result.Rank, result.ID
+	})
+	return results, nextCursor, nil
+}
+
 func (r *UserRepositoryImpl) DeleteUser(ctx context.Context, id int) error {
 	return r.db.WithContext(ctx).Delete(&model.User{}, id).Error
 }
@@ -66,10 +186,51 @@ func (r *UserRepositoryImpl) UpdateUser(ctx context.Context, userId int, user *m
 	return r.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", userId).Updates(user).Error
 }
 
-func (r *UserRepositoryImpl) GetFollowers(ctx context.Context, userID int) ([]model.UserFollow, error) {
+func (r *UserRepositoryImpl) GetFollowers(ctx context.Context, userID int, page pagination.Page) ([]model.UserFollow, string, error) {
 	var followers []model.UserFollow
-	if err := r.db.WithContext(ctx).Where("follower_id = ?", userID).Find(&followers).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("follower_id = ?", userID).Scopes(page.Scope(true)).Find(&followers).Error; err != nil {
+		return nil, "", err
+	}
+	followers, nextCursor := pagination.Result(page, followers, func(follow model.UserFollow) int { return follow.ID })
+	return followers, nextCursor, nil
+}
+
+func (r *UserRepositoryImpl) GetFollowerIDs(ctx context.Context, userID int) ([]int, error) {
+	var followerIDs []int
+	if err := r.db.WithContext(ctx).Model(&model.UserFollow{}).Where("following_id = ?", userID).Pluck("follower_id", &followerIDs).Error; err != nil {
 		return nil, err
 	}
-	return followers, nil
+	return followerIDs, nil
+}
+
+func (r *UserRepositoryImpl) GetFollowingIDs(ctx context.Context, userID int) ([]int, error) {
+	var followingIDs []int
+	if err := r.db.WithContext(ctx).Model(&model.UserFollow{}).Where("follower_id = ?", userID).Pluck("following_id", &followingIDs).Error; err != nil {
+		return nil, err
+	}
+	return followingIDs, nil
+}
+
+func (r *UserRepositoryImpl) GetFollowerCounts(ctx context.Context, userIDs []int) (map[int]int64, error) {
+	counts := make(map[int]int64, len(userIDs))
+	if len(userIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		FollowingID int
+		Count       int64
+	}
+	if err := r.db.WithContext(ctx).Model(&model.UserFollow{}).
+		Select("following_id, COUNT(*) AS count").
+		Where("following_id IN ?", userIDs).
+		Group("following_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.FollowingID] = row.Count
+	}
+	return counts, nil
 }
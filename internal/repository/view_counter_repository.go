@@ -0,0 +1,284 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/temuka-api-service/internal/model"
+	"github.com/temuka-api-service/pkg/helper"
+)
+
+// viewCounterTTL keeps the counters of a day around well after they were last flushed.
+const viewCounterTTL = 3 * 24 * time.Hour
+
+// viewUnionTTL removes the scratch keys of a union count should the count fail halfway.
+const viewUnionTTL = time.Minute
+
+// dirtyViewsKey and dirtyAuthorViewsKey are the sets of post and author counters that changed since
+// they were last flushed to Postgres.
+const (
+	dirtyViewsKey       = "views:dirty"
+	dirtyAuthorViewsKey = "views:authors:dirty"
+)
+
+// ViewCounterRepository counts post views in Redis HyperLogLogs, one per post, kind and day, until they
+// are flushed to Postgres. The viewers of every post of an author are counted once more per author and
+// day, so that author analytics do not have to union the sketches of all their posts.
+type ViewCounterRepository interface {
+	Record(ctx context.Context, kind string, viewerID int, day time.Time, postAuthors map[int]int) error
+	PopChanged(ctx context.Context, limit int64) ([]model.PostViewStat, error)
+	MarkChanged(ctx context.Context, stats []model.PostViewStat) error
+	PopChangedAuthors(ctx context.Context, limit int64) ([]model.AuthorViewStat, error)
+	MarkChangedAuthors(ctx context.Context, stats []model.AuthorViewStat) error
+	CountUnions(ctx context.Context, sketches [][]byte, groups [][]int) ([]int64, error)
+}
+
+type ViewCounterRepositoryImpl struct {
+	client *redis.Client
+}
+
+func NewViewCounterRepository(client *redis.Client) ViewCounterRepository {
+	return &ViewCounterRepositoryImpl{
+		client: client,
+	}
+}
+
+func viewCounterKey(kind string, postID int, day string) string {
+	return fmt.Sprintf("views:%s:%d:%s", kind, postID, day)
+}
+
+func authorViewCounterKey(authorID int, day string) string {
+	return fmt.Sprintf("views:author:%d:%s", authorID, day)
+}
+
+func dirtyAuthorViewsMember(authorID int, day string) string {
+	return fmt.Sprintf("%d:%s", authorID, day)
+}
+
+func parseDirtyAuthorViewsMember(member string) (model.AuthorViewStat, error) {
+	authorIDStr, dayStr, found := strings.Cut(member, ":")
+	if !found {
+		return model.AuthorViewStat{}, fmt.Errorf("invalid author view counter %q", member)
+	}
+	authorID, err := strconv.Atoi(authorIDStr)
+	if err != nil {
+		return model.AuthorViewStat{}, err
+	}
+	day, err := time.Parse(model.AnalyticsDateFormat, dayStr)
+	if err != nil {
+		return model.AuthorViewStat{}, err
+	}
+	return model.AuthorViewStat{AuthorID: authorID, Day: day}, nil
+}
+
+// dirtyViewsMember names a counter in the dirty set along with the author its stats are filed under.
+func dirtyViewsMember(kind string, postID, authorID int, day string) string {
+	return fmt.Sprintf("%s:%d:%d:%s", kind, postID, authorID, day)
+}
+
+func parseDirtyViewsMember(member string) (model.PostViewStat, error) {
+	parts := strings.Split(member, ":")
+	if len(parts) != 4 {
+		return model.PostViewStat{}, fmt.Errorf("invalid view counter %q", member)
+	}
+	postID, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return model.PostViewStat{}, err
+	}
+	authorID, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return model.PostViewStat{}, err
+	}
+	day, err := time.Parse(model.AnalyticsDateFormat, parts[3])
+	if err != nil {
+		return model.PostViewStat{}, err
+	}
+	return model.PostViewStat{PostID: postID, AuthorID: authorID, Kind: parts[0], Day: day}, nil
+}
+
+// Record counts a view by the viewer of each post, given as post ID to author ID, and of each author.
+// Views by the same user on the same day are counted once.
+func (r *ViewCounterRepositoryImpl) Record(ctx context.Context, kind string, viewerID int, day time.Time, postAuthors map[int]int) error {
+	if len(postAuthors) == 0 {
+		return nil
+	}
+
+	date := day.UTC().Format(model.AnalyticsDateFormat)
+	pipe := r.client.Pipeline()
+	authors := make(map[int]bool)
+	for postID, authorID := range postAuthors {
+		key := viewCounterKey(kind, postID, date)
+		pipe.PFAdd(ctx, key, viewerID)
+		pipe.Expire(ctx, key, viewCounterTTL)
+		pipe.SAdd(ctx, dirtyViewsKey, dirtyViewsMember(kind, postID, authorID, date))
+		authors[authorID] = true
+	}
+	for authorID := range authors {
+		key := authorViewCounterKey(authorID, date)
+		pipe.PFAdd(ctx, key, viewerID)
+		pipe.Expire(ctx, key, viewCounterTTL)
+		pipe.SAdd(ctx, dirtyAuthorViewsKey, dirtyAuthorViewsMember(authorID, date))
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// PopChanged takes up to limit changed counters off the dirty set and returns their current counts and
+// sketches. Counters that have expired in the meantime are skipped.
+func (r *ViewCounterRepositoryImpl) PopChanged(ctx context.Context, limit int64) ([]model.PostViewStat, error) {
+	members, err := r.client.SPopN(ctx, dirtyViewsKey, limit).Result()
+	if err != nil || len(members) == 0 {
+		return nil, err
+	}
+
+	stats := make([]model.PostViewStat, 0, len(members))
+	for _, member := range members {
+		stat, err := parseDirtyViewsMember(member)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, stat)
+	}
+
+	pipe := r.client.Pipeline()
+	sketches := make([]*redis.StringCmd, len(stats))
+	counts := make([]*redis.IntCmd, len(stats))
+	for i, stat := range stats {
+		key := viewCounterKey(stat.Kind, stat.PostID, stat.Day.Format(model.AnalyticsDateFormat))
+		sketches[i] = pipe.Get(ctx, key)
+		counts[i] = pipe.PFCount(ctx, key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		if requeueErr := r.MarkChanged(ctx, stats); requeueErr != nil {
+			return nil, requeueErr
+		}
+		return nil, err
+	}
+
+	changed := stats[:0]
+	for i, stat := range stats {
+		sketch, err := sketches[i].Bytes()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		stat.Viewers = sketch
+		stat.Views = counts[i].Val()
+		changed = append(changed, stat)
+	}
+	return changed, nil
+}
+
+// MarkChanged puts counters back on the dirty set, for when flushing them failed.
+func (r *ViewCounterRepositoryImpl) MarkChanged(ctx context.Context, stats []model.PostViewStat) error {
+	if len(stats) == 0 {
+		return nil
+	}
+
+	members := make([]interface{}, 0, len(stats))
+	for _, stat := range stats {
+		members = append(members, dirtyViewsMember(stat.Kind, stat.PostID, stat.AuthorID, stat.Day.Format(model.AnalyticsDateFormat)))
+	}
+	return r.client.SAdd(ctx, dirtyViewsKey, members...).Err()
+}
+
+// PopChangedAuthors is PopChanged for the author counters.
+func (r *ViewCounterRepositoryImpl) PopChangedAuthors(ctx context.Context, limit int64) ([]model.AuthorViewStat, error) {
+	members, err := r.client.SPopN(ctx, dirtyAuthorViewsKey, limit).Result()
+	if err != nil || len(members) == 0 {
+		return nil, err
+	}
+
+	stats := make([]model.AuthorViewStat, 0, len(members))
+	for _, member := range members {
+		stat, err := parseDirtyAuthorViewsMember(member)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, stat)
+	}
+
+	pipe := r.client.Pipeline()
+	sketches := make([]*redis.StringCmd, len(stats))
+	for i, stat := range stats {
+		sketches[i] = pipe.Get(ctx, authorViewCounterKey(stat.AuthorID, stat.Day.Format(model.AnalyticsDateFormat)))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		if requeueErr := r.MarkChangedAuthors(ctx, stats); requeueErr != nil {
+			return nil, requeueErr
+		}
+		return nil, err
+	}
+
+	changed := stats[:0]
+	for i, stat := range stats {
+		sketch, err := sketches[i].Bytes()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		stat.Viewers = sketch
+		changed = append(changed, stat)
+	}
+	return changed, nil
+}
+
+// MarkChangedAuthors puts author counters back on their dirty set, for when flushing them failed.
+func (r *ViewCounterRepositoryImpl) MarkChangedAuthors(ctx context.Context, stats []model.AuthorViewStat) error {
+	if len(stats) == 0 {
+		return nil
+	}
+
+	members := make([]interface{}, 0, len(stats))
+	for _, stat := range stats {
+		members = append(members, dirtyAuthorViewsMember(stat.AuthorID, stat.Day.Format(model.AnalyticsDateFormat)))
+	}
+	return r.client.SAdd(ctx, dirtyAuthorViewsKey, members...).Err()
+}
+
+// CountUnions loads HyperLogLog sketches into scratch keys and counts the distinct elements of each
+// group of sketches, given as indexes into sketches. An empty group counts zero.
+func (r *ViewCounterRepositoryImpl) CountUnions(ctx context.Context, sketches [][]byte, groups [][]int) ([]int64, error) {
+	counts := make([]int64, len(groups))
+	if len(sketches) == 0 {
+		return counts, nil
+	}
+
+	prefix := fmt.Sprintf("views:union:%s", helper.GenerateRandomID(16))
+	keys := make([]string, len(sketches))
+	pipe := r.client.Pipeline()
+	for i, sketch := range sketches {
+		keys[i] = fmt.Sprintf("%s:%d", prefix, i)
+		pipe.Set(ctx, keys[i], sketch, viewUnionTTL)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+	defer r.client.Del(context.Background(), keys...)
+
+	pipe = r.client.Pipeline()
+	cmds := make([]*redis.IntCmd, len(groups))
+	for i, group := range groups {
+		if len(group) == 0 {
+			continue
+		}
+		groupKeys := make([]string, 0, len(group))
+		for _, index := range group {
+			groupKeys = append(groupKeys, keys[index])
+		}
+		cmds[i] = pipe.PFCount(ctx, groupKeys...)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	for i, cmd := range cmds {
+		if cmd != nil {
+			counts[i] = cmd.Val()
+		}
+	}
+	return counts, nil
+}
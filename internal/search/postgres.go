@@ -0,0 +1,248 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// source describes how a table is searched. Every field is a trusted SQL fragment.
+type source struct {
+	table string
+	title string
+	slug  string
+	// body is the text the highlight is cut from.
+	body string
+	// document is the text matched against the query. It must stay in sync with the expression
+	// indexed by PostgresIndexes.
+	document string
+	// filter narrows the matches and returns the condition with its arguments.
+	filter func(query Query) (string, []interface{})
+	// indexedElsewhere marks documents whose index is created outside this package.
+	indexedElsewhere bool
+}
+
+var sources = map[string]source{
+	TypePost: {
+		table:    "posts",
+		title:    "title",
+		slug:     "''",
+		body:     `coalesce("desc", '')`,
+		document: `coalesce(title, '') || ' ' || coalesce("desc", '')`,
+		filter: func(query Query) (string, []interface{}) {
+			return "status = 'published'", nil
+		},
+	},
+	TypeCommunity: {
+		table:    "communities",
+		title:    "name",
+		slug:     "slug",
+		body:     `coalesce("desc", '')`,
+		document: `coalesce(name, '') || ' ' || coalesce("desc", '')`,
+	},
+	TypeUniversity: {
+		table:    "universities",
+		title:    "name",
+		slug:     "slug",
+		body:     `coalesce(summary, '') || ' ' || coalesce(address, '')`,
+		document: `coalesce(name, '') || ' ' || coalesce(summary, '') || ' ' || coalesce(address, '')`,
+		filter: func(query Query) (string, []interface{}) {
+			if query.LocationID == 0 {
+				return "", nil
+			}
+			return "location_id = ?", []interface{}{query.LocationID}
+		},
+	},
+	TypeMajor: {
+		table:    "majors",
+		title:    "name",
+		slug:     "''",
+		body:     `coalesce(name, '')`,
+		document: `coalesce(name, '')`,
+	},
+	TypeUser: {
+		table:    "users",
+		title:    "coalesce(nullif(displayname, ''), username)",
+		slug:     "username",
+		body:     `coalesce(displayname, '') || ' ' || coalesce(description, '')`,
+		document: `coalesce(username, '') || ' ' || coalesce(displayname, '') || ' ' || coalesce(description, '')`,
+		filter: func(query Query) (string, []interface{}) {
+			return "anonymized_at IS NULL", nil
+		},
+		// Shares its expression with repository.UserSearchIndexes.
+		indexedElsewhere: true,
+	},
+}
+
+// ts_headline returns the stored text unescaped, so it marks matches with private use characters
+// instead of HTML. The characters are stripped from the text first, and renderHighlight turns them
+// into HTML once the rest is escaped.
+const (
+	headlineStart = "\uE000"
+	headlineStop  = "\uE001"
+)
+
+var headlineOptions = fmt.Sprintf("StartSel=%s, StopSel=%s, MaxWords=35, MinWords=15, MaxFragments=2", headlineStart, headlineStop)
+
+var headlineMarks = strings.NewReplacer(headlineStart, HighlightStart, headlineStop, HighlightStop)
+
+func renderHighlight(headline string) string {
+	return headlineMarks.Replace(html.EscapeString(headline))
+}
+
+// PostgresIndexes are created by the migration command so that every search runs on a GIN index.
+func PostgresIndexes() []string {
+	var statements []string
+	for _, t := range AllTypes {
+		src := sources[t]
+		if src.indexedElsewhere {
+			continue
+		}
+		statements = append(statements, fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_%s_search_document ON %s USING gin ((%s))", src.table, src.table, src.vector()))
+	}
+	return statements
+}
+
+type PostgresIndex struct {
+	db *gorm.DB
+}
+
+func NewPostgresIndex(db *gorm.DB) Index {
+	return &PostgresIndex{db: db}
+}
+
+type hitRow struct {
+	ID        int
+	Title     string
+	Slug      string
+	Highlight string
+	Score     float64
+	Total     int64
+}
+
+func (i *PostgresIndex) Search(ctx context.Context, query Query) (*Results, error) {
+	query, err := Normalize(query)
+	if err != nil {
+		return nil, err
+	}
+	tsQuery := prefixQuery(query.Text)
+
+	results := &Results{
+		Query:  query.Text,
+		Groups: make([]Group, 0, len(query.Types)),
+		Facets: make(map[string][]FacetValue),
+	}
+	typeFacet := make([]FacetValue, 0, len(query.Types))
+
+	for _, t := range AllTypes {
+		if !containsType(query.Types, t) {
+			continue
+		}
+
+		group, err := i.searchSource(ctx, t, sources[t], tsQuery, query)
+		if err != nil {
+			return nil, err
+		}
+		results.Groups = append(results.Groups, group)
+		results.Total += group.Total
+		typeFacet = append(typeFacet, FacetValue{Value: t, Count: group.Total})
+	}
+	results.Facets["type"] = typeFacet
+
+	if containsType(query.Types, TypeUniversity) {
+		locationFacet, err := i.locationFacet(ctx, tsQuery)
+		if err != nil {
+			return nil, err
+		}
+		results.Facets["location"] = locationFacet
+	}
+
+	return results, nil
+}
+
+func (i *PostgresIndex) searchSource(ctx context.Context, t string, src source, tsQuery string, query Query) (Group, error) {
+	conditions := src.vector() + " @@ q"
+	args := []interface{}{headlineStart + headlineStop, headlineOptions, tsQuery}
+	if src.filter != nil {
+		if condition, filterArgs := src.filter(query); condition != "" {
+			conditions += " AND " + condition
+			args = append(args, filterArgs...)
+		}
+	}
+	args = append(args, query.Limit)
+
+	sql := fmt.Sprintf(`SELECT id, %s AS title, %s AS slug,
+		ts_rank(%s, q) AS score,
+		ts_headline('simple', translate(%s, ?, ''), q, ?) AS highlight,
+		count(*) OVER () AS total
+	FROM %s CROSS JOIN to_tsquery('simple', ?) AS q
+	WHERE deleted_at IS NULL AND %s
+	ORDER BY score DESC, id DESC
+	LIMIT ?`, src.title, src.slug, src.vector(), src.body, src.table, conditions)
+
+	var rows []hitRow
+	if err := i.db.WithContext(ctx).Raw(sql, args...).Scan(&rows).Error; err != nil {
+		return Group{}, err
+	}
+
+	group := Group{Type: t, Hits: make([]Hit, 0, len(rows))}
+	for _, row := range rows {
+		group.Total = row.Total
+		group.Hits = append(group.Hits, Hit{
+			Type:      t,
+			ID:        row.ID,
+			Title:     row.Title,
+			Slug:      row.Slug,
+			Highlight: renderHighlight(row.Highlight),
+			Score:     row.Score,
+		})
+	}
+	return group, nil
+}
+
+// locationFacet counts the matching universities per location, ignoring the location filter so
+// that clients can offer the other locations too.
+func (i *PostgresIndex) locationFacet(ctx context.Context, tsQuery string) ([]FacetValue, error) {
+	src := sources[TypeUniversity]
+	sql := fmt.Sprintf(`SELECT matched.location_id AS value, locations.name AS label, count(*) AS count
+	FROM (
+		SELECT location_id FROM %s CROSS JOIN to_tsquery('simple', ?) AS q
+		WHERE deleted_at IS NULL AND %s @@ q
+	) AS matched
+	JOIN locations ON locations.id = matched.location_id
+	GROUP BY matched.location_id, locations.name
+	ORDER BY count DESC, label`, src.table, src.vector())
+
+	var rows []struct {
+		Value int
+		Label string
+		Count int64
+	}
+	if err := i.db.WithContext(ctx).Raw(sql, tsQuery).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	facet := make([]FacetValue, 0, len(rows))
+	for _, row := range rows {
+		facet = append(facet, FacetValue{Value: strconv.Itoa(row.Value), Label: row.Label, Count: row.Count})
+	}
+	return facet, nil
+}
+
+func (s source) vector() string {
+	return "to_tsvector('simple', " + s.document + ")"
+}
+
+// prefixQuery turns free text into a tsquery matching documents that contain every term as a word
+// prefix, so that partially typed words match too.
+func prefixQuery(text string) string {
+	terms := Terms(text)
+	for i, term := range terms {
+		terms[i] = term + ":*"
+	}
+	return strings.Join(terms, " & ")
+}
@@ -0,0 +1,35 @@
+package search
+
+import "testing"
+
+func TestRenderHighlight(t *testing.T) {
+	tests := []struct {
+		name     string
+		headline string
+		want     string
+	}{
+		{
+			name:     "marks matches",
+			headline: "best " + headlineStart + "campus" + headlineStop + " food",
+			want:     "best <mark>campus</mark> food",
+		},
+		{
+			name:     "escapes markup around matches",
+			headline: `<img src=x onerror="alert(1)"> ` + headlineStart + "campus" + headlineStop + " & more",
+			want:     `&lt;img src=x onerror=&#34;alert(1)&#34;&gt; <mark>campus</mark> &amp; more`,
+		},
+		{
+			name:     "escapes markup inside matches",
+			headline: headlineStart + "<script>" + headlineStop,
+			want:     "<mark>&lt;script&gt;</mark>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderHighlight(tt.headline); got != tt.want {
+				t.Fatalf("renderHighlight(%q) = %q, want %q", tt.headline, got, tt.want)
+			}
+		})
+	}
+}
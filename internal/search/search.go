@@ -0,0 +1,121 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// Document types that can be searched. Results are grouped in this order.
+const (
+	TypePost       = "post"
+	TypeCommunity  = "community"
+	TypeUniversity = "university"
+	TypeMajor      = "major"
+	TypeUser       = "user"
+)
+
+var AllTypes = []string{TypePost, TypeCommunity, TypeUniversity, TypeMajor, TypeUser}
+
+const (
+	DefaultLimit = 5
+	MaxLimit     = 50
+
+	// HighlightStart and HighlightStop wrap the matched terms in Hit.Highlight. The rest of the
+	// highlight is HTML-escaped, so it can be rendered as HTML.
+	HighlightStart = "<mark>"
+	HighlightStop  = "</mark>"
+)
+
+var (
+	ErrEmptyQuery  = errors.New("empty search query")
+	ErrUnknownType = errors.New("unknown search type")
+)
+
+type Query struct {
+	Text string
+	// Types restricts the search to some document types; empty searches all of them.
+	Types []string
+	// Limit is the number of hits returned per type.
+	Limit int
+	// LocationID narrows universities to one location; zero disables the filter.
+	LocationID int
+}
+
+type Hit struct {
+	Type      string  `json:"type"`
+	ID        int     `json:"id"`
+	Title     string  `json:"title"`
+	Slug      string  `json:"slug,omitempty"`
+	Highlight string  `json:"highlight"`
+	Score     float64 `json:"score"`
+}
+
+// Group holds the best hits of one type. Total counts every match, not only the returned hits.
+type Group struct {
+	Type  string `json:"type"`
+	Total int64  `json:"total"`
+	Hits  []Hit  `json:"hits"`
+}
+
+type FacetValue struct {
+	Value string `json:"value"`
+	Label string `json:"label,omitempty"`
+	Count int64  `json:"count"`
+}
+
+type Results struct {
+	Query  string                  `json:"query"`
+	Total  int64                   `json:"total"`
+	Groups []Group                 `json:"groups"`
+	Facets map[string][]FacetValue `json:"facets"`
+}
+
+// Index is a full-text search backend. PostgresIndex reads straight from the primary database;
+// backends that keep their own copy of the documents, such as Bleve or OpenSearch, implement the
+// same interface and are fed by a separate indexer.
+type Index interface {
+	Search(ctx context.Context, query Query) (*Results, error)
+}
+
+// Normalize validates the query and fills in its defaults.
+func Normalize(query Query) (Query, error) {
+	query.Text = strings.TrimSpace(query.Text)
+	if len(Terms(query.Text)) == 0 {
+		return Query{}, ErrEmptyQuery
+	}
+
+	if len(query.Types) == 0 {
+		query.Types = AllTypes
+	}
+	for _, t := range query.Types {
+		if !containsType(AllTypes, t) {
+			return Query{}, ErrUnknownType
+		}
+	}
+
+	if query.Limit <= 0 {
+		query.Limit = DefaultLimit
+	}
+	if query.Limit > MaxLimit {
+		query.Limit = MaxLimit
+	}
+	return query, nil
+}
+
+// Terms splits text into lower-cased words made of letters and digits.
+func Terms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func containsType(types []string, t string) bool {
+	for _, candidate := range types {
+		if candidate == t {
+			return true
+		}
+	}
+	return false
+}
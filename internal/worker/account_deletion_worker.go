@@ -0,0 +1,62 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"github.com/temuka-api-service/internal/repository"
+	"github.com/temuka-api-service/pkg/clock"
+)
+
+const (
+	accountDeletionBatch    = 50
+	accountDeletionInterval = 10 * time.Minute
+)
+
+// AccountDeletionWorker anonymises accounts whose deletion grace period has ended.
+type AccountDeletionWorker struct {
+	AccountRepository repository.AccountRepository
+	SessionRepository repository.SessionRepository
+	Clock             clock.Clock
+}
+
+func NewAccountDeletionWorker(accountRepo repository.AccountRepository, sessionRepo repository.SessionRepository, clk clock.Clock) *AccountDeletionWorker {
+	return &AccountDeletionWorker{
+		AccountRepository: accountRepo,
+		SessionRepository: sessionRepo,
+		Clock:             clk,
+	}
+}
+
+func (w *AccountDeletionWorker) Start(ctx context.Context) {
+	go runEvery(ctx, "account deletion", accountDeletionInterval, w.RunOnce)
+}
+
+func (w *AccountDeletionWorker) RunOnce(ctx context.Context) error {
+	users, err := w.AccountRepository.GetUsersDueForDeletion(ctx, w.Clock.Now(), accountDeletionBatch)
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		exportFiles, err := w.AccountRepository.AnonymizeUser(ctx, user.ID, w.Clock.Now())
+		if err != nil {
+			log.Printf("Error anonymising user %d: %v", user.ID, err)
+			continue
+		}
+
+		for _, filePath := range exportFiles {
+			if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+				log.Printf("Error removing data export file %s: %v", filePath, err)
+			}
+		}
+
+		if err := w.SessionRepository.DeleteUserSessions(ctx, user.ID); err != nil {
+			log.Printf("Error revoking sessions of deleted user %d: %v", user.ID, err)
+		}
+	}
+
+	return nil
+}
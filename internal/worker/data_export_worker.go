@@ -0,0 +1,162 @@
+package worker
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/temuka-api-service/internal/model"
+	"github.com/temuka-api-service/internal/repository"
+	"github.com/temuka-api-service/pkg/clock"
+)
+
+// DataExportLease is how long an export may stay processing before the worker assumes the instance
+// that claimed it died and builds it again.
+const DataExportLease = 30 * time.Minute
+
+const (
+	DataExportTTL      = 7 * 24 * time.Hour
+	dataExportBatch    = 10
+	dataExportInterval = 30 * time.Second
+)
+
+// DataExportWorker builds the ZIP archives requested through the account export endpoint and
+// removes them again once their download window has passed.
+type DataExportWorker struct {
+	DataExportRepository repository.DataExportRepository
+	AccountRepository    repository.AccountRepository
+	ExportDirectory      string
+	Clock                clock.Clock
+}
+
+func NewDataExportWorker(exportRepo repository.DataExportRepository, accountRepo repository.AccountRepository, exportDir string, clk clock.Clock) *DataExportWorker {
+	return &DataExportWorker{
+		DataExportRepository: exportRepo,
+		AccountRepository:    accountRepo,
+		ExportDirectory:      exportDir,
+		Clock:                clk,
+	}
+}
+
+func (w *DataExportWorker) Start(ctx context.Context) {
+	go runEvery(ctx, "data export", dataExportInterval, w.RunOnce)
+}
+
+func (w *DataExportWorker) RunOnce(ctx context.Context) error {
+	now := w.Clock.Now()
+	exports, err := w.DataExportRepository.ClaimDataExports(ctx, now, now.Add(-DataExportLease), dataExportBatch)
+	if err != nil {
+		return err
+	}
+
+	for _, export := range exports {
+		filePath, err := w.buildArchive(ctx, &export)
+		if err != nil {
+			log.Printf("Error building data export %d: %v", export.ID, err)
+			if err := w.DataExportRepository.FailDataExport(ctx, export.ID, "Error building archive"); err != nil {
+				return err
+			}
+			continue
+		}
+
+		completedAt := w.Clock.Now()
+		if err := w.DataExportRepository.CompleteDataExport(ctx, export.ID, filePath, completedAt, completedAt.Add(DataExportTTL)); err != nil {
+			return err
+		}
+	}
+
+	return w.removeExpired(ctx)
+}
+
+func (w *DataExportWorker) buildArchive(ctx context.Context, export *model.DataExport) (string, error) {
+	snapshot, err := w.AccountRepository.GetUserDataSnapshot(ctx, export.UserID)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(w.ExportDirectory, 0o700); err != nil {
+		return "", err
+	}
+
+	filePath := filepath.Join(w.ExportDirectory, fmt.Sprintf("export_%d_user_%d.zip", export.ID, export.UserID))
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return "", err
+	}
+
+	archive := zip.NewWriter(file)
+	sections := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", snapshot.Profile},
+		{"posts.json", snapshot.Posts},
+		{"comments.json", snapshot.Comments},
+		{"reviews.json", snapshot.Reviews},
+		{"reactions.json", snapshot.Reactions},
+		{"bookmarks.json", snapshot.Bookmarks},
+		{"bookmark_collections.json", snapshot.BookmarkCollections},
+		{"poll_votes.json", snapshot.PollVotes},
+		{"media.json", snapshot.Media},
+		{"messages.json", snapshot.Messages},
+		{"followers.json", snapshot.Followers},
+		{"followings.json", snapshot.Followings},
+		{"notifications.json", snapshot.Notifications},
+	}
+
+	for _, section := range sections {
+		if err = writeJSONEntry(archive, section.name, section.data); err != nil {
+			break
+		}
+	}
+
+	if closeErr := archive.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(filePath)
+		return "", err
+	}
+
+	return filePath, nil
+}
+
+func writeJSONEntry(archive *zip.Writer, name string, data interface{}) error {
+	entry, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(entry)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(data)
+}
+
+func (w *DataExportWorker) removeExpired(ctx context.Context) error {
+	exports, err := w.DataExportRepository.GetExpiredDataExports(ctx, w.Clock.Now())
+	if err != nil {
+		return err
+	}
+
+	for _, export := range exports {
+		if export.FilePath != "" {
+			if err := os.Remove(export.FilePath); err != nil && !os.IsNotExist(err) {
+				log.Printf("Error removing data export file %s: %v", export.FilePath, err)
+				continue
+			}
+		}
+		if err := w.DataExportRepository.DeleteDataExport(ctx, export.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
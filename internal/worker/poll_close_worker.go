@@ -0,0 +1,42 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/temuka-api-service/internal/feed"
+)
+
+const (
+	pollCloseBatch    = 50
+	pollCloseInterval = time.Minute
+)
+
+// PollCloseWorker closes polls once their close time has passed and notifies the authors. Polls stop
+// taking votes at their close time on their own; the claim in PollRepository.ClaimClosedPolls lets
+// every server instance run the worker without notifying anyone twice.
+type PollCloseWorker struct {
+	PollService feed.PollService
+}
+
+func NewPollCloseWorker(pollService feed.PollService) *PollCloseWorker {
+	return &PollCloseWorker{
+		PollService: pollService,
+	}
+}
+
+func (w *PollCloseWorker) Start(ctx context.Context) {
+	go runEvery(ctx, "poll close", pollCloseInterval, w.RunOnce)
+}
+
+func (w *PollCloseWorker) RunOnce(ctx context.Context) error {
+	for {
+		closed, err := w.PollService.CloseDue(ctx, pollCloseBatch)
+		if err != nil {
+			return err
+		}
+		if closed < pollCloseBatch {
+			return nil
+		}
+	}
+}
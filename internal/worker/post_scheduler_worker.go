@@ -0,0 +1,53 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/temuka-api-service/internal/feed"
+	"github.com/temuka-api-service/internal/repository"
+)
+
+const (
+	postSchedulerBatch    = 50
+	postSchedulerInterval = 30 * time.Second
+	postSchedulerLock     = "post_scheduler"
+	postSchedulerLockTTL  = 2 * time.Minute
+)
+
+// PostSchedulerWorker publishes scheduled posts once their publish time has passed. Every server
+// instance runs it; the Redis lock lets one instance do the work per tick, and the claim in
+// PostRepository.ClaimDuePosts keeps posts from being published twice should a run outlive the lock.
+type PostSchedulerWorker struct {
+	PublishingService feed.PublishingService
+	LockRepository    repository.LockRepository
+}
+
+func NewPostSchedulerWorker(publishingService feed.PublishingService, lockRepo repository.LockRepository) *PostSchedulerWorker {
+	return &PostSchedulerWorker{
+		PublishingService: publishingService,
+		LockRepository:    lockRepo,
+	}
+}
+
+func (w *PostSchedulerWorker) Start(ctx context.Context) {
+	go runEvery(ctx, "post scheduler", postSchedulerInterval, w.RunOnce)
+}
+
+func (w *PostSchedulerWorker) RunOnce(ctx context.Context) error {
+	lockToken, acquired, err := w.LockRepository.Acquire(ctx, postSchedulerLock, postSchedulerLockTTL)
+	if err != nil || !acquired {
+		return err
+	}
+	defer w.LockRepository.Release(context.Background(), postSchedulerLock, lockToken)
+
+	for {
+		published, err := w.PublishingService.PublishDue(ctx, postSchedulerBatch)
+		if err != nil {
+			return err
+		}
+		if published < postSchedulerBatch {
+			return nil
+		}
+	}
+}
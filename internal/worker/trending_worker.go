@@ -0,0 +1,43 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/temuka-api-service/internal/feed"
+	"github.com/temuka-api-service/internal/repository"
+)
+
+const (
+	trendingInterval = 5 * time.Minute
+	trendingLock     = "trending"
+	trendingLockTTL  = 2 * time.Minute
+)
+
+// TrendingWorker recomputes the trending rankings. The Redis lock keeps every instance from repeating
+// the same work.
+type TrendingWorker struct {
+	TrendingService feed.TrendingService
+	LockRepository  repository.LockRepository
+}
+
+func NewTrendingWorker(trendingService feed.TrendingService, lockRepo repository.LockRepository) *TrendingWorker {
+	return &TrendingWorker{
+		TrendingService: trendingService,
+		LockRepository:  lockRepo,
+	}
+}
+
+func (w *TrendingWorker) Start(ctx context.Context) {
+	go runEvery(ctx, "trending", trendingInterval, w.RunOnce)
+}
+
+func (w *TrendingWorker) RunOnce(ctx context.Context) error {
+	lockToken, acquired, err := w.LockRepository.Acquire(ctx, trendingLock, trendingLockTTL)
+	if err != nil || !acquired {
+		return err
+	}
+	defer w.LockRepository.Release(context.Background(), trendingLock, lockToken)
+
+	return w.TrendingService.Recompute(ctx)
+}
@@ -0,0 +1,52 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/temuka-api-service/internal/feed"
+	"github.com/temuka-api-service/internal/repository"
+)
+
+const (
+	viewFlushBatch    = 500
+	viewFlushInterval = time.Minute
+	viewFlushLock     = "view_flush"
+	viewFlushLockTTL  = 2 * time.Minute
+)
+
+// ViewFlushWorker writes the view counters kept in Redis to Postgres. The Redis lock keeps two
+// instances from flushing the same counter at once, where the older read could overwrite the newer.
+type ViewFlushWorker struct {
+	AnalyticsService feed.AnalyticsService
+	LockRepository   repository.LockRepository
+}
+
+func NewViewFlushWorker(analyticsService feed.AnalyticsService, lockRepo repository.LockRepository) *ViewFlushWorker {
+	return &ViewFlushWorker{
+		AnalyticsService: analyticsService,
+		LockRepository:   lockRepo,
+	}
+}
+
+func (w *ViewFlushWorker) Start(ctx context.Context) {
+	go runEvery(ctx, "view flush", viewFlushInterval, w.RunOnce)
+}
+
+func (w *ViewFlushWorker) RunOnce(ctx context.Context) error {
+	lockToken, acquired, err := w.LockRepository.Acquire(ctx, viewFlushLock, viewFlushLockTTL)
+	if err != nil || !acquired {
+		return err
+	}
+	defer w.LockRepository.Release(context.Background(), viewFlushLock, lockToken)
+
+	for {
+		flushed, err := w.AnalyticsService.FlushViews(ctx, viewFlushBatch)
+		if err != nil {
+			return err
+		}
+		if flushed < viewFlushBatch {
+			return nil
+		}
+	}
+}
@@ -1,31 +1,222 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
-	"github.com/dgrijalva/jwt-go"
+	"github.com/temuka-api-service/internal/rbac"
+	"github.com/temuka-api-service/internal/repository"
+	"github.com/temuka-api-service/pkg/token"
 )
 
-func CheckAuth(next http.Handler) http.Handler {
+type Principal struct {
+	ID        int
+	Username  string
+	Email     string
+	Roles     []string
+	SessionID string
+	APIKeyID  int
+	Scopes    []string
+}
+
+func (p *Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAPIKey reports whether the request was authenticated with an API key instead of a login session.
+func (p *Principal) IsAPIKey() bool {
+	return p.APIKeyID != 0
+}
+
+type contextKey string
+
+const principalKey contextKey = "principal"
+
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey, principal)
+}
+
+// GetPrincipal returns the authenticated user attached to the request context by CheckAuth.
+func GetPrincipal(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey).(*Principal)
+	return principal, ok && principal != nil
+}
+
+type AuthMiddleware struct {
+	SessionRepository    repository.SessionRepository
+	UserRepository       repository.UserRepository
+	APIKeyRepository     repository.APIKeyRepository
+	Authorizer           rbac.Authorizer
+	RequireVerifiedEmail bool
+}
+
+func NewAuthMiddleware(sessionRepo repository.SessionRepository, userRepo repository.UserRepository, apiKeyRepo repository.APIKeyRepository, authorizer rbac.Authorizer) *AuthMiddleware {
+	return &AuthMiddleware{
+		SessionRepository:    sessionRepo,
+		UserRepository:       userRepo,
+		APIKeyRepository:     apiKeyRepo,
+		Authorizer:           authorizer,
+		RequireVerifiedEmail: os.Getenv("REQUIRE_VERIFIED_EMAIL") == "true",
+	}
+}
+
+func (m *AuthMiddleware) CheckAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
+		scheme, tokenString, found := strings.Cut(authHeader, " ")
+
+		if apiKey := r.Header.Get("X-API-Key"); apiKey != "" {
+			m.checkAPIKey(w, r, next, apiKey)
+			return
+		}
+		if found && strings.EqualFold(scheme, "ApiKey") {
+			m.checkAPIKey(w, r, next, tokenString)
+			return
+		}
+
+		if !found || !strings.EqualFold(scheme, "Bearer") || tokenString == "" {
 			http.Error(w, "You are not authorized", http.StatusUnauthorized)
 			return
 		}
 
-		tokenString := strings.Split(authHeader, " ")[1]
+		claims, err := token.ParseAccessToken(tokenString)
+		if err != nil {
+			http.Error(w, "Token not valid", http.StatusUnauthorized)
+			return
+		}
+
+		active, err := m.SessionRepository.SessionExists(r.Context(), claims.SessionID)
+		if err != nil {
+			http.Error(w, "Error checking session", http.StatusInternalServerError)
+			return
+		}
+		if !active {
+			http.Error(w, "Session has been revoked", http.StatusUnauthorized)
+			return
+		}
+
+		principal := &Principal{
+			ID:        claims.ID,
+			Username:  claims.Username,
+			Email:     claims.Email,
+			Roles:     claims.Roles,
+			SessionID: claims.SessionID,
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+	})
+}
 
-		claims := &jwt.StandardClaims{}
+// checkAPIKey authenticates the request as the key's owner. Read-only keys are limited to safe methods.
+func (m *AuthMiddleware) checkAPIKey(w http.ResponseWriter, r *http.Request, next http.Handler, rawKey string) {
+	if !token.IsAPIKey(rawKey) {
+		http.Error(w, "API key not valid", http.StatusUnauthorized)
+		return
+	}
 
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			return []byte(os.Getenv("JWT_SECRET_KEY")), nil
-		})
+	apiKey, err := m.APIKeyRepository.GetAPIKeyByHash(r.Context(), token.HashToken(rawKey))
+	if err != nil {
+		http.Error(w, "API key not valid", http.StatusUnauthorized)
+		return
+	}
+
+	now := time.Now()
+	if apiKey.RevokedAt != nil || (apiKey.ExpiresAt != nil && now.After(*apiKey.ExpiresAt)) {
+		http.Error(w, "API key has been revoked or has expired", http.StatusUnauthorized)
+		return
+	}
+
+	scopes := strings.Split(apiKey.Scopes, ",")
+	if !scopeAllowsMethod(scopes, r.Method) {
+		http.Error(w, "API key does not have the required scope", http.StatusForbidden)
+		return
+	}
+
+	user, err := m.UserRepository.GetUserByID(r.Context(), apiKey.UserID)
+	if err != nil {
+		http.Error(w, "API key not valid", http.StatusUnauthorized)
+		return
+	}
+
+	if err := m.APIKeyRepository.TouchAPIKey(r.Context(), apiKey.ID, now); err != nil {
+		http.Error(w, "Error checking API key", http.StatusInternalServerError)
+		return
+	}
+
+	principal := &Principal{
+		ID:       user.ID,
+		Username: user.Username,
+		Email:    user.Email,
+		Roles:    rbac.GlobalRoles(user.Role),
+		APIKeyID: apiKey.ID,
+		Scopes:   scopes,
+	}
+
+	next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+}
+
+func scopeAllowsMethod(scopes []string, method string) bool {
+	for _, scope := range scopes {
+		if scope == rbac.ScopeWrite {
+			return true
+		}
+		if scope == rbac.ScopeRead && (method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions) {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireVerified rejects users who have not confirmed their email address yet. It must run after
+// CheckAuth and is a no-op unless REQUIRE_VERIFIED_EMAIL is enabled.
+func (m *AuthMiddleware) RequireVerified(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !m.RequireVerifiedEmail {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		principal, ok := GetPrincipal(r.Context())
+		if !ok {
+			http.Error(w, "You are not authorized", http.StatusUnauthorized)
+			return
+		}
+
+		user, err := m.UserRepository.GetUserByID(r.Context(), principal.ID)
+		if err != nil {
+			http.Error(w, "User not found", http.StatusUnauthorized)
+			return
+		}
+
+		if !user.EmailVerified {
+			http.Error(w, "Please verify your email address first", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireSession rejects principals authenticated with an API key. It guards endpoints that manage
+// credentials, so that a leaked key cannot be used to mint new keys or take over the account.
+func RequireSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := GetPrincipal(r.Context())
+		if !ok {
+			http.Error(w, "You are not authorized", http.StatusUnauthorized)
+			return
+		}
 
-		if err != nil || !token.Valid {
-			http.Error(w, "Token not valid", http.StatusForbidden)
+		if principal.IsAPIKey() {
+			http.Error(w, "This endpoint cannot be used with an API key", http.StatusForbidden)
 			return
 		}
 
@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/temuka-api-service/internal/rbac"
+	"github.com/temuka-api-service/internal/repository"
+)
+
+// CommunityResolver extracts the community a request acts on, so community-scoped permissions can be checked.
+type CommunityResolver func(r *http.Request) (int, error)
+
+func CommunityFromVar(name string) CommunityResolver {
+	return func(r *http.Request) (int, error) {
+		return strconv.Atoi(mux.Vars(r)[name])
+	}
+}
+
+// CommunityFromBody reads the community id from a JSON body field and restores the body for the handler.
+func CommunityFromBody(field string) CommunityResolver {
+	return func(r *http.Request) (int, error) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return 0, err
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(body, &fields); err != nil {
+			return 0, err
+		}
+
+		var communityID int
+		if err := json.Unmarshal(fields[field], &communityID); err != nil {
+			return 0, err
+		}
+		if communityID == 0 {
+			return 0, errors.New("missing community id")
+		}
+		return communityID, nil
+	}
+}
+
+func CommunityOfModerator(moderatorRepo repository.ModeratorRepository, name string) CommunityResolver {
+	return func(r *http.Request) (int, error) {
+		moderatorID, err := strconv.Atoi(mux.Vars(r)[name])
+		if err != nil {
+			return 0, err
+		}
+
+		moderator, err := moderatorRepo.GetModeratorByID(r.Context(), moderatorID)
+		if err != nil {
+			return 0, err
+		}
+		return moderator.CommunityID, nil
+	}
+}
+
+// RequirePermission only lets through users whose global role grants the permission. It must run after CheckAuth.
+func RequirePermission(permission rbac.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := GetPrincipal(r.Context())
+			if !ok {
+				http.Error(w, "You are not authorized", http.StatusUnauthorized)
+				return
+			}
+
+			if !rbac.HasGlobalPermission(principal.Roles, permission) {
+				http.Error(w, "You do not have permission to perform this action", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireCommunityPermission checks the permission against the user's role in the community returned by resolve.
+// Admins are always allowed. It must run after CheckAuth.
+func (m *AuthMiddleware) RequireCommunityPermission(permission rbac.Permission, resolve CommunityResolver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := GetPrincipal(r.Context())
+			if !ok {
+				http.Error(w, "You are not authorized", http.StatusUnauthorized)
+				return
+			}
+
+			communityID, err := resolve(r)
+			if err != nil {
+				http.Error(w, "Community not found", http.StatusNotFound)
+				return
+			}
+
+			allowed, err := m.Authorizer.HasCommunityPermission(r.Context(), principal.Roles, principal.ID, communityID, permission)
+			if err != nil {
+				http.Error(w, "Community not found", http.StatusNotFound)
+				return
+			}
+			if !allowed {
+				http.Error(w, "You do not have permission to perform this action", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
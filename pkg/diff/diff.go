@@ -0,0 +1,190 @@
+package diff
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Operation types of an Op.
+const (
+	OpEqual  = "equal"
+	OpInsert = "insert"
+	OpDelete = "delete"
+)
+
+// maxEditCost bounds the number of edits searched for between two parts of the texts, which keeps
+// the work proportional to their length. Parts that differ more are reported as a single deletion
+// followed by a single insertion.
+const maxEditCost = 256
+
+// Op is one step of turning the old text into the new one. Concatenating the Text of the equal and
+// delete ops gives the old text, and that of the equal and insert ops gives the new text.
+type Op struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// Words diffs two texts word by word. Whitespace runs are tokens of their own, so that changes in
+// spacing show up without swallowing the surrounding words.
+func Words(oldText, newText string) []Op {
+	return diffTokens(tokenize(oldText), tokenize(newText))
+}
+
+// diffTokens finds a shortest edit script with the linear space variant of Myers' algorithm: the
+// middle snake of an optimal path splits the texts into two smaller problems.
+func diffTokens(oldTokens, newTokens []string) []Op {
+	return appendDiff(nil, oldTokens, newTokens)
+}
+
+func appendDiff(ops []Op, oldTokens, newTokens []string) []Op {
+	prefix := 0
+	for prefix < len(oldTokens) && prefix < len(newTokens) && oldTokens[prefix] == newTokens[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(oldTokens)-prefix && suffix < len(newTokens)-prefix &&
+		oldTokens[len(oldTokens)-1-suffix] == newTokens[len(newTokens)-1-suffix] {
+		suffix++
+	}
+
+	ops = appendOp(ops, OpEqual, oldTokens[:prefix]...)
+
+	oldMiddle := oldTokens[prefix : len(oldTokens)-suffix]
+	newMiddle := newTokens[prefix : len(newTokens)-suffix]
+	if len(oldMiddle) == 0 || len(newMiddle) == 0 {
+		ops = appendOp(ops, OpDelete, oldMiddle...)
+		ops = appendOp(ops, OpInsert, newMiddle...)
+	} else if x, y, found := middleSnake(oldMiddle, newMiddle); found {
+		ops = appendDiff(ops, oldMiddle[:x], newMiddle[:y])
+		ops = appendDiff(ops, oldMiddle[x:], newMiddle[y:])
+	} else {
+		ops = appendOp(ops, OpDelete, oldMiddle...)
+		ops = appendOp(ops, OpInsert, newMiddle...)
+	}
+
+	return appendOp(ops, OpEqual, oldTokens[len(oldTokens)-suffix:]...)
+}
+
+// middleSnake searches forwards from the start and backwards from the end of the edit graph at the
+// same time, and returns the point where the two paths meet. It reports false when the texts need
+// more than maxEditCost edits. The texts must not be empty.
+func middleSnake(oldTokens, newTokens []string) (int, int, bool) {
+	n, m := len(oldTokens), len(newTokens)
+	maxD := (n + m + 1) / 2
+	if maxD > maxEditCost {
+		maxD = maxEditCost
+	}
+
+	// forward[offset+k] and backward[offset+k] hold the furthest x reached on diagonal k, counted
+	// from the start and from the end of the texts.
+	offset := maxD
+	forward := make([]int, 2*maxD+2)
+	backward := make([]int, 2*maxD+2)
+	for i := range forward {
+		forward[i] = -1
+		backward[i] = -1
+	}
+	forward[offset+1] = 0
+	backward[offset+1] = 0
+
+	delta := n - m
+	// With an odd delta the paths can only meet during a forward step, otherwise during a backward one.
+	checkForward := delta%2 != 0
+	// Diagonals that ran off the edit graph are skipped from then on.
+	forwardStart, forwardEnd, backwardStart, backwardEnd := 0, 0, 0, 0
+
+	for d := 0; d < maxD; d++ {
+		for k := -d + forwardStart; k <= d-forwardEnd; k += 2 {
+			var x int
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && oldTokens[x] == newTokens[y] {
+				x++
+				y++
+			}
+			forward[offset+k] = x
+
+			switch {
+			case x > n:
+				forwardEnd += 2
+			case y > m:
+				forwardStart += 2
+			case checkForward:
+				backwardIndex := offset + delta - k
+				if backwardIndex >= 0 && backwardIndex < len(backward) && backward[backwardIndex] != -1 && x >= n-backward[backwardIndex] {
+					return x, y, true
+				}
+			}
+		}
+
+		for k := -d + backwardStart; k <= d-backwardEnd; k += 2 {
+			var x int
+			if k == -d || (k != d && backward[offset+k-1] < backward[offset+k+1]) {
+				x = backward[offset+k+1]
+			} else {
+				x = backward[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && oldTokens[n-x-1] == newTokens[m-y-1] {
+				x++
+				y++
+			}
+			backward[offset+k] = x
+
+			switch {
+			case x > n:
+				backwardEnd += 2
+			case y > m:
+				backwardStart += 2
+			case !checkForward:
+				forwardIndex := offset + delta - k
+				if forwardIndex >= 0 && forwardIndex < len(forward) && forward[forwardIndex] != -1 {
+					forwardX := forward[forwardIndex]
+					if forwardX >= n-x {
+						return forwardX, forwardX - (forwardIndex - offset), true
+					}
+				}
+			}
+		}
+	}
+
+	return 0, 0, false
+}
+
+// appendOp adds the tokens to the last op when it has the same type, so that the result holds no
+// two consecutive ops of one type.
+func appendOp(ops []Op, opType string, tokens ...string) []Op {
+	if len(tokens) == 0 {
+		return ops
+	}
+
+	text := strings.Join(tokens, "")
+	if len(ops) > 0 && ops[len(ops)-1].Type == opType {
+		ops[len(ops)-1].Text += text
+		return ops
+	}
+	return append(ops, Op{Type: opType, Text: text})
+}
+
+// tokenize splits text into alternating runs of whitespace and non-whitespace.
+func tokenize(text string) []string {
+	var tokens []string
+	start := 0
+	inSpace := false
+	for i, r := range text {
+		space := unicode.IsSpace(r)
+		if i > start && space != inSpace {
+			tokens = append(tokens, text[start:i])
+			start = i
+		}
+		inSpace = space
+	}
+	if start < len(text) {
+		tokens = append(tokens, text[start:])
+	}
+	return tokens
+}
@@ -0,0 +1,139 @@
+package diff
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+)
+
+// texts rebuilds the old and the new text from ops.
+func texts(ops []Op) (string, string) {
+	var oldText, newText strings.Builder
+	for _, op := range ops {
+		if op.Type != OpInsert {
+			oldText.WriteString(op.Text)
+		}
+		if op.Type != OpDelete {
+			newText.WriteString(op.Text)
+		}
+	}
+	return oldText.String(), newText.String()
+}
+
+func TestWords(t *testing.T) {
+	tests := []struct {
+		name    string
+		oldText string
+		newText string
+		want    []Op
+	}{
+		{name: "empty", want: nil},
+		{name: "equal", oldText: "same text", newText: "same text", want: []Op{{OpEqual, "same text"}}},
+		{name: "insert", oldText: "", newText: "new", want: []Op{{OpInsert, "new"}}},
+		{name: "delete", oldText: "old", newText: "", want: []Op{{OpDelete, "old"}}},
+		{
+			name:    "replace word",
+			oldText: "the quick fox",
+			newText: "the slow fox",
+			want:    []Op{{OpEqual, "the "}, {OpDelete, "quick"}, {OpInsert, "slow"}, {OpEqual, " fox"}},
+		},
+		{
+			name:    "insert word",
+			oldText: "campus food",
+			newText: "campus street food",
+			want:    []Op{{OpEqual, "campus "}, {OpInsert, "street "}, {OpEqual, "food"}},
+		},
+		{
+			name:    "keeps common words between changes",
+			oldText: "a b c d e",
+			newText: "x b c d y",
+			want:    []Op{{OpDelete, "a"}, {OpInsert, "x"}, {OpEqual, " b c d "}, {OpDelete, "e"}, {OpInsert, "y"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops := Words(tt.oldText, tt.newText)
+			if len(ops) != len(tt.want) {
+				t.Fatalf("Words(%q, %q) = %v, want %v", tt.oldText, tt.newText, ops, tt.want)
+			}
+			for i := range ops {
+				if ops[i] != tt.want[i] {
+					t.Fatalf("Words(%q, %q) = %v, want %v", tt.oldText, tt.newText, ops, tt.want)
+				}
+			}
+		})
+	}
+}
+
+// lcsLength is the number of tokens a shortest edit script keeps.
+func lcsLength(oldTokens, newTokens []string) int {
+	lengths := make([][]int, len(oldTokens)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(newTokens)+1)
+	}
+	for i := len(oldTokens) - 1; i >= 0; i-- {
+		for j := len(newTokens) - 1; j >= 0; j-- {
+			switch {
+			case oldTokens[i] == newTokens[j]:
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			case lengths[i+1][j] > lengths[i][j+1]:
+				lengths[i][j] = lengths[i+1][j]
+			default:
+				lengths[i][j] = lengths[i][j+1]
+			}
+		}
+	}
+	return lengths[0][0]
+}
+
+func TestWordsIsMinimal(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	words := []string{"a", "b", "c", "d", "e"}
+	randomText := func(length int) string {
+		tokens := make([]string, length)
+		for i := range tokens {
+			tokens[i] = words[random.Intn(len(words))]
+		}
+		return strings.Join(tokens, " ")
+	}
+
+	for i := 0; i < 200; i++ {
+		oldText, newText := randomText(random.Intn(40)), randomText(random.Intn(40))
+		ops := Words(oldText, newText)
+		gotOld, gotNew := texts(ops)
+		if gotOld != oldText || gotNew != newText {
+			t.Fatalf("Words(%q, %q) rebuilds %q and %q", oldText, newText, gotOld, gotNew)
+		}
+
+		kept := 0
+		for _, op := range ops {
+			if op.Type == OpEqual {
+				kept += len(tokenize(op.Text))
+			}
+		}
+		if want := lcsLength(tokenize(oldText), tokenize(newText)); kept != want {
+			t.Fatalf("Words(%q, %q) keeps %d tokens, want %d", oldText, newText, kept, want)
+		}
+	}
+}
+
+func TestWordsLargeTexts(t *testing.T) {
+	oldTokens := make([]string, 100_000)
+	newTokens := make([]string, 100_000)
+	for i := range oldTokens {
+		oldTokens[i] = "old"
+		newTokens[i] = "new"
+	}
+	oldText, newText := strings.Join(oldTokens, " "), strings.Join(newTokens, " ")
+
+	start := time.Now()
+	ops := Words(oldText, newText)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("diffing took %v", elapsed)
+	}
+	if gotOld, gotNew := texts(ops); gotOld != oldText || gotNew != newText {
+		t.Fatal("ops do not rebuild the texts")
+	}
+}
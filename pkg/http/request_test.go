@@ -0,0 +1,103 @@
+package httputil
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name           string
+		trustedProxies string
+		remoteAddr     string
+		forwardedFor   []string
+		want           string
+	}{
+		{
+			name:       "no proxy",
+			remoteAddr: "203.0.113.7:51234",
+			want:       "203.0.113.7",
+		},
+		{
+			name:         "forwarded header from an untrusted peer",
+			remoteAddr:   "203.0.113.7:51234",
+			forwardedFor: []string{"198.51.100.1"},
+			want:         "203.0.113.7",
+		},
+		{
+			name:           "forwarded header without trusted proxies",
+			trustedProxies: "",
+			remoteAddr:     "10.0.0.2:443",
+			forwardedFor:   []string{"198.51.100.1"},
+			want:           "10.0.0.2",
+		},
+		{
+			name:           "trusted proxy",
+			trustedProxies: "10.0.0.0/8",
+			remoteAddr:     "10.0.0.2:443",
+			forwardedFor:   []string{"198.51.100.1"},
+			want:           "198.51.100.1",
+		},
+		{
+			name:           "spoofed hops left of the proxy are ignored",
+			trustedProxies: "10.0.0.0/8",
+			remoteAddr:     "10.0.0.2:443",
+			forwardedFor:   []string{"1.2.3.4, 5.6.7.8, 198.51.100.1"},
+			want:           "198.51.100.1",
+		},
+		{
+			name:           "chain of trusted proxies",
+			trustedProxies: "10.0.0.0/8, 192.0.2.10",
+			remoteAddr:     "10.0.0.2:443",
+			forwardedFor:   []string{"1.2.3.4, 198.51.100.1", "192.0.2.10"},
+			want:           "198.51.100.1",
+		},
+		{
+			name:           "only trusted hops",
+			trustedProxies: "10.0.0.0/8",
+			remoteAddr:     "10.0.0.2:443",
+			forwardedFor:   []string{"10.0.0.3"},
+			want:           "10.0.0.3",
+		},
+		{
+			name:           "trusted proxy without header",
+			trustedProxies: "10.0.0.0/8",
+			remoteAddr:     "10.0.0.2:443",
+			want:           "10.0.0.2",
+		},
+		{
+			name:           "ipv6",
+			trustedProxies: "::1",
+			remoteAddr:     "[::1]:443",
+			forwardedFor:   []string{"2001:db8::1"},
+			want:           "2001:db8::1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := SetTrustedProxies(tt.trustedProxies); err != nil {
+				t.Fatalf("SetTrustedProxies() error = %v", err)
+			}
+			t.Cleanup(func() { SetTrustedProxies("") })
+
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for _, header := range tt.forwardedFor {
+				r.Header.Add("X-Forwarded-For", header)
+			}
+
+			if got := ClientIP(r); got != tt.want {
+				t.Fatalf("ClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetTrustedProxiesRejectsInvalidEntries(t *testing.T) {
+	for _, list := range []string{"proxy.internal", "10.0.0.0/33", "10.0.0.1, nope"} {
+		if err := SetTrustedProxies(list); err == nil {
+			t.Errorf("SetTrustedProxies(%q) error = nil, want an error", list)
+		}
+	}
+}
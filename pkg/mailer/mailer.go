@@ -0,0 +1,56 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strconv"
+)
+
+// ErrNotConfigured is returned when no mail server is configured and the in-memory mailer was not
+// asked for explicitly.
+var ErrNotConfigured = errors.New("mailer: SMTP_HOST is not set, set MAIL_DRIVER=memory to keep emails in memory")
+
+// DriverMemory selects the in-memory mailer through MAIL_DRIVER. It is meant for local development
+// and tests only, as nothing is delivered and every message is kept for the life of the process.
+const DriverMemory = "memory"
+
+type Message struct {
+	To       string
+	Subject  string
+	TextBody string
+	HTMLBody string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// NewMailerFromEnv returns the in-memory mailer when MAIL_DRIVER is memory and an SMTP mailer
+// otherwise. Without SMTP_HOST it fails, so that a misconfigured server does not silently stop
+// delivering reset and verification emails.
+func NewMailerFromEnv() (Mailer, error) {
+	if os.Getenv("MAIL_DRIVER") == DriverMemory {
+		log.Println("MAIL_DRIVER is memory, emails will not be delivered")
+		return NewMemoryMailer(), nil
+	}
+
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		return nil, ErrNotConfigured
+	}
+
+	port, err := strconv.Atoi(os.Getenv("SMTP_PORT"))
+	if err != nil {
+		port = 587
+	}
+
+	return NewSMTPMailer(SMTPConfig{
+		Host:     host,
+		Port:     port,
+		Username: os.Getenv("SMTP_USER"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("MAIL_FROM"),
+	}), nil
+}
@@ -0,0 +1,76 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestNewMailerFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		driver  string
+		host    string
+		want    string
+		wantErr error
+	}{
+		{name: "smtp", host: "smtp.example.com", want: "smtp"},
+		{name: "memory when asked for", driver: DriverMemory, want: "memory"},
+		{name: "memory wins over smtp", driver: DriverMemory, host: "smtp.example.com", want: "memory"},
+		{name: "missing host", wantErr: ErrNotConfigured},
+		{name: "unknown driver without host", driver: "log", wantErr: ErrNotConfigured},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MAIL_DRIVER", tt.driver)
+			t.Setenv("SMTP_HOST", tt.host)
+
+			mailer, err := NewMailerFromEnv()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("NewMailerFromEnv() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			var got string
+			switch mailer.(type) {
+			case *SMTPMailer:
+				got = "smtp"
+			case *MemoryMailer:
+				got = "memory"
+			}
+			if got != tt.want {
+				t.Fatalf("NewMailerFromEnv() = %T, want %s", mailer, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryMailer(t *testing.T) {
+	mailer := NewMemoryMailer()
+	if _, found := mailer.Last(); found {
+		t.Fatal("Last() found a message before any was sent")
+	}
+
+	for _, to := range []string{"a@example.com", "b@example.com"} {
+		if err := mailer.Send(context.Background(), Message{To: to, Subject: "Hello"}); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+	}
+
+	messages := mailer.Messages()
+	if len(messages) != 2 || messages[0].To != "a@example.com" {
+		t.Fatalf("Messages() = %+v, want both messages in order", messages)
+	}
+	messages[0].To = "changed@example.com"
+
+	last, found := mailer.Last()
+	if !found || last.To != "b@example.com" {
+		t.Fatalf("Last() = %+v, %v, want the message to b@example.com", last, found)
+	}
+	if mailer.Messages()[0].To != "a@example.com" {
+		t.Fatal("Messages() returned the mailer's own slice")
+	}
+}
@@ -0,0 +1,44 @@
+package mailer
+
+import (
+	"context"
+
+	mail "gopkg.in/mail.v2"
+)
+
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+type SMTPMailer struct {
+	config SMTPConfig
+	dialer *mail.Dialer
+}
+
+func NewSMTPMailer(config SMTPConfig) *SMTPMailer {
+	return &SMTPMailer{
+		config: config,
+		dialer: mail.NewDialer(config.Host, config.Port, config.Username, config.Password),
+	}
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, message Message) error {
+	msg := mail.NewMessage()
+	msg.SetHeader("From", m.config.From)
+	msg.SetHeader("To", message.To)
+	msg.SetHeader("Subject", message.Subject)
+	msg.SetBody("text/plain", message.TextBody)
+	if message.HTMLBody != "" {
+		msg.AddAlternative("text/html", message.HTMLBody)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return m.dialer.DialAndSend(msg)
+}
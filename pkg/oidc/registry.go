@@ -0,0 +1,83 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+var ErrUnknownProvider = errors.New("unknown oidc provider")
+
+// Registry holds the configured providers and runs discovery lazily, so an unreachable
+// issuer does not stop the server from starting.
+type Registry struct {
+	configs    map[string]Config
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	providers map[string]*Provider
+}
+
+func NewRegistry(configs []Config, httpClient *http.Client) *Registry {
+	registry := &Registry{
+		configs:    make(map[string]Config),
+		httpClient: httpClient,
+		providers:  make(map[string]*Provider),
+	}
+	for _, config := range configs {
+		registry.configs[config.Name] = config
+	}
+	return registry
+}
+
+// ConfigsFromEnv reads OIDC_PROVIDERS (a comma separated list of names) and, for each name,
+// OIDC_<NAME>_ISSUER_URL, OIDC_<NAME>_CLIENT_ID, OIDC_<NAME>_CLIENT_SECRET, OIDC_<NAME>_REDIRECT_URL
+// and the optional space separated OIDC_<NAME>_SCOPES.
+func ConfigsFromEnv() []Config {
+	var configs []Config
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		config := Config{
+			Name:         name,
+			IssuerURL:    os.Getenv(prefix + "ISSUER_URL"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+			Scopes:       strings.Fields(os.Getenv(prefix + "SCOPES")),
+		}
+		if config.IssuerURL == "" || config.ClientID == "" {
+			continue
+		}
+		configs = append(configs, config)
+	}
+	return configs
+}
+
+func (r *Registry) Get(ctx context.Context, name string) (*Provider, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if provider, ok := r.providers[name]; ok {
+		return provider, nil
+	}
+
+	config, ok := r.configs[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	provider, err := NewProvider(ctx, config, r.httpClient)
+	if err != nil {
+		return nil, err
+	}
+	r.providers[name] = provider
+	return provider, nil
+}
@@ -0,0 +1,199 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidLimit  = errors.New("invalid limit")
+)
+
+// Page selects a window of a list ordered by primary key, or by another unique positive integer
+// column through ScopeBy. AfterID is the key of the last row of the previous page and zero on the
+// first page.
+type Page struct {
+	AfterID int
+	Limit   int
+}
+
+// FirstPage returns the first page with the default limit.
+func FirstPage() Page {
+	return Page{Limit: DefaultLimit}
+}
+
+// New decodes an opaque cursor and clamps limit to (0, MaxLimit]. A zero limit selects DefaultLimit.
+func New(cursor string, limit int) (Page, error) {
+	limit, err := clampLimit(limit)
+	if err != nil {
+		return Page{}, err
+	}
+
+	afterID, err := decodeCursor(cursor)
+	if err != nil {
+		return Page{}, err
+	}
+	return Page{AfterID: afterID, Limit: limit}, nil
+}
+
+// FromRequest reads the page from the cursor and limit query parameters.
+func FromRequest(r *http.Request) (Page, error) {
+	limit, err := requestLimit(r)
+	if err != nil {
+		return Page{}, err
+	}
+	return New(r.URL.Query().Get("cursor"), limit)
+}
+
+// Scope orders the query by id and restricts it to the page. One row more than the limit is fetched
+// so that Result can tell whether another page follows.
+func (p Page) Scope(newestFirst bool) func(*gorm.DB) *gorm.DB {
+	return p.ScopeBy("id", newestFirst)
+}
+
+// ScopeBy is Scope for lists keyed by another column. The column name must be trusted.
+func (p Page) ScopeBy(column string, descending bool) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if descending {
+			if p.AfterID > 0 {
+				db = db.Where(column+" < ?", p.AfterID)
+			}
+			return db.Order(column + " DESC").Limit(p.Limit + 1)
+		}
+
+		if p.AfterID > 0 {
+			db = db.Where(column+" > ?", p.AfterID)
+		}
+		return db.Order(column + " ASC").Limit(p.Limit + 1)
+	}
+}
+
+// Result drops the extra row fetched by Scope and returns the cursor of the next page, which is
+// empty when items holds the last page.
+func Result[T any](p Page, items []T, idOf func(T) int) ([]T, string) {
+	if len(items) <= p.Limit {
+		return items, ""
+	}
+	items = items[:p.Limit]
+	return items, encodeCursor(idOf(items[len(items)-1]))
+}
+
+// RankedPage selects a window of a list ordered by descending score, with ties broken by descending
+// id. AfterScore and AfterID identify the last row of the previous page; AfterID is zero on the first page.
+type RankedPage struct {
+	AfterScore float64
+	AfterID    int
+	Limit      int
+}
+
+// NewRanked decodes an opaque ranked cursor and clamps limit the same way New does.
+func NewRanked(cursor string, limit int) (RankedPage, error) {
+	limit, err := clampLimit(limit)
+	if err != nil {
+		return RankedPage{}, err
+	}
+	if cursor == "" {
+		return RankedPage{Limit: limit}, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return RankedPage{}, ErrInvalidCursor
+	}
+
+	scoreStr, idStr, found := strings.Cut(string(raw), ":")
+	if !found {
+		return RankedPage{}, ErrInvalidCursor
+	}
+	score, err := strconv.ParseFloat(scoreStr, 64)
+	if err != nil {
+		return RankedPage{}, ErrInvalidCursor
+	}
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id <= 0 {
+		return RankedPage{}, ErrInvalidCursor
+	}
+	return RankedPage{AfterScore: score, AfterID: id, Limit: limit}, nil
+}
+
+// RankedFromRequest reads the ranked page from the cursor and limit query parameters.
+func RankedFromRequest(r *http.Request) (RankedPage, error) {
+	limit, err := requestLimit(r)
+	if err != nil {
+		return RankedPage{}, err
+	}
+	return NewRanked(r.URL.Query().Get("cursor"), limit)
+}
+
+// IsFirst reports whether the page starts at the top of the list.
+func (p RankedPage) IsFirst() bool {
+	return p.AfterID == 0
+}
+
+// RankedResult is Result for ranked lists; the query must fetch Limit+1 rows.
+func RankedResult[T any](p RankedPage, items []T, keyOf func(T) (float64, int)) ([]T, string) {
+	if len(items) <= p.Limit {
+		return items, ""
+	}
+	items = items[:p.Limit]
+	score, id := keyOf(items[len(items)-1])
+	raw := strconv.FormatFloat(score, 'g', -1, 64) + ":" + strconv.Itoa(id)
+	return items, base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func requestLimit(r *http.Request) (int, error) {
+	limitStr := r.URL.Query().Get("limit")
+	if limitStr == "" {
+		return 0, nil
+	}
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil {
+		return 0, ErrInvalidLimit
+	}
+	return limit, nil
+}
+
+func clampLimit(limit int) (int, error) {
+	if limit < 0 {
+		return 0, ErrInvalidLimit
+	}
+	if limit == 0 {
+		return DefaultLimit, nil
+	}
+	if limit > MaxLimit {
+		return MaxLimit, nil
+	}
+	return limit, nil
+}
+
+func encodeCursor(id int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(id)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+
+	id, err := strconv.Atoi(string(raw))
+	if err != nil || id <= 0 {
+		return 0, ErrInvalidCursor
+	}
+	return id, nil
+}
@@ -0,0 +1,26 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/temuka-api-service/config"
+)
+
+// AcquireCooldown returns true when the key was free and is now held for the given duration.
+// Otherwise it returns false together with the time left before the key can be acquired again.
+func AcquireCooldown(ctx context.Context, key string, duration time.Duration) (bool, time.Duration, error) {
+	acquired, err := config.RedisClient.SetNX(ctx, key, 1, duration).Result()
+	if err != nil {
+		return false, 0, err
+	}
+	if acquired {
+		return true, 0, nil
+	}
+
+	remaining, err := config.RedisClient.TTL(ctx, key).Result()
+	if err != nil {
+		return false, 0, err
+	}
+	return false, remaining, nil
+}
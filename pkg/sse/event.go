@@ -1 +1,64 @@
 package sse
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var ErrStreamingUnsupported = errors.New("streaming unsupported")
+
+// Stream writes server-sent events to a response.
+type Stream struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+// NewStream sends the event stream headers. It fails when the response writer cannot flush.
+func NewStream(w http.ResponseWriter) (*Stream, error) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, ErrStreamingUnsupported
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// Keeps nginx from buffering the stream.
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	return &Stream{w: w, flusher: flusher}, nil
+}
+
+// Send writes an event whose data is the JSON encoding of data.
+func (s *Stream) Send(event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	if event != "" {
+		fmt.Fprintf(&b, "event: %s\n", event)
+	}
+	fmt.Fprintf(&b, "data: %s\n\n", payload)
+
+	if _, err := s.w.Write([]byte(b.String())); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+	return nil
+}
+
+// KeepAlive writes a comment line so that proxies do not close an idle stream.
+func (s *Stream) KeepAlive() error {
+	if _, err := s.w.Write([]byte(": keep-alive\n\n")); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+	return nil
+}
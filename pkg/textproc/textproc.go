@@ -0,0 +1,149 @@
+package textproc
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Entity types found in user-written text.
+const (
+	EntityHashtag = "hashtag"
+	EntityMention = "mention"
+)
+
+const (
+	MaxHashtagLength   = 64
+	MaxMentionLength   = 64
+	hashtagSign        = '#'
+	mentionSign        = '@'
+	mentionPunctuation = ".-"
+)
+
+// Entity is a hashtag or mention in a text. Start and End are rune offsets of the entity, sign
+// included, so that clients can turn it into a link. Value is the normalized tag or username
+// without its sign.
+type Entity struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+// Parse returns the hashtags and mentions of text in the order they appear.
+//
+// A sign only starts an entity at the beginning of the text or after a rune that cannot be part of a
+// word, so e-mail addresses and URL fragments are skipped. Hashtags are made of letters, digits and
+// underscores and need at least one letter; mentions may also contain dots and dashes, except at the
+// end where they are read as punctuation. Entities longer than the maximum length are ignored.
+func Parse(text string) []Entity {
+	runes := []rune(text)
+
+	var entities []Entity
+	for i := 0; i < len(runes); i++ {
+		sign := runes[i]
+		if sign != hashtagSign && sign != mentionSign {
+			continue
+		}
+		if i > 0 && !isBoundary(runes[i-1]) {
+			continue
+		}
+
+		end := i + 1
+		for end < len(runes) && isEntityRune(sign, runes[end]) {
+			end++
+		}
+		if sign == mentionSign {
+			for end > i+1 && strings.ContainsRune(mentionPunctuation, runes[end-1]) {
+				end--
+			}
+		}
+
+		body := runes[i+1 : end]
+		if entity, ok := newEntity(sign, body); ok {
+			entity.Start, entity.End = i, end
+			entities = append(entities, entity)
+		}
+		if end > i+1 {
+			i = end - 1
+		}
+	}
+	return entities
+}
+
+// Hashtags returns the distinct normalized hashtags of the entities.
+func Hashtags(entities []Entity) []string {
+	return distinctValues(entities, EntityHashtag)
+}
+
+// Mentions returns the distinct normalized usernames mentioned in the entities.
+func Mentions(entities []Entity) []string {
+	return distinctValues(entities, EntityMention)
+}
+
+// NormalizeTag turns user input such as "#Golang" into the form hashtags are stored in and reports
+// whether it is a valid hashtag.
+func NormalizeTag(tag string) (string, bool) {
+	tag = strings.TrimPrefix(strings.TrimSpace(tag), string(hashtagSign))
+	for _, r := range tag {
+		if !isEntityRune(hashtagSign, r) {
+			return "", false
+		}
+	}
+
+	entity, ok := newEntity(hashtagSign, []rune(tag))
+	if !ok {
+		return "", false
+	}
+	return entity.Value, true
+}
+
+func newEntity(sign rune, body []rune) (Entity, bool) {
+	if len(body) == 0 {
+		return Entity{}, false
+	}
+
+	if sign == hashtagSign {
+		if len(body) > MaxHashtagLength || !containsLetter(body) {
+			return Entity{}, false
+		}
+		return Entity{Type: EntityHashtag, Value: strings.ToLower(string(body))}, true
+	}
+
+	if len(body) > MaxMentionLength {
+		return Entity{}, false
+	}
+	return Entity{Type: EntityMention, Value: strings.ToLower(string(body))}, true
+}
+
+func isEntityRune(sign rune, r rune) bool {
+	if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+		return true
+	}
+	return sign == mentionSign && strings.ContainsRune(mentionPunctuation, r)
+}
+
+func isBoundary(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != hashtagSign && r != mentionSign && r != '/' && r != '&'
+}
+
+func containsLetter(runes []rune) bool {
+	for _, r := range runes {
+		if unicode.IsLetter(r) {
+			return true
+		}
+	}
+	return false
+}
+
+func distinctValues(entities []Entity, entityType string) []string {
+	seen := make(map[string]bool)
+	var values []string
+	for _, entity := range entities {
+		if entity.Type != entityType || seen[entity.Value] {
+			continue
+		}
+		seen[entity.Value] = true
+		values = append(values, entity.Value)
+	}
+	return values
+}
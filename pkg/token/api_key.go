@@ -0,0 +1,31 @@
+package token
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+const apiKeyPrefix = "tmk_"
+
+// GenerateAPIKey returns a new API key of the form tmk_<id>_<secret>, its displayable prefix
+// and the hash that is stored in place of the key.
+func GenerateAPIKey() (string, string, string, error) {
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", "", err
+	}
+
+	secret, err := GenerateRandomToken(32)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	prefix := apiKeyPrefix + hex.EncodeToString(id)
+	key := prefix + "_" + secret
+	return key, prefix, HashToken(key), nil
+}
+
+func IsAPIKey(value string) bool {
+	return strings.HasPrefix(value, apiKeyPrefix)
+}
@@ -0,0 +1,102 @@
+package token
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/temuka-api-service/internal/model"
+)
+
+const (
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+type Claims struct {
+	ID        int      `json:"id"`
+	Username  string   `json:"username"`
+	Email     string   `json:"email"`
+	Roles     []string `json:"roles,omitempty"`
+	SessionID string   `json:"sid"`
+	jwt.StandardClaims
+}
+
+func secretKey() []byte {
+	return []byte(os.Getenv("JWT_SECRET_KEY"))
+}
+
+func GenerateAccessToken(user *model.User, roles []string, sessionID string, now time.Time) (string, error) {
+	claims := Claims{
+		ID:        user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+		Roles:     roles,
+		SessionID: sessionID,
+		StandardClaims: jwt.StandardClaims{
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(AccessTokenTTL).Unix(),
+		},
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secretKey())
+}
+
+func ParseAccessToken(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return secretKey(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !token.Valid || claims.ID == 0 || claims.SessionID == "" || claims.ExpiresAt == 0 {
+		return nil, errors.New("token not valid")
+	}
+
+	return claims, nil
+}
+
+// GenerateRandomToken returns a URL-safe string built from n bytes of crypto/rand entropy.
+func GenerateRandomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func HashToken(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateRefreshToken returns an opaque "<session id>.<secret>" token and the hash of its secret,
+// which is the only part that gets stored server side.
+func GenerateRefreshToken(sessionID string) (string, string, error) {
+	secret, err := GenerateRandomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	return sessionID + "." + secret, HashToken(secret), nil
+}
+
+func SplitRefreshToken(refreshToken string) (string, string, error) {
+	sessionID, secret, found := strings.Cut(refreshToken, ".")
+	if !found || sessionID == "" || secret == "" {
+		return "", "", errors.New("malformed refresh token")
+	}
+	return sessionID, secret, nil
+}
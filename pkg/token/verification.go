@@ -0,0 +1,57 @@
+package token
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+const (
+	EmailVerificationTTL     = 24 * time.Hour
+	emailVerificationPurpose = "email_verification"
+)
+
+type EmailVerificationClaims struct {
+	UserID  int    `json:"user_id"`
+	Email   string `json:"email"`
+	Purpose string `json:"purpose"`
+	jwt.StandardClaims
+}
+
+// GenerateEmailVerificationToken signs the user's current email address, so changing the
+// address invalidates links that were sent to the old one.
+func GenerateEmailVerificationToken(userID int, email string, now time.Time) (string, error) {
+	claims := EmailVerificationClaims{
+		UserID:  userID,
+		Email:   email,
+		Purpose: emailVerificationPurpose,
+		StandardClaims: jwt.StandardClaims{
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(EmailVerificationTTL).Unix(),
+		},
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secretKey())
+}
+
+func ParseEmailVerificationToken(tokenString string) (*EmailVerificationClaims, error) {
+	claims := &EmailVerificationClaims{}
+
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return secretKey(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !token.Valid || claims.Purpose != emailVerificationPurpose || claims.UserID == 0 {
+		return nil, errors.New("token not valid")
+	}
+
+	return claims, nil
+}